@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var since, source string
+	var saved bool
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List wallpapers that were shown",
+		Long: `Lists every wallpaper that was shown, newest first, with how long it
+stayed and what happened to it (saved, deleted, banned, skipped).
+
+--since accepts a duration (90m, 24h, 7d) or a date (2006-01-02, RFC 3339).
+Use 'wallboy history apply <index>' to show an entry again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			filter := history.Filter{Source: source, Saved: saved}
+			if since != "" {
+				t, err := parseSince(since, time.Now())
+				if err != nil {
+					out.Error("%v", err)
+					return usageError(err)
+				}
+				filter.Since = t
+			}
+
+			engine, err := newEngine()
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			entries, err := engine.History(filter)
+			if err != nil {
+				out.Error("Failed to read history: %v", err)
+				return err
+			}
+
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+			if limit > 0 && len(entries) > limit {
+				entries = entries[:limit]
+			}
+
+			if structured() {
+				return emitList(entries)
+			}
+			if len(entries) == 0 {
+				out.Info("No history")
+				return nil
+			}
+
+			headers := []string{"#", "Shown", "Stayed", "Outcome", "Source", "Path"}
+			var rows [][]string
+			for _, e := range entries {
+				path := e.Path
+				if e.SavedPath != "" {
+					path = e.SavedPath
+				}
+				rows = append(rows, []string{
+					strconv.Itoa(e.Index),
+					e.ShownAt.Local().Format("2006-01-02 15:04"),
+					formatStay(e.Duration),
+					e.Outcome,
+					e.SourceID,
+					shortenPath(path),
+				})
+			}
+
+			out.Print("")
+			out.Table(headers, rows)
+			out.Print("")
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "only entries shown after this duration ago or date")
+	cmd.Flags().StringVar(&source, "source", "", "only entries from this source ID")
+	cmd.Flags().BoolVar(&saved, "saved", false, "only entries that were saved")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "maximum number of entries (0 for all)")
+
+	cmd.AddCommand(newHistoryApplyCmd())
+	return cmd
+}
+
+func newHistoryApplyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "apply <index>",
+		Short: "Set the wallpaper of a history entry again",
+		Long: `Sets the wallpaper of a history entry again. The index is the '#'
+column of 'wallboy history'. Saved copies are preferred; removed
+temporary downloads are fetched again from their URL.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			index, err := strconv.Atoi(args[0])
+			if err != nil {
+				err = fmt.Errorf("invalid history index: %s", args[0])
+				out.Error("%v", err)
+				return usageError(err)
+			}
+
+			engine, err := newEngine()
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			result, err := engine.ApplyHistory(cmd.Context(), index)
+			if err != nil {
+				out.Error("Failed to apply history entry: %v", err)
+				return err
+			}
+
+			if structured() {
+				return emit(result)
+			}
+
+			if dryRun {
+				printDryRun(result)
+				return nil
+			}
+
+			printWallpaperResult(result)
+			return nil
+		},
+	}
+}
+
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value: %s (use a duration like 24h or 7d, or a date like 2006-01-02)", value)
+}
+
+func formatStay(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	return fmt.Sprintf("%dd%02dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+}
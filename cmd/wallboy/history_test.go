@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"90m", now.Add(-90 * time.Minute)},
+		{"24h", now.Add(-24 * time.Hour)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2024-05-01T08:00:00Z", time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %v", got)
+		})
+	}
+
+	for _, value := range []string{"yesterday", "-5h", "d"} {
+		_, err := parseSince(value, now)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFormatStay(t *testing.T) {
+	assert.Equal(t, "42s", formatStay(42*time.Second))
+	assert.Equal(t, "5m", formatStay(5*time.Minute+10*time.Second))
+	assert.Equal(t, "2h05m", formatStay(2*time.Hour+5*time.Minute))
+	assert.Equal(t, "3d04h", formatStay(76*time.Hour))
+}
@@ -2,15 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/Artawower/wallboy/internal/colors"
 	"github.com/Artawower/wallboy/internal/config"
+	"github.com/Artawower/wallboy/internal/control"
 	"github.com/Artawower/wallboy/internal/core"
+	"github.com/Artawower/wallboy/internal/daemon"
 	"github.com/Artawower/wallboy/internal/state"
 	"github.com/Artawower/wallboy/internal/ui"
 	"github.com/spf13/cobra"
@@ -31,9 +39,12 @@ var (
 	cfgFile      string
 	themeFlag    string
 	providerFlag string
+	displayFlag  string
 	dryRun       bool
 	verbose      bool
 	quiet        bool
+	outputFormat string
+	noDaemon     bool
 
 	out *ui.Output
 )
@@ -50,25 +61,45 @@ and provides additional features like color analysis.`,
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.config/wallboy/config.toml)")
 	rootCmd.PersistentFlags().StringVar(&themeFlag, "theme", "", "theme to use (auto|light|dark)")
 	rootCmd.PersistentFlags().StringVar(&providerFlag, "provider", "", "use specific provider (bing, wallhaven, wallhalla, unsplash, local)")
+	rootCmd.PersistentFlags().StringVar(&displayFlag, "display", "", "target display (all|<name>, see 'wallboy displays')")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would be done without doing it")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "suppress non-error output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "output format (text|json|ndjson)")
+	rootCmd.PersistentFlags().BoolVar(&noDaemon, "no-daemon", false, "do not send commands to a running daemon")
+	rootCmd.PersistentPreRunE = validateOutput
 
 	rootCmd.AddCommand(
 		newInitCmd(),
 		newNextCmd(),
+		newPrevCmd(),
+		newSetCmd(),
+		newHistoryCmd(),
+		newLikeCmd(),
+		newDislikeCmd(),
+		newRateCmd(),
 		newSaveCmd(),
 		newShowCmd(),
 		newOpenCmd(),
 		newInfoCmd(),
 		newColorsCmd(),
 		newDeleteCmd(),
+		newBanCmd(),
+		newBansCmd(),
+		newStateCmd(),
 		newSourcesCmd(),
+		newDisplaysCmd(),
+		newDoctorCmd(),
 		newVersionCmd(),
+		newDaemonCmd(),
+		newWatchThemeCmd(),
 		newAgentInstallCmd(),
 		newAgentUninstallCmd(),
 		newAgentStatusCmd(),
+		newPauseCmd(),
+		newResumeCmd(),
 	)
+	markUsageErrors(rootCmd)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -81,11 +112,20 @@ and provides additional features like color analysis.`,
 	}()
 
 	if err := rootCmd.ExecuteContext(ctx); err != nil {
-		os.Exit(1)
+		if structured() {
+			_ = emit(newErrorBody(err))
+		}
+		_, code := classifyError(err)
+		os.Exit(code)
 	}
 }
 
 func initOutput() {
+	if structured() {
+		out = ui.NewOutput(io.Discard)
+		out.SetQuiet(true)
+		return
+	}
 	out = ui.DefaultOutput()
 	out.SetVerbose(verbose)
 	out.SetQuiet(quiet)
@@ -95,7 +135,7 @@ func newEngine() (*core.Engine, error) {
 	return newEngineWithQuery("")
 }
 
-func newEngineWithQuery(query string) (*core.Engine, error) {
+func newEngineWithQuery(query string, extra ...core.Option) (*core.Engine, error) {
 	var opts []core.Option
 	if themeFlag != "" {
 		opts = append(opts, core.WithThemeOverride(themeFlag))
@@ -103,6 +143,9 @@ func newEngineWithQuery(query string) (*core.Engine, error) {
 	if providerFlag != "" {
 		opts = append(opts, core.WithProviderOverride(providerFlag))
 	}
+	if displayFlag != "" {
+		opts = append(opts, core.WithDisplay(displayFlag))
+	}
 	if dryRun {
 		opts = append(opts, core.WithDryRun(true))
 	}
@@ -110,7 +153,11 @@ func newEngineWithQuery(query string) (*core.Engine, error) {
 		opts = append(opts, core.WithQueryOverride(query))
 	}
 
-	return core.New(cfgFile, opts...)
+	engine, err := core.New(cfgFile, append(opts, extra...)...)
+	if err != nil {
+		return nil, configError(err)
+	}
+	return engine, nil
 }
 
 func newInitCmd() *cobra.Command {
@@ -127,6 +174,9 @@ func newInitCmd() *cobra.Command {
 			configPath := filepath.Join(configDir, "config.toml")
 
 			if _, err := os.Stat(configPath); err == nil && !force {
+				if structured() {
+					return usageError(fmt.Errorf("configuration already exists at %s, use --force to overwrite", configPath))
+				}
 				out.Warning("Configuration already exists at %s", configPath)
 				out.Info("Use --force to overwrite")
 				return nil
@@ -150,6 +200,10 @@ func newInitCmd() *cobra.Command {
 				return err
 			}
 
+			if structured() {
+				return emit(initResult{Config: configPath, State: cfg.State.Path, Temp: config.GetTempDir()})
+			}
+
 			out.Success("Wallboy initialized")
 			out.Field("Config", configPath)
 			out.Field("State", cfg.State.Path)
@@ -166,9 +220,17 @@ func newInitCmd() *cobra.Command {
 	return cmd
 }
 
+type initResult struct {
+	Config string `json:"config"`
+	State  string `json:"state"`
+	Temp   string `json:"temp"`
+}
+
 func newNextCmd() *cobra.Command {
 	var openAfter bool
 	var queryFlag string
+	var spanFlag bool
+	var fromHistory bool
 
 	cmd := &cobra.Command{
 		Use:   "next",
@@ -176,36 +238,74 @@ func newNextCmd() *cobra.Command {
 		Long: `Selects and sets a random wallpaper from configured sources.
 
 For remote sources: downloads image to temp directory.
-Use 'wallboy save' to keep the image permanently.`,
+Use 'wallboy save' to keep the image permanently.
+
+With --display all every display gets its own image,
+with --display <name> only that display changes.
+With --span one ultra-wide image is sliced across all displays.
+
+After 'wallboy prev', next steps forward through history like a
+browser's forward button. --from-history only steps forward and
+never picks a new image.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			initOutput()
 
-			engine, err := newEngineWithQuery(queryFlag)
+			if spanFlag && displayFlag != "" {
+				out.Error("--span cannot be combined with --display")
+				return usageError(fmt.Errorf("--span cannot be combined with --display"))
+			}
+
+			if !spanFlag && !fromHistory && !openAfter && queryFlag == "" {
+				if remote := dialDaemon(); remote != nil {
+					defer remote.Close()
+					result, err := remote.Next(cmd.Context())
+					if !runLocally(err) {
+						if err != nil {
+							out.Error("Failed to set wallpaper: %v", err)
+							return err
+						}
+						return showWallpaperResult(result)
+					}
+				}
+			}
+
+			var opts []core.Option
+			if spanFlag {
+				opts = append(opts, core.WithSpan(true))
+			}
+
+			engine, err := newEngineWithQuery(queryFlag, opts...)
 			if err != nil {
 				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
 				return err
 			}
 
-			result, err := engine.Next(cmd.Context())
+			if displayFlag == core.DisplayAll && !fromHistory {
+				return runNextAll(cmd, engine)
+			}
+
+			next := engine.Advance
+			if fromHistory {
+				next = engine.Forward
+			}
+
+			result, err := next(cmd.Context())
 			if err != nil {
 				out.Error("Failed to set wallpaper: %v", err)
 				return err
 			}
 
+			if structured() {
+				engine.WaitPrefetch()
+				return emit(result)
+			}
+
 			if dryRun {
-				out.Info("Would set wallpaper to: %s", result.Path)
-				if result.IsTemp {
-					out.Info("(temporary - use 'wallboy save' to keep)")
-				}
+				printDryRun(result)
 				return nil
 			}
 
-			out.WallpaperInfo(result.Theme, result.SourceID, shortenPath(result.Path), result.Query, result.SetAt)
-
-			if result.IsTemp {
-				out.Print("")
-				out.Info("Use 'wallboy save' to keep this wallpaper")
-			}
+			printWallpaperResult(result)
 
 			if openAfter {
 				if err := engine.OpenInFinder(); err != nil {
@@ -221,10 +321,244 @@ Use 'wallboy save' to keep the image permanently.`,
 
 	cmd.Flags().BoolVar(&openAfter, "open", false, "open image in Finder after setting")
 	cmd.Flags().StringVar(&queryFlag, "query", "", "override search query for remote sources")
+	cmd.Flags().BoolVar(&spanFlag, "span", false, "slice one wide image across all displays")
+	cmd.Flags().BoolVar(&fromHistory, "from-history", false, "step forward through history instead of picking a new image")
 
 	return cmd
 }
 
+func newPrevCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "prev",
+		Short: "Go back to the previous wallpaper",
+		Long: `Steps back through the wallpapers that were shown, skipping images
+that no longer exist. Use 'wallboy next' to step forward again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			if remote := dialDaemon(); remote != nil {
+				defer remote.Close()
+				result, err := remote.Prev(cmd.Context())
+				if !runLocally(err) {
+					if err != nil {
+						out.Error("Failed to set wallpaper: %v", err)
+						return err
+					}
+					return showWallpaperResult(result)
+				}
+			}
+
+			engine, err := newEngine()
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			result, err := engine.Prev(cmd.Context())
+			if err != nil {
+				out.Error("Failed to set wallpaper: %v", err)
+				return err
+			}
+
+			if structured() {
+				return emit(result)
+			}
+
+			if dryRun {
+				printDryRun(result)
+				return nil
+			}
+
+			printWallpaperResult(result)
+			return nil
+		},
+	}
+}
+
+func newSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <path|url>",
+		Short: "Set a specific image as wallpaper",
+		Long: `Sets a local image file or an http(s) URL as wallpaper.
+
+URLs are downloaded to the temp directory like remote images;
+use 'wallboy save' to keep them.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			engine, err := newEngine()
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			result, err := engine.Set(cmd.Context(), args[0])
+			if err != nil {
+				out.Error("Failed to set wallpaper: %v", err)
+				return err
+			}
+
+			if structured() {
+				return emit(result)
+			}
+
+			if dryRun {
+				printDryRun(result)
+				return nil
+			}
+
+			printWallpaperResult(result)
+			return nil
+		},
+	}
+}
+
+func newLikeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "like",
+		Short: "Rate current wallpaper 5 so it is shown more often",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRate(func(e *core.Engine) (*core.RatingResult, error) { return e.Like() })
+		},
+	}
+}
+
+func newDislikeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "dislike",
+		Short: "Rate current wallpaper 1 so it is shown less often",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRate(func(e *core.Engine) (*core.RatingResult, error) { return e.Dislike() })
+		},
+	}
+}
+
+func newRateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rate <1-5>",
+		Short: "Rate current wallpaper from 1 to 5",
+		Long: `Stores a rating for the current wallpaper. Local images are picked with
+probability weighted by rating: unrated images count as 3, each step up
+doubles the chance and each step down halves it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			score, err := strconv.Atoi(args[0])
+			if err != nil {
+				initOutput()
+				out.Error("Invalid rating: %s", args[0])
+				return usageError(fmt.Errorf("invalid rating: %s", args[0]))
+			}
+			return runRate(func(e *core.Engine) (*core.RatingResult, error) { return e.Rate(score) })
+		},
+	}
+}
+
+func runRate(rate func(*core.Engine) (*core.RatingResult, error)) error {
+	initOutput()
+
+	engine, err := newEngine()
+	if err != nil {
+		out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+		return err
+	}
+
+	result, err := rate(engine)
+	if err != nil {
+		out.Error("Failed to rate wallpaper: %v", err)
+		return err
+	}
+
+	if structured() {
+		return emit(result)
+	}
+
+	if dryRun {
+		out.Info("Would rate %s: %d/5", shortenPath(result.Path), result.Rating)
+		return nil
+	}
+
+	out.Success("Rated %s: %d/5", shortenPath(result.Path), result.Rating)
+	return nil
+}
+
+func printDryRun(result *core.WallpaperResult) {
+	out.Info("Would set wallpaper to: %s", result.Path)
+	if result.IsTemp {
+		out.Info("(temporary - use 'wallboy save' to keep)")
+	}
+}
+
+func showWallpaperResult(result *core.WallpaperResult) error {
+	if structured() {
+		return emit(result)
+	}
+	printWallpaperResult(result)
+	return nil
+}
+
+func printWallpaperResult(result *core.WallpaperResult) {
+	if len(result.Displays) > 0 {
+		printDisplayResults(result.Displays)
+		return
+	}
+
+	out.WallpaperInfo(result.Theme, result.SourceID, shortenPath(result.Path), result.Query, result.SetAt)
+	if result.Display != "" {
+		out.Field("Display", result.Display)
+	}
+	if len(result.SpanDisplays) > 0 {
+		out.Field("Spanned", strings.Join(result.SpanDisplays, ", "))
+	}
+	if result.FromHistory {
+		out.Field("From", "history")
+	}
+
+	if result.IsTemp {
+		out.Print("")
+		out.Info("Use 'wallboy save' to keep this wallpaper")
+	}
+}
+
+func runNextAll(cmd *cobra.Command, engine *core.Engine) error {
+	results, err := engine.NextAll(cmd.Context())
+	if err != nil {
+		out.Error("Failed to set wallpaper: %v", err)
+		return err
+	}
+
+	if structured() {
+		engine.WaitPrefetch()
+		return emitList(results)
+	}
+
+	if dryRun {
+		for _, r := range results {
+			out.Info("Would set %s to: %s", r.Display, r.Path)
+		}
+		return nil
+	}
+
+	printDisplayResults(results)
+	engine.WaitPrefetch()
+
+	return nil
+}
+
+func printDisplayResults(results []*core.WallpaperResult) {
+	hasTemp := false
+	for _, r := range results {
+		out.WallpaperInfo(r.Theme, r.SourceID, shortenPath(r.Path), r.Query, r.SetAt)
+		out.Field("Display", r.Display)
+		hasTemp = hasTemp || r.IsTemp
+	}
+
+	if hasTemp {
+		out.Print("")
+		out.Info("Use 'wallboy save --display <name>' to keep a wallpaper")
+	}
+}
+
 func newSaveCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "save",
@@ -233,6 +567,28 @@ func newSaveCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			initOutput()
 
+			if remote := dialDaemon(); remote != nil {
+				defer remote.Close()
+				info, err := remote.Info()
+				if errors.Is(err, core.ErrNoWallpaper) {
+					return noWallpaper()
+				}
+				if !runLocally(err) {
+					if err != nil {
+						out.Error("Failed to save: %v", err)
+						return err
+					}
+					result, err := remote.Save()
+					if !runLocally(err) {
+						if err != nil {
+							out.Error("Failed to save: %v", err)
+							return err
+						}
+						return printSaved(result, !info.IsTemp)
+					}
+				}
+			}
+
 			engine, err := newEngine()
 			if err != nil {
 				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
@@ -241,31 +597,38 @@ func newSaveCmd() *cobra.Command {
 
 			result, err := engine.Save()
 			if err != nil {
-				if err.Error() == "no wallpaper currently set" {
-					out.Warning("No wallpaper currently set")
-					return nil
+				if errors.Is(err, core.ErrNoWallpaper) {
+					return noWallpaper()
 				}
 				out.Error("Failed to save: %v", err)
 				return err
 			}
 
-			if !engine.IsTempWallpaper() && result.Path == engine.CurrentPath() {
-				out.Info("Current wallpaper is already saved")
-				out.Field("Path", shortenPath(result.Path))
-				return nil
-			}
+			return printSaved(result, !engine.IsTempWallpaper() && result.Path == engine.CurrentPath())
+		},
+	}
+}
 
-			if dryRun {
-				out.Info("Would save: %s", shortenPath(result.Path))
-				return nil
-			}
+func printSaved(result *core.WallpaperResult, alreadySaved bool) error {
+	if structured() {
+		return emit(result)
+	}
 
-			out.Success("Wallpaper saved")
-			out.Field("Path", shortenPath(result.Path))
+	if alreadySaved {
+		out.Info("Current wallpaper is already saved")
+		out.Field("Path", shortenPath(result.Path))
+		return nil
+	}
 
-			return nil
-		},
+	if dryRun {
+		out.Info("Would save: %s", shortenPath(result.Path))
+		return nil
 	}
+
+	out.Success("Wallpaper saved")
+	out.Field("Path", shortenPath(result.Path))
+
+	return nil
 }
 
 func newShowCmd() *cobra.Command {
@@ -282,14 +645,16 @@ func newShowCmd() *cobra.Command {
 			}
 
 			if err := engine.OpenImage(); err != nil {
-				if err.Error() == "no wallpaper currently set" {
-					out.Warning("No wallpaper currently set")
-					return nil
+				if errors.Is(err, core.ErrNoWallpaper) {
+					return noWallpaper()
 				}
 				out.Error("Failed to open image: %v", err)
 				return err
 			}
 
+			if structured() {
+				return emit(pathResult{Path: engine.CurrentPath()})
+			}
 			return nil
 		},
 	}
@@ -309,20 +674,27 @@ func newOpenCmd() *cobra.Command {
 			}
 
 			if err := engine.OpenInFinder(); err != nil {
-				if err.Error() == "no wallpaper currently set" {
-					out.Warning("No wallpaper currently set")
-					return nil
+				if errors.Is(err, core.ErrNoWallpaper) {
+					return noWallpaper()
 				}
 				out.Error("Failed to open Finder: %v", err)
 				return err
 			}
 
+			if structured() {
+				return emit(pathResult{Path: engine.CurrentPath()})
+			}
+
 			out.Success("Opened in Finder")
 			return nil
 		},
 	}
 }
 
+type pathResult struct {
+	Path string `json:"path"`
+}
+
 func newInfoCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "info",
@@ -330,6 +702,17 @@ func newInfoCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			initOutput()
 
+			if remote := dialDaemon(); remote != nil {
+				defer remote.Close()
+				info, err := remote.Info()
+				if !runLocally(err) {
+					if err != nil {
+						return infoError(err)
+					}
+					return printInfo(info)
+				}
+			}
+
 			engine, err := newEngine()
 			if err != nil {
 				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
@@ -338,40 +721,75 @@ func newInfoCmd() *cobra.Command {
 
 			info, err := engine.Info()
 			if err != nil {
-				if err.Error() == "no wallpaper currently set" {
-					out.Warning("No wallpaper currently set")
-					return nil
-				}
-				out.Error("Failed to get info: %v", err)
-				return err
+				return infoError(err)
 			}
+			return printInfo(info)
+		},
+	}
+}
 
-			out.Print("")
-			out.Field("Path", info.Path)
-			out.Field("Theme", info.Theme)
-			out.Field("Source", info.SourceID)
-			if info.Query != "" {
-				out.Field("Query", info.Query)
-			}
-			out.Field("Set at", info.SetAt.Format("2006-01-02 15:04:05"))
-			if info.IsTemp {
-				out.FieldColored("Status", "temporary (use 'save' to keep)", ui.Yellow)
-			} else {
-				out.FieldColored("Status", "saved", ui.Green)
-			}
-			out.Print("")
+func infoError(err error) error {
+	if errors.Is(err, core.ErrNoWallpaper) {
+		return noWallpaper()
+	}
+	out.Error("Failed to get info: %v", err)
+	return err
+}
 
-			if !info.Exists {
-				out.Warning("File no longer exists")
-			}
+func printInfo(info *core.WallpaperInfo) error {
+	if structured() {
+		return emit(info)
+	}
 
-			return nil
-		},
+	out.Print("")
+	if info.Display != "" {
+		out.Field("Display", info.Display)
+	}
+	out.Field("Path", info.Path)
+	out.Field("Theme", info.Theme)
+	out.Field("Source", info.SourceID)
+	if info.Query != "" {
+		out.Field("Query", info.Query)
+	}
+	out.Field("Set at", info.SetAt.Format("2006-01-02 15:04:05"))
+	if info.Rating > 0 {
+		out.Field("Rating", fmt.Sprintf("%d/5", info.Rating))
+	}
+	if info.IsTemp {
+		out.FieldColored("Status", "temporary (use 'save' to keep)", ui.Yellow)
+	} else {
+		out.FieldColored("Status", "saved", ui.Green)
 	}
+	out.Print("")
+
+	if !info.Exists {
+		out.Warning("File no longer exists")
+	}
+
+	if len(info.Displays) > 0 {
+		headers := []string{"Display", "Source", "Status", "Path"}
+		var rows [][]string
+		for _, d := range info.Displays {
+			status := "saved"
+			if d.IsTemp {
+				status = "temporary"
+			}
+			rows = append(rows, []string{d.Display, d.SourceID, status, shortenPath(d.Path)})
+		}
+		out.Table(headers, rows)
+		out.Print("")
+	}
+
+	return nil
 }
 
 func newColorsCmd() *cobra.Command {
-	var topN int
+	var (
+		topN   int
+		render bool
+		format string
+		file   string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "colors",
@@ -379,29 +797,77 @@ func newColorsCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			initOutput()
 
-			engine, err := newEngine()
-			if err != nil {
-				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
-				return err
+			var analyze func() ([]core.Color, error)
+			if !render && file == "" {
+				if remote := dialDaemon(); remote != nil {
+					defer remote.Close()
+					analyze = func() ([]core.Color, error) {
+						palette, err := remote.AnalyzeColors(topN)
+						if !runLocally(err) {
+							return palette, err
+						}
+						engine, err := newEngine()
+						if err != nil {
+							return nil, err
+						}
+						return engine.AnalyzeColors(topN)
+					}
+				}
+			}
+
+			if analyze == nil {
+				engine, err := newEngine()
+				if err != nil {
+					out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+					return err
+				}
+
+				if render {
+					return runRenderTemplates(engine)
+				}
+
+				analyze = func() ([]core.Color, error) {
+					if file != "" {
+						return engine.AnalyzeFileColors(file, topN)
+					}
+					return engine.AnalyzeColors(topN)
+				}
+			}
+
+			if format != "" {
+				if !slices.Contains(colors.Formats, format) {
+					out.Error("Unsupported format: %s (must be one of %s)", format, strings.Join(colors.Formats, ", "))
+					return usageError(fmt.Errorf("unsupported format: %s", format))
+				}
+
+				result, err := analyze()
+				if err != nil {
+					out.Error("Failed to analyze colors: %v", err)
+					return err
+				}
+				return exportColors(format, result)
 			}
 
 			spinner := ui.NewSpinner(out, "Analyzing colors...")
 			spinner.Start()
 
-			colors, err := engine.AnalyzeColors(topN)
+			result, err := analyze()
 			spinner.Stop()
 
 			if err != nil {
-				if err.Error() == "no wallpaper currently set" {
-					out.Warning("No wallpaper currently set")
-					return nil
+				if errors.Is(err, core.ErrNoWallpaper) {
+					return noWallpaper()
 				}
 				out.Error("Failed to analyze colors: %v", err)
 				return err
 			}
 
+			if structured() {
+				return emitList(result)
+			}
+
 			out.Print("")
-			for _, c := range colors {
+			for _, c := range result {
 				out.ColorSwatch(c.Hex())
 			}
 			out.Print("")
@@ -411,10 +877,51 @@ func newColorsCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&topN, "top", 10, "number of colors to show")
+	cmd.Flags().BoolVar(&render, "render", false, "render [templates] from the current wallpaper")
+	cmd.Flags().StringVar(&format, "format", "", "export format ("+strings.Join(colors.Formats, ", ")+")")
+	cmd.Flags().StringVar(&file, "file", "", "analyze this image instead of the current wallpaper")
 
 	return cmd
 }
 
+func exportColors(format string, result []core.Color) error {
+	swatches := make([]colors.Swatch, len(result))
+	for i, c := range result {
+		swatches[i] = colors.Swatch{Color: colors.Color{R: c.R, G: c.G, B: c.B}, Share: c.Share}
+	}
+
+	if err := colors.Export(stdout, format, swatches); err != nil {
+		out.Error("Failed to export colors: %v", err)
+		return err
+	}
+	return nil
+}
+
+func runRenderTemplates(engine *core.Engine) error {
+	rendered, err := engine.RenderTemplates()
+	if structured() {
+		if err != nil {
+			return err
+		}
+		return emitList(rendered)
+	}
+	for _, path := range rendered {
+		if dryRun {
+			out.Info("Would render: %s", shortenPath(path))
+		} else {
+			out.Success("Rendered %s", shortenPath(path))
+		}
+	}
+	if err != nil {
+		if errors.Is(err, core.ErrNoWallpaper) {
+			return noWallpaper()
+		}
+		out.Error("Failed to render templates: %v", err)
+		return err
+	}
+	return nil
+}
+
 func newDeleteCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "delete",
@@ -424,6 +931,21 @@ and automatically sets the next random wallpaper.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			initOutput()
 
+			if remote := dialDaemon(); remote != nil {
+				defer remote.Close()
+				result, err := remote.Delete(cmd.Context())
+				if errors.Is(err, core.ErrNoWallpaper) {
+					return noWallpaper()
+				}
+				if !runLocally(err) {
+					if err != nil {
+						out.Error("Failed to delete: %v", err)
+						return err
+					}
+					return printDeleted(result)
+				}
+			}
+
 			engine, err := newEngine()
 			if err != nil {
 				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
@@ -432,8 +954,7 @@ and automatically sets the next random wallpaper.`,
 
 			info, _ := engine.Info()
 			if info == nil {
-				out.Warning("No wallpaper currently set")
-				return nil
+				return noWallpaper()
 			}
 
 			if dryRun {
@@ -448,7 +969,66 @@ and automatically sets the next random wallpaper.`,
 				return err
 			}
 
-			out.Success("Deleted previous wallpaper")
+			defer engine.WaitPrefetch()
+			return printDeleted(result)
+		},
+	}
+}
+
+func printDeleted(result *core.WallpaperResult) error {
+	if structured() {
+		return emit(result)
+	}
+
+	out.Success("Deleted previous wallpaper")
+	out.WallpaperInfo(result.Theme, result.SourceID, shortenPath(result.Path), result.Query, result.SetAt)
+
+	if result.IsTemp {
+		out.Print("")
+		out.Info("Use 'wallboy save' to keep this wallpaper")
+	}
+	return nil
+}
+
+func newBanCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ban",
+		Short: "Never show current wallpaper again and set next",
+		Long: `Adds the current wallpaper to the ban list and sets the next one.
+Local images are banned by path, remote images by provider and image ID.
+Use 'wallboy bans' to review or lift bans.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			engine, err := newEngine()
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			info, _ := engine.Info()
+			if info == nil {
+				return noWallpaper()
+			}
+
+			if dryRun {
+				out.Info("Would ban: %s", shortenPath(info.Path))
+				out.Info("Would set next wallpaper")
+				return nil
+			}
+
+			result, err := engine.Ban(cmd.Context())
+			if err != nil {
+				out.Error("Failed to ban: %v", err)
+				return err
+			}
+
+			if structured() {
+				engine.WaitPrefetch()
+				return emit(result)
+			}
+
+			out.Success("Banned %s", shortenPath(info.Path))
 			out.WallpaperInfo(result.Theme, result.SourceID, shortenPath(result.Path), result.Query, result.SetAt)
 
 			if result.IsTemp {
@@ -463,6 +1043,181 @@ and automatically sets the next random wallpaper.`,
 	}
 }
 
+func newBansCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bans",
+		Short: "Manage banned wallpapers",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List banned wallpapers",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				initOutput()
+
+				engine, err := newEngine()
+				if err != nil {
+					out.Error("Failed to load config: %v", err)
+					return err
+				}
+
+				bans := engine.Bans()
+				if structured() {
+					return emitList(bans)
+				}
+				if len(bans) == 0 {
+					out.Info("No banned wallpapers")
+					return nil
+				}
+
+				headers := []string{"Image", "Banned at"}
+				var rows [][]string
+				for _, b := range bans {
+					rows = append(rows, []string{shortenPath(b.Key), b.BannedAt.Format("2006-01-02 15:04:05")})
+				}
+
+				out.Print("")
+				out.Table(headers, rows)
+				out.Print("")
+
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "remove <path|provider:id>",
+			Short: "Remove a wallpaper from the ban list",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				initOutput()
+
+				engine, err := newEngine()
+				if err != nil {
+					out.Error("Failed to load config: %v", err)
+					return err
+				}
+
+				key, err := engine.Unban(args[0])
+				if err != nil {
+					out.Error("Failed to remove ban: %v", err)
+					return err
+				}
+
+				if structured() {
+					return emit(struct {
+						Key string `json:"key"`
+					}{key})
+				}
+
+				if dryRun {
+					out.Info("Would remove ban: %s", shortenPath(key))
+					return nil
+				}
+
+				out.Success("Removed ban: %s", shortenPath(key))
+				return nil
+			},
+		},
+	)
+
+	return cmd
+}
+
+type stateReport struct {
+	Path     string        `json:"path"`
+	Version  int           `json:"version"`
+	Issues   []state.Issue `json:"issues"`
+	Repaired bool          `json:"repaired"`
+}
+
+func newStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Inspect and repair the state file",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "check",
+			Short: "Report entries that point to missing files",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runStateCheck(false)
+			},
+		},
+		&cobra.Command{
+			Use:   "repair",
+			Short: "Remove entries that point to missing files",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runStateCheck(true)
+			},
+		},
+	)
+
+	return cmd
+}
+
+func runStateCheck(repair bool) error {
+	initOutput()
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		out.Error("Failed to load config: %v", err)
+		return configError(err)
+	}
+
+	st, err := state.Load(cfg.State.Path)
+	if err != nil {
+		out.Error("Failed to load state: %v", err)
+		return err
+	}
+
+	report := stateReport{Path: cfg.State.Path, Version: st.Version}
+	if repair && !dryRun {
+		report.Issues = st.Repair()
+		if len(report.Issues) > 0 {
+			if err := st.Save(); err != nil {
+				out.Error("Failed to save state: %v", err)
+				return err
+			}
+			report.Repaired = true
+		}
+	} else {
+		report.Issues = st.Check()
+	}
+
+	if structured() {
+		if report.Issues == nil {
+			report.Issues = []state.Issue{}
+		}
+		return emit(report)
+	}
+
+	if len(report.Issues) == 0 {
+		out.Success("State is consistent: %s", shortenPath(report.Path))
+		return nil
+	}
+
+	headers := []string{"Field", "Key", "Path"}
+	var rows [][]string
+	for _, issue := range report.Issues {
+		rows = append(rows, []string{issue.Field, issue.Key, shortenPath(issue.Path)})
+	}
+
+	out.Print("")
+	out.Table(headers, rows)
+	out.Print("")
+
+	switch {
+	case report.Repaired:
+		out.Success("Removed %d dangling entries", len(report.Issues))
+	case repair:
+		out.Info("Would remove %d dangling entries", len(report.Issues))
+	default:
+		out.Warning("%d dangling entries, run 'wallboy state repair' to remove them", len(report.Issues))
+	}
+	return nil
+}
+
 func newSourcesCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "sources",
@@ -477,6 +1232,9 @@ func newSourcesCmd() *cobra.Command {
 			}
 
 			sources := engine.ListSources()
+			if structured() {
+				return emitList(sources)
+			}
 			if len(sources) == 0 {
 				out.Warning("No sources configured")
 				out.Info("Edit your config file to add sources")
@@ -504,12 +1262,135 @@ func newSourcesCmd() *cobra.Command {
 	}
 }
 
+func newDisplaysCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "displays",
+		Short: "List connected displays",
+		Long: `Lists displays reported by the wallpaper backend.
+Use the names with --display to target a single display.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			engine, err := newEngine()
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			displays, err := engine.Displays()
+			if err != nil {
+				out.Error("Failed to list displays: %v", err)
+				return err
+			}
+
+			if structured() {
+				return emitList(displays)
+			}
+
+			if len(displays) == 0 {
+				out.Warning("No displays found")
+				return nil
+			}
+
+			headers := []string{"Name", "Primary", "Resolution", "Position", "Wallpaper"}
+			var rows [][]string
+			for _, d := range displays {
+				primary := ""
+				if d.Primary {
+					primary = "yes"
+				}
+				resolution, position := "", ""
+				if d.Width > 0 && d.Height > 0 {
+					resolution = fmt.Sprintf("%dx%d", d.Width, d.Height)
+					position = fmt.Sprintf("%d,%d", d.X, d.Y)
+				}
+				rows = append(rows, []string{d.Name, primary, resolution, position, shortenPath(d.Path)})
+			}
+
+			out.Print("")
+			out.Table(headers, rows)
+			out.Print("")
+
+			return nil
+		},
+	}
+}
+
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Show platform and wallpaper backend diagnostics",
+		Long: `Shows which platform and wallpaper backend wallboy uses,
+the tool it picked and the detection order of candidate tools.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			engine, err := newEngine()
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			report := engine.Doctor()
+			if structured() {
+				return emit(report)
+			}
+
+			out.Print("")
+			out.Field("Platform", report.Platform)
+			if report.Supported {
+				out.FieldColored("Supported", "yes", ui.Green)
+			} else {
+				out.FieldColored("Supported", "no", ui.Red)
+			}
+			if report.Backend != "" {
+				out.Field("Backend", report.Backend)
+			}
+			if report.Tool != "" {
+				out.Field("Tool", report.Tool)
+			} else if len(report.Tools) > 0 {
+				out.FieldColored("Tool", "none found", ui.Red)
+			}
+			out.Field("Theme", report.Theme)
+			if report.Scheduler {
+				out.Field("Scheduler", "available")
+			} else {
+				out.FieldColored("Scheduler", "unavailable", ui.Yellow)
+			}
+
+			if len(report.Tools) > 0 {
+				headers := []string{"#", "Tool", "Status", "Path"}
+				var rows [][]string
+				for i, t := range report.Tools {
+					status := "missing"
+					if t.Available {
+						status = "found"
+					}
+					if t.Name == report.Tool {
+						status = "selected"
+					}
+					rows = append(rows, []string{fmt.Sprintf("%d", i+1), t.Name, status, t.Path})
+				}
+				out.Print("")
+				out.Table(headers, rows)
+			}
+			out.Print("")
+
+			return nil
+		},
+	}
+}
+
 func newVersionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
 		Short: "Show version information",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			initOutput()
+			if structured() {
+				return emit(versionInfo{Version: version, Commit: commit, Date: date})
+			}
+
 			out.Print("wallboy %s", version)
 			if commit != "none" {
 				out.Field("Commit", commit)
@@ -517,23 +1398,183 @@ func newVersionCmd() *cobra.Command {
 			if date != "unknown" {
 				out.Field("Built", date)
 			}
+			return nil
 		},
 	}
 }
 
-func newAgentInstallCmd() *cobra.Command {
+type versionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+func newDaemonCmd() *cobra.Command {
 	var interval int
 
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run in the foreground and rotate wallpapers on an interval",
+		Long: `Keeps a single engine alive and rotates the wallpaper on an interval,
+keeping the next remote image prefetched. With theme.mode = "auto", the
+wallpaper also follows system theme changes. Send SIGHUP to reload the
+configuration and SIGTERM or SIGINT to stop.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			if interval < minInterval {
+				out.Error("Minimum interval is %d seconds", minInterval)
+				return usageError(fmt.Errorf("minimum interval is %d seconds", minInterval))
+			}
+
+			reload := make(chan os.Signal, 1)
+			signal.Notify(reload, syscall.SIGHUP)
+			defer signal.Stop(reload)
+
+			logger := newDaemonLogger()
+			d := daemon.New(func() (daemon.Engine, error) {
+				return newEngineWithQuery("", core.WithLogger(logger))
+			}, time.Duration(interval)*time.Second, logger)
+			if structured() {
+				d.SetListener(emitDaemonEvent)
+			}
+			d.SetControlSocket(control.DefaultSocketPath())
+
+			if err := d.Run(cmd.Context(), reload); err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&interval, "interval", defaultInterval, "interval in seconds (minimum 60)")
+
+	return cmd
+}
+
+func newWatchThemeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch-theme",
+		Short: "Switch wallpaper when the system theme changes",
+		Long: `Runs in the foreground and switches the wallpaper as soon as the system
+theme changes, restoring the last wallpaper used for the new theme.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			initOutput()
+
+			logger := newDaemonLogger()
+			engine, err := newEngineWithQuery("", core.WithLogger(logger))
+			if err != nil {
+				out.ErrorWithHint(err.Error(), "Run 'wallboy init' to create a default configuration")
+				return err
+			}
+
+			mode := engine.Config().Theme.Mode
+			if themeFlag != "" || (mode != config.ThemeModeAuto && mode != config.ThemeModeSolar) {
+				out.ErrorWithHint("Theme is not detected automatically", "Set theme.mode = \"auto\" or \"solar\" and omit --theme")
+				return configError(fmt.Errorf("theme mode is not auto"))
+			}
+
+			reload := make(chan os.Signal, 1)
+			signal.Notify(reload, syscall.SIGHUP)
+			defer signal.Stop(reload)
+
+			d := daemon.New(func() (daemon.Engine, error) {
+				if engine != nil {
+					first := engine
+					engine = nil
+					return first, nil
+				}
+				return newEngineWithQuery("", core.WithLogger(logger))
+			}, 0, logger)
+			if structured() {
+				d.SetListener(emitDaemonEvent)
+			}
+			d.SetControlSocket(control.DefaultSocketPath())
+
+			return d.Run(cmd.Context(), reload)
+		},
+	}
+}
+
+func newPauseCmd() *cobra.Command {
+	var toggle bool
+
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause interval rotation of the running daemon",
+		Long: `Stops the running daemon from rotating on its interval until
+'wallboy resume'. Theme changes are still followed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if toggle {
+				return runPause(nil)
+			}
+			paused := true
+			return runPause(&paused)
+		},
+	}
+
+	cmd.Flags().BoolVar(&toggle, "toggle", false, "resume if already paused")
+
+	return cmd
+}
+
+func newResumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resume interval rotation of the running daemon",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paused := false
+			return runPause(&paused)
+		},
+	}
+}
+
+func runPause(paused *bool) error {
+	initOutput()
+
+	client, err := control.Dial(control.DefaultSocketPath())
+	if err != nil {
+		out.ErrorWithHint("Daemon is not running", "Start it with 'wallboy daemon' or 'wallboy agent-install --daemon'")
+		return fmt.Errorf("daemon is not running: %w", err)
+	}
+	remote := &remoteEngine{client: client}
+	defer remote.Close()
+
+	result, err := remote.Pause(paused)
+	if err != nil {
+		out.Error("Failed to pause: %v", err)
+		return err
+	}
+
+	if structured() {
+		return emit(result)
+	}
+	if result.Paused {
+		out.Success("Rotation paused")
+	} else {
+		out.Success("Rotation resumed")
+	}
+	return nil
+}
+
+func newAgentInstallCmd() *cobra.Command {
+	var (
+		interval  int
+		runDaemon bool
+	)
+
 	cmd := &cobra.Command{
 		Use:   "agent-install",
 		Short: "Install background agent for auto-rotation",
-		Long:  "Installs a background agent that runs 'wallboy next' at regular intervals.",
+		Long: `Installs a background agent that runs 'wallboy next' at regular intervals.
+With --daemon, installs a long-running 'wallboy daemon' service instead.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			initOutput()
 
 			if interval < minInterval {
 				out.Error("Minimum interval is %d seconds", minInterval)
-				return fmt.Errorf("interval too small")
+				return usageError(fmt.Errorf("minimum interval is %d seconds", minInterval))
 			}
 
 			engine, err := newEngine()
@@ -542,12 +1583,24 @@ func newAgentInstallCmd() *cobra.Command {
 				return err
 			}
 
-			if err := engine.InstallAgent(time.Duration(interval) * time.Second); err != nil {
+			install := engine.InstallAgent
+			mode := "interval"
+			if runDaemon {
+				install = engine.InstallDaemonAgent
+				mode = "daemon"
+			}
+
+			if err := install(time.Duration(interval) * time.Second); err != nil {
 				out.Error("Failed to install agent: %v", err)
 				return err
 			}
 
+			if structured() {
+				return emitAgentStatus(engine)
+			}
+
 			out.Success("Agent installed")
+			out.Field("Mode", mode)
 			out.Field("Interval", formatDuration(time.Duration(interval)*time.Second))
 			out.Field("Log", shortenPath(filepath.Join(config.DefaultConfigDir(), "agent.log")))
 
@@ -556,6 +1609,7 @@ func newAgentInstallCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&interval, "interval", defaultInterval, "interval in seconds (minimum 60)")
+	cmd.Flags().BoolVar(&runDaemon, "daemon", false, "install a long-running daemon instead of an interval job")
 
 	return cmd
 }
@@ -580,6 +1634,9 @@ func newAgentUninstallCmd() *cobra.Command {
 			}
 
 			if !status.Installed {
+				if structured() {
+					return emit(status)
+				}
 				out.Info("Agent is not installed")
 				return nil
 			}
@@ -589,6 +1646,10 @@ func newAgentUninstallCmd() *cobra.Command {
 				return err
 			}
 
+			if structured() {
+				return emitAgentStatus(engine)
+			}
+
 			out.Success("Agent uninstalled")
 			return nil
 		},
@@ -616,7 +1677,11 @@ func newAgentStatusCmd() *cobra.Command {
 
 			if !status.Supported {
 				out.Error("Background scheduling is not supported on this platform")
-				return fmt.Errorf("scheduler not supported")
+				return unsupportedError(fmt.Errorf("background scheduling is not supported on this platform"))
+			}
+
+			if structured() {
+				return emit(status)
 			}
 
 			if !status.Installed {
@@ -626,6 +1691,9 @@ func newAgentStatusCmd() *cobra.Command {
 
 			if status.Running {
 				out.Success("Agent is running")
+				if status.Daemon {
+					out.Field("Mode", "daemon")
+				}
 				if status.Interval > 0 {
 					out.Field("Interval", formatDuration(status.Interval))
 				}
@@ -640,6 +1708,14 @@ func newAgentStatusCmd() *cobra.Command {
 	}
 }
 
+func emitAgentStatus(engine *core.Engine) error {
+	status, err := engine.AgentStatus()
+	if err != nil {
+		return err
+	}
+	return emit(status)
+}
+
 func shortenPath(path string) string {
 	home, err := os.UserHomeDir()
 	if err != nil {
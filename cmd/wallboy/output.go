@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Artawower/wallboy/internal/core"
+	"github.com/Artawower/wallboy/internal/daemon"
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputNDJSON}
+
+const (
+	exitError       = 1
+	exitUsage       = 2
+	exitConfig      = 3
+	exitNoWallpaper = 4
+	exitUnsupported = 5
+)
+
+const (
+	codeError       = "error"
+	codeUsage       = "usage"
+	codeConfig      = "config"
+	codeNoWallpaper = "no_wallpaper"
+	codeUnsupported = "unsupported"
+)
+
+var stdout io.Writer = os.Stdout
+
+type cliError struct {
+	code string
+	exit int
+	err  error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+
+func (e *cliError) Unwrap() error { return e.err }
+
+func usageError(err error) error {
+	return &cliError{code: codeUsage, exit: exitUsage, err: err}
+}
+
+func configError(err error) error {
+	return &cliError{code: codeConfig, exit: exitConfig, err: err}
+}
+
+func unsupportedError(err error) error {
+	return &cliError{code: codeUnsupported, exit: exitUnsupported, err: err}
+}
+
+func classifyError(err error) (string, int) {
+	var cerr *cliError
+	switch {
+	case errors.As(err, &cerr):
+		return cerr.code, cerr.exit
+	case errors.Is(err, core.ErrNoWallpaper):
+		return codeNoWallpaper, exitNoWallpaper
+	case errors.Is(err, platform.ErrUnsupported):
+		return codeUnsupported, exitUnsupported
+	case strings.HasPrefix(err.Error(), "unknown command"):
+		return codeUsage, exitUsage
+	}
+	return codeError, exitError
+}
+
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newErrorBody(err error) errorBody {
+	code, _ := classifyError(err)
+	return errorBody{Error: errorDetail{Code: code, Message: err.Error()}}
+}
+
+func structured() bool {
+	return outputFormat == outputJSON || outputFormat == outputNDJSON
+}
+
+func validateOutput(cmd *cobra.Command, args []string) error {
+	for _, f := range outputFormats {
+		if outputFormat == f {
+			silenceStructured(cmd)
+			return nil
+		}
+	}
+	return usageError(fmt.Errorf("unsupported output format: %s (must be one of %s)", outputFormat, strings.Join(outputFormats, ", ")))
+}
+
+func silenceStructured(cmd *cobra.Command) {
+	if structured() {
+		cmd.Root().SilenceErrors = true
+		cmd.Root().SilenceUsage = true
+	}
+}
+
+func markUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return usageError(err)
+	})
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(c *cobra.Command, a []string) error {
+			if err := args(c, a); err != nil {
+				silenceStructured(c)
+				return usageError(err)
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+func emit(v any) error {
+	enc := json.NewEncoder(stdout)
+	if outputFormat == outputJSON {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return nil
+}
+
+func emitList[T any](items []T) error {
+	if outputFormat == outputJSON {
+		if items == nil {
+			items = []T{}
+		}
+		return emit(items)
+	}
+	for _, item := range items {
+		if err := emit(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func noWallpaper() error {
+	if structured() {
+		return core.ErrNoWallpaper
+	}
+	out.Warning("No wallpaper currently set")
+	return nil
+}
+
+type daemonEvent struct {
+	Event  daemon.EventType      `json:"event"`
+	Theme  string                `json:"theme,omitempty"`
+	Result *core.WallpaperResult `json:"result,omitempty"`
+	Error  *errorDetail          `json:"error,omitempty"`
+}
+
+func newDaemonLogger() *log.Logger {
+	if structured() {
+		return log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return log.New(os.Stdout, "", log.LstdFlags)
+}
+
+func emitDaemonEvent(e daemon.Event) {
+	event := daemonEvent{Event: e.Type, Theme: string(e.Theme), Result: e.Result}
+	if e.Err != nil {
+		body := newErrorBody(e.Err)
+		event.Error = &body.Error
+	}
+	_ = json.NewEncoder(stdout).Encode(event)
+}
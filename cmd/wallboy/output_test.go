@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Artawower/wallboy/internal/core"
+	"github.com/Artawower/wallboy/internal/daemon"
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureOutput(t *testing.T, format string) *bytes.Buffer {
+	var buf bytes.Buffer
+	prevOut, prevFormat := stdout, outputFormat
+	stdout, outputFormat = &buf, format
+	t.Cleanup(func() { stdout, outputFormat = prevOut, prevFormat })
+	return &buf
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+		exit int
+	}{
+		{"generic", errors.New("boom"), codeError, exitError},
+		{"usage", usageError(errors.New("bad flag")), codeUsage, exitUsage},
+		{"config", configError(errors.New("bad config")), codeConfig, exitConfig},
+		{"no wallpaper", fmt.Errorf("failed: %w", core.ErrNoWallpaper), codeNoWallpaper, exitNoWallpaper},
+		{"unsupported platform", fmt.Errorf("failed: %w", platform.ErrUnsupported), codeUnsupported, exitUnsupported},
+		{"unsupported", unsupportedError(errors.New("no scheduler")), codeUnsupported, exitUnsupported},
+		{"unknown command", errors.New(`unknown command "nope" for "wallboy"`), codeUsage, exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, exit := classifyError(tt.err)
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.exit, exit)
+		})
+	}
+}
+
+func TestEmit_JSON(t *testing.T) {
+	buf := captureOutput(t, outputJSON)
+
+	require.NoError(t, emit(newErrorBody(core.ErrNoWallpaper)))
+	assert.JSONEq(t, `{"error":{"code":"no_wallpaper","message":"no wallpaper currently set"}}`, buf.String())
+}
+
+func TestEmitList(t *testing.T) {
+	sources := []core.SourceInfo{
+		{ID: "light-local-1", Theme: "light", Type: "local", Description: "/pics"},
+		{ID: "dark-wallhaven-1", Theme: "dark", Type: "wallhaven", Description: "nature"},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		buf := captureOutput(t, outputJSON)
+		require.NoError(t, emitList(sources))
+		assert.JSONEq(t, `[
+			{"id":"light-local-1","theme":"light","type":"local","description":"/pics"},
+			{"id":"dark-wallhaven-1","theme":"dark","type":"wallhaven","description":"nature"}
+		]`, buf.String())
+	})
+
+	t.Run("json empty", func(t *testing.T) {
+		buf := captureOutput(t, outputJSON)
+		require.NoError(t, emitList[core.SourceInfo](nil))
+		assert.JSONEq(t, `[]`, buf.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		buf := captureOutput(t, outputNDJSON)
+		require.NoError(t, emitList(sources))
+		assert.Equal(t,
+			`{"id":"light-local-1","theme":"light","type":"local","description":"/pics"}`+"\n"+
+				`{"id":"dark-wallhaven-1","theme":"dark","type":"wallhaven","description":"nature"}`+"\n",
+			buf.String())
+	})
+}
+
+func TestEmitDaemonEvent(t *testing.T) {
+	buf := captureOutput(t, outputNDJSON)
+
+	emitDaemonEvent(daemon.Event{Type: daemon.EventThemeChange, Theme: platform.ThemeDark, Result: &core.WallpaperResult{Path: "/tmp/a.jpg"}})
+	emitDaemonEvent(daemon.Event{Type: daemon.EventRotate, Err: core.ErrNoWallpaper})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Contains(t, string(lines[0]), `"event":"theme-change","theme":"dark","result":{"path":"/tmp/a.jpg"`)
+	assert.JSONEq(t, `{"event":"rotate","error":{"code":"no_wallpaper","message":"no wallpaper currently set"}}`, string(lines[1]))
+}
+
+func TestValidateOutput(t *testing.T) {
+	root := &cobra.Command{Use: "wallboy"}
+
+	captureOutput(t, "yaml")
+	err := validateOutput(root, nil)
+	require.Error(t, err)
+	code, _ := classifyError(err)
+	assert.Equal(t, codeUsage, code)
+
+	captureOutput(t, outputJSON)
+	require.NoError(t, validateOutput(root, nil))
+	assert.True(t, root.SilenceErrors)
+	assert.True(t, root.SilenceUsage)
+}
+
+func TestMarkUsageErrors(t *testing.T) {
+	root := &cobra.Command{Use: "wallboy", SilenceErrors: true, SilenceUsage: true}
+	root.AddCommand(&cobra.Command{
+		Use:  "set",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error { return nil },
+	})
+	markUsageErrors(root)
+
+	for _, args := range [][]string{{"set"}, {"set", "--bogus", "a"}} {
+		root.SetArgs(args)
+		err := root.Execute()
+		require.Error(t, err)
+		_, exit := classifyError(err)
+		assert.Equal(t, exitUsage, exit, "args %v", args)
+	}
+}
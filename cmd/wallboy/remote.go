@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Artawower/wallboy/internal/control"
+	"github.com/Artawower/wallboy/internal/core"
+)
+
+type remoteEngine struct {
+	client *control.Client
+}
+
+func dialDaemon() *remoteEngine {
+	if noDaemon || cfgFile != "" || themeFlag != "" || providerFlag != "" || displayFlag != "" || dryRun {
+		return nil
+	}
+	client, err := control.Dial(control.DefaultSocketPath())
+	if err != nil {
+		return nil
+	}
+	out.Debug("Using running daemon")
+	return &remoteEngine{client: client}
+}
+
+func runLocally(err error) bool {
+	if !errors.Is(err, control.ErrTimeout) {
+		return false
+	}
+	out.Warning("Daemon did not respond, running locally")
+	return true
+}
+
+func (r *remoteEngine) Close() {
+	r.client.Close()
+}
+
+func (r *remoteEngine) Next(ctx context.Context) (*core.WallpaperResult, error) {
+	return remoteCall[core.WallpaperResult](r, control.MethodNext, nil)
+}
+
+func (r *remoteEngine) Prev(ctx context.Context) (*core.WallpaperResult, error) {
+	return remoteCall[core.WallpaperResult](r, control.MethodPrev, nil)
+}
+
+func (r *remoteEngine) Save() (*core.WallpaperResult, error) {
+	return remoteCall[core.WallpaperResult](r, control.MethodSave, nil)
+}
+
+func (r *remoteEngine) Delete(ctx context.Context) (*core.WallpaperResult, error) {
+	return remoteCall[core.WallpaperResult](r, control.MethodDelete, nil)
+}
+
+func (r *remoteEngine) Info() (*core.WallpaperInfo, error) {
+	return remoteCall[core.WallpaperInfo](r, control.MethodInfo, nil)
+}
+
+func (r *remoteEngine) AnalyzeColors(topN int) ([]core.Color, error) {
+	result, err := remoteCall[[]core.Color](r, control.MethodPalette, control.PaletteParams{Top: topN})
+	if err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+func (r *remoteEngine) Pause(paused *bool) (*control.PauseResult, error) {
+	return remoteCall[control.PauseResult](r, control.MethodPause, control.PauseParams{Paused: paused})
+}
+
+func remoteCall[T any](r *remoteEngine, method string, params any) (*T, error) {
+	var result T
+	if err := r.client.Call(method, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -27,7 +27,26 @@ type ColorWithCount struct {
 	Count int
 }
 
+type Swatch struct {
+	Color Color
+	Share float64
+}
+
 func Analyze(path string, topN int) ([]Color, error) {
+	swatches, err := AnalyzeShares(path, topN)
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make([]Color, len(swatches))
+	for i, s := range swatches {
+		colors[i] = s.Color
+	}
+
+	return colors, nil
+}
+
+func AnalyzeShares(path string, topN int) ([]Swatch, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open image: %w", err)
@@ -51,12 +70,15 @@ func Analyze(path string, topN int) ([]Color, error) {
 		return clusters[i].Count > clusters[j].Count
 	})
 
-	colors := make([]Color, len(clusters))
+	swatches := make([]Swatch, len(clusters))
 	for i, c := range clusters {
-		colors[i] = c.Color
+		swatches[i] = Swatch{
+			Color: c.Color,
+			Share: float64(c.Count) / float64(len(pixels)),
+		}
 	}
 
-	return colors, nil
+	return swatches, nil
 }
 
 func resizeImage(img image.Image, maxWidth, maxHeight int) image.Image {
@@ -120,10 +142,20 @@ func kmeans(pixels []Color, k int, maxIterations int) []ColorWithCount {
 
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 
-	centroids := make([]Color, k)
 	perm := rng.Perm(len(pixels))
-	for i := 0; i < k; i++ {
-		centroids[i] = pixels[perm[i]]
+	centroids := make([]Color, 0, k)
+	seen := make(map[Color]bool)
+	for _, idx := range perm {
+		if len(centroids) == k {
+			break
+		}
+		if !seen[pixels[idx]] {
+			seen[pixels[idx]] = true
+			centroids = append(centroids, pixels[idx])
+		}
+	}
+	for i := 0; len(centroids) < k; i++ {
+		centroids = append(centroids, pixels[perm[i]])
 	}
 
 	var assignments []int
@@ -113,6 +113,23 @@ func TestAnalyze(t *testing.T) {
 		require.Len(t, colors, 3)
 	})
 
+	t.Run("shares", func(t *testing.T) {
+		imgPath := filepath.Join(tmpDir, "three_quarters.png")
+		createTestImage(t, imgPath, 100, 100, []color.Color{
+			color.RGBA{R: 255, G: 0, B: 0, A: 255},
+			color.RGBA{R: 255, G: 0, B: 0, A: 255},
+			color.RGBA{R: 255, G: 0, B: 0, A: 255},
+			color.RGBA{R: 0, G: 0, B: 255, A: 255},
+		})
+
+		swatches, err := AnalyzeShares(imgPath, 2)
+		require.NoError(t, err)
+		require.Len(t, swatches, 2)
+		assert.Equal(t, Color{R: 255}, swatches[0].Color)
+		assert.InDelta(t, 0.75, swatches[0].Share, 0.01)
+		assert.InDelta(t, 0.25, swatches[1].Share, 0.01)
+	})
+
 	t.Run("non-existent file", func(t *testing.T) {
 		colors, err := Analyze("/nonexistent/path.jpg", 3)
 		require.Error(t, err)
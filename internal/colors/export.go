@@ -0,0 +1,117 @@
+package colors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	FormatJSON       = "json"
+	FormatCSS        = "css"
+	FormatSCSS       = "scss"
+	FormatXresources = "xresources"
+	FormatGPL        = "gpl"
+	FormatASE        = "ase"
+	FormatTXT        = "txt"
+)
+
+var Formats = []string{FormatJSON, FormatCSS, FormatSCSS, FormatXresources, FormatGPL, FormatASE, FormatTXT}
+
+type jsonSwatch struct {
+	Hex   string   `json:"hex"`
+	RGB   [3]uint8 `json:"rgb"`
+	Share float64  `json:"share"`
+}
+
+func Export(w io.Writer, format string, swatches []Swatch) error {
+	switch format {
+	case FormatJSON:
+		return exportJSON(w, swatches)
+	case FormatCSS:
+		return exportLines(w, ":root {\n", "}\n", swatches, func(i int, s Swatch) string {
+			return fmt.Sprintf("  --color%d: %s; /* %s */\n", i, s.Color.Hex(), percent(s.Share))
+		})
+	case FormatSCSS:
+		return exportLines(w, "", "", swatches, func(i int, s Swatch) string {
+			return fmt.Sprintf("$color%d: %s; // %s\n", i, s.Color.Hex(), percent(s.Share))
+		})
+	case FormatXresources:
+		return exportLines(w, "", "", swatches, func(i int, s Swatch) string {
+			return fmt.Sprintf("*.color%d: %s\n", i, s.Color.Hex())
+		})
+	case FormatGPL:
+		return exportLines(w, "GIMP Palette\nName: wallboy\nColumns: 0\n#\n", "", swatches, func(i int, s Swatch) string {
+			return fmt.Sprintf("%3d %3d %3d\tcolor%d (%s)\n", s.Color.R, s.Color.G, s.Color.B, i, percent(s.Share))
+		})
+	case FormatASE:
+		return exportASE(w, swatches)
+	case FormatTXT:
+		return exportLines(w, "", "", swatches, func(i int, s Swatch) string {
+			return fmt.Sprintf("%s %s\n", s.Color.Hex(), percent(s.Share))
+		})
+	default:
+		return fmt.Errorf("unsupported format: %s (must be one of %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+func percent(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
+
+func exportJSON(w io.Writer, swatches []Swatch) error {
+	out := make([]jsonSwatch, len(swatches))
+	for i, s := range swatches {
+		out[i] = jsonSwatch{
+			Hex:   s.Color.Hex(),
+			RGB:   [3]uint8{s.Color.R, s.Color.G, s.Color.B},
+			Share: math.Round(s.Share*10000) / 10000,
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func exportLines(w io.Writer, header, footer string, swatches []Swatch, line func(int, Swatch) string) error {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for i, s := range swatches {
+		buf.WriteString(line(i, s))
+	}
+	buf.WriteString(footer)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func exportASE(w io.Writer, swatches []Swatch) error {
+	var buf bytes.Buffer
+	buf.WriteString("ASEF")
+	write := func(v interface{}) { _ = binary.Write(&buf, binary.BigEndian, v) }
+
+	write(uint16(1))
+	write(uint16(0))
+	write(uint32(len(swatches)))
+
+	for i, s := range swatches {
+		name := utf16.Encode([]rune(fmt.Sprintf("color%d", i)))
+		name = append(name, 0)
+
+		write(uint16(0x0001))
+		write(uint32(2 + len(name)*2 + 4 + 12 + 2))
+		write(uint16(len(name)))
+		write(name)
+		buf.WriteString("RGB ")
+		write([3]float32{float32(s.Color.R) / 255, float32(s.Color.G) / 255, float32(s.Color.B) / 255})
+		write(uint16(2))
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
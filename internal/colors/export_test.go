@@ -0,0 +1,71 @@
+package colors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSwatches = []Swatch{
+	{Color: Color{R: 255, G: 0, B: 0}, Share: 0.75},
+	{Color: Color{R: 0, G: 0, B: 255}, Share: 0.25},
+}
+
+func TestExport(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatCSS, ":root {\n  --color0: #ff0000; /* 75.0% */\n  --color1: #0000ff; /* 25.0% */\n}\n"},
+		{FormatSCSS, "$color0: #ff0000; // 75.0%\n$color1: #0000ff; // 25.0%\n"},
+		{FormatXresources, "*.color0: #ff0000\n*.color1: #0000ff\n"},
+		{FormatGPL, "GIMP Palette\nName: wallboy\nColumns: 0\n#\n255   0   0\tcolor0 (75.0%)\n  0   0 255\tcolor1 (25.0%)\n"},
+		{FormatTXT, "#ff0000 75.0%\n#0000ff 25.0%\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Export(&buf, tt.format, testSwatches))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Export(&buf, FormatJSON, testSwatches))
+
+		var got []map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "#ff0000", got[0]["hex"])
+		assert.Equal(t, []interface{}{255.0, 0.0, 0.0}, got[0]["rgb"])
+		assert.Equal(t, 0.75, got[0]["share"])
+	})
+
+	t.Run("ase", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Export(&buf, FormatASE, testSwatches))
+
+		data := buf.Bytes()
+		assert.Equal(t, "ASEF", string(data[:4]))
+		assert.Equal(t, uint16(1), binary.BigEndian.Uint16(data[4:6]))
+		assert.Equal(t, uint32(2), binary.BigEndian.Uint32(data[8:12]))
+		assert.Equal(t, uint16(1), binary.BigEndian.Uint16(data[12:14]))
+
+		blockLen := binary.BigEndian.Uint32(data[14:18])
+		assert.Equal(t, uint32(2+7*2+4+12+2), blockLen)
+		assert.Equal(t, "RGB ", string(data[18+2+14:18+2+14+4]))
+		assert.Len(t, data, 12+2*(6+int(blockLen)))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := Export(&bytes.Buffer{}, "pdf", testSwatches)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported format: pdf")
+	})
+}
@@ -0,0 +1,86 @@
+package colors
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	minAccentContrast = 0.3
+	accentSlots       = 6
+)
+
+var (
+	black = Color{}
+	white = Color{R: 255, G: 255, B: 255}
+)
+
+type Palette struct {
+	Colors     []Color
+	Background Color
+	Foreground Color
+	ANSI       []Color
+}
+
+func (c Color) RGB() string {
+	return fmt.Sprintf("%d, %d, %d", c.R, c.G, c.B)
+}
+
+func (c Color) Luminance() float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+}
+
+func (c Color) Mix(other Color, amount float64) Color {
+	amount = math.Max(0, math.Min(1, amount))
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*amount))
+	}
+	return Color{R: mix(c.R, other.R), G: mix(c.G, other.G), B: mix(c.B, other.B)}
+}
+
+func NewPalette(colors []Color, dark bool) Palette {
+	if len(colors) == 0 {
+		colors = []Color{{R: 128, G: 128, B: 128}}
+	}
+
+	byLuminance := append([]Color(nil), colors...)
+	sort.SliceStable(byLuminance, func(i, j int) bool {
+		return byLuminance[i].Luminance() < byLuminance[j].Luminance()
+	})
+	darkest := byLuminance[0]
+	lightest := byLuminance[len(byLuminance)-1]
+
+	p := Palette{Colors: colors}
+	if dark {
+		p.Background = darkest.Mix(black, 0.7)
+		p.Foreground = lightest.Mix(white, 0.7)
+	} else {
+		p.Background = lightest.Mix(white, 0.8)
+		p.Foreground = darkest.Mix(black, 0.7)
+	}
+
+	p.ANSI = make([]Color, 16)
+	p.ANSI[0] = p.Background
+	p.ANSI[7] = p.Foreground.Mix(p.Background, 0.25)
+	p.ANSI[8] = p.Background.Mix(p.Foreground, 0.35)
+	p.ANSI[15] = p.Foreground
+
+	for i := 0; i < accentSlots; i++ {
+		accent := ensureContrast(colors[i%len(colors)], p.Background, p.Foreground)
+		p.ANSI[i+1] = accent
+		p.ANSI[i+9] = accent.Mix(p.Foreground, 0.25)
+	}
+
+	return p
+}
+
+func ensureContrast(c, background, foreground Color) Color {
+	for step := 0; step < 10; step++ {
+		if math.Abs(c.Luminance()-background.Luminance()) >= minAccentContrast {
+			break
+		}
+		c = c.Mix(foreground, 0.15)
+	}
+	return c
+}
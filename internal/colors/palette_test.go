@@ -0,0 +1,50 @@
+package colors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColor_Mix(t *testing.T) {
+	c := Color{R: 100, G: 0, B: 200}
+	assert.Equal(t, c, c.Mix(white, 0))
+	assert.Equal(t, white, c.Mix(white, 1))
+	assert.Equal(t, Color{R: 50, G: 0, B: 100}, c.Mix(black, 0.5))
+	assert.Equal(t, "100, 0, 200", c.RGB())
+}
+
+func TestNewPalette(t *testing.T) {
+	input := []Color{
+		{R: 30, G: 40, B: 60},
+		{R: 200, G: 80, B: 40},
+		{R: 220, G: 220, B: 210},
+	}
+
+	t.Run("dark", func(t *testing.T) {
+		p := NewPalette(input, true)
+		assert.Equal(t, input, p.Colors)
+		assert.Less(t, p.Background.Luminance(), 0.1)
+		assert.Greater(t, p.Foreground.Luminance(), 0.8)
+		require.Len(t, p.ANSI, 16)
+		assert.Equal(t, p.Background, p.ANSI[0])
+		assert.Equal(t, p.Foreground, p.ANSI[15])
+		for i := 1; i <= 6; i++ {
+			assert.GreaterOrEqual(t, math.Abs(p.ANSI[i].Luminance()-p.Background.Luminance()), minAccentContrast-0.01, "slot %d", i)
+		}
+	})
+
+	t.Run("light", func(t *testing.T) {
+		p := NewPalette(input, false)
+		assert.Greater(t, p.Background.Luminance(), 0.9)
+		assert.Less(t, p.Foreground.Luminance(), 0.1)
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		p := NewPalette(nil, true)
+		require.Len(t, p.ANSI, 16)
+		require.Len(t, p.Colors, 1)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -15,6 +16,7 @@ const (
 	ThemeModeAuto  ThemeMode = "auto"
 	ThemeModeLight ThemeMode = "light"
 	ThemeModeDark  ThemeMode = "dark"
+	ThemeModeSolar ThemeMode = "solar"
 )
 
 type ProviderType string
@@ -41,19 +43,101 @@ type ThemeConfig struct {
 }
 
 type StateConfig struct {
-	Path string `toml:"path"`
+	Path    string `toml:"path"`
+	History string `toml:"history,omitempty"`
+}
+
+func (s StateConfig) HistoryPath() string {
+	if s.History != "" {
+		return s.History
+	}
+	return filepath.Join(filepath.Dir(s.Path), "history.jsonl")
+}
+
+const (
+	BackendAuto       = "auto"
+	BackendGnome      = "gnome"
+	BackendKDE        = "kde"
+	BackendWlroots    = "wlroots"
+	BackendSwww       = "swww"
+	BackendSwaybg     = "swaybg"
+	BackendX11        = "x11"
+	BackendFeh        = "feh"
+	BackendXwallpaper = "xwallpaper"
+	BackendNitrogen   = "nitrogen"
+	BackendHsetroot   = "hsetroot"
+)
+
+const (
+	ScaleFill   = "fill"
+	ScaleFit    = "fit"
+	ScaleCenter = "center"
+	ScaleTile   = "tile"
+)
+
+type PlatformConfig struct {
+	Backend            string  `toml:"backend"`
+	Mode               string  `toml:"mode,omitempty"`
+	TransitionType     string  `toml:"transition-type,omitempty"`
+	TransitionDuration float64 `toml:"transition-duration,omitempty"`
+	TransitionFPS      int     `toml:"transition-fps,omitempty"`
+	SetCommand         string  `toml:"set-command,omitempty"`
+	GetCommand         string  `toml:"get-command,omitempty"`
+	ThemeCommand       string  `toml:"theme-command,omitempty"`
+}
+
+func (p PlatformConfig) HasCommands() bool {
+	return p.SetCommand != "" || p.GetCommand != "" || p.ThemeCommand != ""
+}
+
+type HookEvent string
+
+const (
+	HookSet         HookEvent = "set"
+	HookSave        HookEvent = "save"
+	HookDelete      HookEvent = "delete"
+	HookThemeChange HookEvent = "theme-change"
+)
+
+type HookConfig struct {
+	On      HookEvent `toml:"on"`
+	Command []string  `toml:"command"`
+	Timeout float64   `toml:"timeout,omitempty"`
 }
 
 type ThemeSettings struct {
-	Mode ThemeMode `toml:"mode"`
+	Mode      ThemeMode `toml:"mode"`
+	Latitude  *float64  `toml:"latitude,omitempty"`
+	Longitude *float64  `toml:"longitude,omitempty"`
+	LightFrom string    `toml:"light-from,omitempty"`
+	DarkFrom  string    `toml:"dark-from,omitempty"`
+}
+
+func (t ThemeSettings) HasSchedule() bool {
+	return t.LightFrom != "" || t.DarkFrom != ""
+}
+
+func (t ThemeSettings) HasCoordinates() bool {
+	return t.Latitude != nil && t.Longitude != nil
+}
+
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day: %q (expected HH:MM)", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
 }
 
 type Config struct {
 	State     StateConfig               `toml:"state"`
 	Theme     ThemeSettings             `toml:"theme"`
+	Platform  PlatformConfig            `toml:"platform"`
 	Providers map[string]ProviderConfig `toml:"providers"`
 	Light     ThemeConfig               `toml:"light"`
 	Dark      ThemeConfig               `toml:"dark"`
+	Hooks     []HookConfig              `toml:"hooks"`
+	Templates map[string]string         `toml:"templates"`
 
 	configPath string
 }
@@ -78,6 +162,9 @@ func DefaultConfig() *Config {
 		Theme: ThemeSettings{
 			Mode: ThemeModeAuto,
 		},
+		Platform: PlatformConfig{
+			Backend: BackendAuto,
+		},
 		Providers: map[string]ProviderConfig{
 			"local": {Recursive: true},
 		},
@@ -121,6 +208,7 @@ func Load(path string) (*Config, error) {
 
 func (c *Config) postProcess() {
 	c.State.Path = expandPath(c.State.Path)
+	c.State.History = expandPath(c.State.History)
 
 	for name, p := range c.Providers {
 		p.Auth = expandEnv(p.Auth)
@@ -132,6 +220,26 @@ func (c *Config) postProcess() {
 
 	c.processTheme(&c.Light)
 	c.processTheme(&c.Dark)
+
+	if len(c.Templates) > 0 {
+		templates := make(map[string]string, len(c.Templates))
+		for src, dest := range c.Templates {
+			templates[c.resolvePath(src)] = c.resolvePath(dest)
+		}
+		c.Templates = templates
+	}
+}
+
+func (c *Config) resolvePath(path string) string {
+	path = expandPath(path)
+	if path == "" || filepath.IsAbs(path) || c.configPath == "" {
+		return path
+	}
+	dir, err := filepath.Abs(filepath.Dir(c.configPath))
+	if err != nil {
+		return path
+	}
+	return filepath.Join(dir, path)
 }
 
 func (c *Config) processTheme(theme *ThemeConfig) {
@@ -144,8 +252,24 @@ func (c *Config) processTheme(theme *ThemeConfig) {
 func (c *Config) Validate() error {
 	switch c.Theme.Mode {
 	case ThemeModeAuto, ThemeModeLight, ThemeModeDark:
+	case ThemeModeSolar:
+		if err := c.Theme.validateSolar(); err != nil {
+			return err
+		}
 	default:
-		return fmt.Errorf("invalid theme mode: %s (must be auto, light, or dark)", c.Theme.Mode)
+		return fmt.Errorf("invalid theme mode: %s (must be auto, light, dark, or solar)", c.Theme.Mode)
+	}
+
+	if !isValidBackend(c.Platform.Backend) {
+		return fmt.Errorf("invalid platform backend: %s", c.Platform.Backend)
+	}
+
+	if !isValidScaleMode(c.Platform.Mode) {
+		return fmt.Errorf("invalid platform mode: %s (must be fill, fit, center, or tile)", c.Platform.Mode)
+	}
+
+	if c.Platform.TransitionDuration < 0 || c.Platform.TransitionFPS < 0 {
+		return fmt.Errorf("platform transition settings must not be negative")
 	}
 
 	for name := range c.Providers {
@@ -157,6 +281,12 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for i, hook := range c.Hooks {
+		if err := hook.validate(); err != nil {
+			return fmt.Errorf("hooks[%d]: %w", i, err)
+		}
+	}
+
 	if err := c.validateThemeProviders("light", &c.Light); err != nil {
 		return err
 	}
@@ -167,6 +297,46 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (t ThemeSettings) validateSolar() error {
+	if t.HasSchedule() {
+		if t.LightFrom == "" || t.DarkFrom == "" {
+			return fmt.Errorf("theme: light-from and dark-from must be set together")
+		}
+		for _, value := range []string{t.LightFrom, t.DarkFrom} {
+			if _, err := ParseTimeOfDay(value); err != nil {
+				return fmt.Errorf("theme: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if !t.HasCoordinates() {
+		return fmt.Errorf("theme: solar mode requires latitude and longitude, or light-from and dark-from")
+	}
+	if *t.Latitude < -90 || *t.Latitude > 90 {
+		return fmt.Errorf("theme: latitude must be between -90 and 90")
+	}
+	if *t.Longitude < -180 || *t.Longitude > 180 {
+		return fmt.Errorf("theme: longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+func (h HookConfig) validate() error {
+	switch h.On {
+	case HookSet, HookSave, HookDelete, HookThemeChange:
+	default:
+		return fmt.Errorf("invalid hook event: %q (must be set, save, delete, or theme-change)", h.On)
+	}
+	if len(h.Command) == 0 || h.Command[0] == "" {
+		return fmt.Errorf("command is required")
+	}
+	if h.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
+
 func (c *Config) validateThemeProviders(themeName string, theme *ThemeConfig) error {
 	for _, p := range theme.Providers {
 		if _, ok := c.Providers[p]; !ok {
@@ -220,6 +390,23 @@ func isValidProvider(name string) bool {
 	return false
 }
 
+func isValidBackend(name string) bool {
+	switch name {
+	case "", BackendAuto, BackendGnome, BackendKDE, BackendWlroots, BackendSwww, BackendSwaybg,
+		BackendX11, BackendFeh, BackendXwallpaper, BackendNitrogen, BackendHsetroot:
+		return true
+	}
+	return false
+}
+
+func isValidScaleMode(mode string) bool {
+	switch mode {
+	case "", ScaleFill, ScaleFit, ScaleCenter, ScaleTile:
+		return true
+	}
+	return false
+}
+
 func (c *Config) GetThemeConfig(theme ThemeMode) *ThemeConfig {
 	switch theme {
 	case ThemeModeLight:
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,6 +25,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, ThemeModeAuto, cfg.Theme.Mode)
 	assert.NotEmpty(t, cfg.State.Path)
 	assert.Contains(t, cfg.State.Path, "state.json")
+	assert.Equal(t, filepath.Join(filepath.Dir(cfg.State.Path), "history.jsonl"), cfg.State.HistoryPath())
 
 	// Check both themes have default dirs
 	assert.NotEmpty(t, cfg.Light.Dirs)
@@ -44,6 +46,11 @@ func TestGetTempDir(t *testing.T) {
 	assert.Contains(t, dir, "wallboy")
 }
 
+func TestStateConfig_HistoryPath(t *testing.T) {
+	assert.Equal(t, "/data/wallboy/history.jsonl", StateConfig{Path: "/data/wallboy/state.json"}.HistoryPath())
+	assert.Equal(t, "/logs/shown.jsonl", StateConfig{Path: "/data/wallboy/state.json", History: "/logs/shown.jsonl"}.HistoryPath())
+}
+
 func TestLoad(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -59,6 +66,11 @@ func TestLoad(t *testing.T) {
 			validate: func(t *testing.T, cfg *Config) {
 				assert.Equal(t, ThemeModeAuto, cfg.Theme.Mode)
 
+				assert.Equal(t, BackendSwww, cfg.Platform.Backend)
+				assert.Equal(t, "grow", cfg.Platform.TransitionType)
+				assert.Equal(t, 1.5, cfg.Platform.TransitionDuration)
+				assert.Equal(t, 60, cfg.Platform.TransitionFPS)
+
 				// Check providers
 				unsplash, ok := cfg.Providers["unsplash"]
 				assert.True(t, ok)
@@ -215,6 +227,34 @@ func TestConfig_Validate(t *testing.T) {
 		assert.Contains(t, err.Error(), "queries are required")
 	})
 
+	t.Run("invalid platform backend", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Platform.Backend = "xfce"
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid platform backend")
+	})
+
+	t.Run("invalid platform mode", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Platform.Backend = BackendFeh
+		cfg.Platform.Mode = "stretch"
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid platform mode")
+	})
+
+	t.Run("negative transition duration", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Platform.TransitionDuration = -1
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must not be negative")
+	})
+
 	t.Run("local only does not require upload-dir or queries", func(t *testing.T) {
 		cfg := &Config{
 			Theme: ThemeSettings{Mode: ThemeModeLight},
@@ -233,6 +273,109 @@ func TestConfig_Validate(t *testing.T) {
 	})
 }
 
+func TestConfig_ValidateSolar(t *testing.T) {
+	lat, lon, bad := 52.52, 13.405, 120.0
+
+	tests := []struct {
+		name        string
+		theme       ThemeSettings
+		errContains string
+	}{
+		{"coordinates", ThemeSettings{Mode: ThemeModeSolar, Latitude: &lat, Longitude: &lon}, ""},
+		{"fixed times", ThemeSettings{Mode: ThemeModeSolar, LightFrom: "07:00", DarkFrom: "19:30"}, ""},
+		{"nothing set", ThemeSettings{Mode: ThemeModeSolar}, "requires latitude and longitude"},
+		{"latitude only", ThemeSettings{Mode: ThemeModeSolar, Latitude: &lat}, "requires latitude and longitude"},
+		{"latitude out of range", ThemeSettings{Mode: ThemeModeSolar, Latitude: &bad, Longitude: &lon}, "latitude must be between"},
+		{"only light-from", ThemeSettings{Mode: ThemeModeSolar, LightFrom: "07:00"}, "must be set together"},
+		{"malformed time", ThemeSettings{Mode: ThemeModeSolar, LightFrom: "7am", DarkFrom: "19:00"}, "invalid time of day"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Theme: tt.theme}
+			err := cfg.Validate()
+			if tt.errContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+
+	t.Run("load from file", func(t *testing.T) {
+		cfg, err := Load("testdata/solar.toml")
+		require.NoError(t, err)
+		assert.Equal(t, ThemeModeSolar, cfg.Theme.Mode)
+		require.True(t, cfg.Theme.HasCoordinates())
+		assert.InDelta(t, 52.52, *cfg.Theme.Latitude, 0.0001)
+	})
+}
+
+func TestConfig_Hooks(t *testing.T) {
+	t.Run("load from file", func(t *testing.T) {
+		cfg, err := Load("testdata/hooks.toml")
+		require.NoError(t, err)
+		require.Len(t, cfg.Hooks, 2)
+		assert.Equal(t, HookSet, cfg.Hooks[0].On)
+		assert.Equal(t, []string{"notify-send", "wallboy", "New wallpaper"}, cfg.Hooks[0].Command)
+		assert.Equal(t, HookThemeChange, cfg.Hooks[1].On)
+		assert.InDelta(t, 2.5, cfg.Hooks[1].Timeout, 0.0001)
+	})
+
+	tests := []struct {
+		name        string
+		hook        HookConfig
+		errContains string
+	}{
+		{"valid", HookConfig{On: HookSave, Command: []string{"true"}}, ""},
+		{"unknown event", HookConfig{On: "rotate", Command: []string{"true"}}, "invalid hook event"},
+		{"missing command", HookConfig{On: HookSet}, "command is required"},
+		{"negative timeout", HookConfig{On: HookDelete, Command: []string{"true"}, Timeout: -1}, "timeout must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Theme: ThemeSettings{Mode: ThemeModeLight}, Hooks: []HookConfig{tt.hook}}
+			err := cfg.Validate()
+			if tt.errContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "hooks[0]")
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
+
+func TestConfig_Templates(t *testing.T) {
+	cfg, err := Load("testdata/templates.toml")
+	require.NoError(t, err)
+
+	dir, err := filepath.Abs("testdata")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		filepath.Join(dir, "templates", "kitty.conf"): "/tmp/wallboy/kitty/colors.conf",
+		"/etc/wallboy/colors.css":                     filepath.Join(dir, "out", "colors.css"),
+	}, cfg.Templates)
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	d, err := ParseTimeOfDay("07:30")
+	require.NoError(t, err)
+	assert.Equal(t, 7*time.Hour+30*time.Minute, d)
+
+	_, err = ParseTimeOfDay("25:00")
+	assert.Error(t, err)
+}
+
+func TestPlatformConfig_HasCommands(t *testing.T) {
+	assert.False(t, PlatformConfig{Backend: BackendAuto}.HasCommands())
+	assert.True(t, PlatformConfig{SetCommand: "feh --bg-fill {path}"}.HasCommands())
+	assert.True(t, PlatformConfig{ThemeCommand: "darkman get"}.HasCommands())
+}
+
 func TestConfig_GetThemeConfig(t *testing.T) {
 	cfg := DefaultConfig()
 
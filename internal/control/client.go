@@ -0,0 +1,91 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/core"
+)
+
+const (
+	dialTimeout        = 200 * time.Millisecond
+	DefaultCallTimeout = 30 * time.Second
+)
+
+var ErrTimeout = errors.New("daemon did not respond in time")
+
+type Client struct {
+	conn    net.Conn
+	enc     *json.Encoder
+	dec     *json.Decoder
+	nextID  int64
+	timeout time.Duration
+}
+
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", path, err)
+	}
+	return &Client{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn), timeout: DefaultCallTimeout}, nil
+}
+
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+func (c *Client) Call(method string, params, result any) error {
+	c.nextID++
+	req := Request{JSONRPC: version, ID: c.nextID, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode params: %w", err)
+		}
+		req.Params = data
+	}
+
+	if c.timeout > 0 {
+		if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return fmt.Errorf("failed to set deadline: %w", err)
+		}
+	}
+
+	if err := c.enc.Encode(req); err != nil {
+		return fmt.Errorf("failed to send request: %w", timeoutError(err))
+	}
+
+	var resp Response
+	if err := c.dec.Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read response: %w", timeoutError(err))
+	}
+
+	if resp.Error != nil {
+		if resp.Error.Code == CodeNoWallpaper {
+			return core.ErrNoWallpaper
+		}
+		return resp.Error
+	}
+
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode result: %w", err)
+		}
+	}
+	return nil
+}
+
+func timeoutError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	return err
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,81 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/Artawower/wallboy/internal/config"
+)
+
+const (
+	MethodNext    = "next"
+	MethodPrev    = "prev"
+	MethodSave    = "save"
+	MethodDelete  = "delete"
+	MethodInfo    = "info"
+	MethodPause   = "pause"
+	MethodPalette = "palette"
+)
+
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternal       = -32603
+	CodeNoWallpaper    = 1
+)
+
+const version = "2.0"
+
+var (
+	ErrMethodNotFound = errors.New("method not found")
+	ErrInvalidParams  = errors.New("invalid params")
+)
+
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type PauseParams struct {
+	Paused *bool `json:"paused,omitempty"`
+}
+
+type PauseResult struct {
+	Paused bool `json:"paused"`
+}
+
+type PaletteParams struct {
+	Top int `json:"top,omitempty"`
+}
+
+func DefaultSocketPath() string {
+	if path := os.Getenv("WALLBOY_SOCKET"); path != "" {
+		return path
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "wallboy", "wallboy.sock")
+	}
+	return filepath.Join(config.DefaultConfigDir(), "wallboy.sock")
+}
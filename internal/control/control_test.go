@@ -0,0 +1,144 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoHandler(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "echo":
+		return params, nil
+	case MethodInfo:
+		return nil, core.ErrNoWallpaper
+	case "fail":
+		return nil, errors.New("boom")
+	}
+	return nil, ErrMethodNotFound
+}
+
+func startServer(t *testing.T, path string) {
+	srv, err := Listen(path, echoHandler, log.New(&bytes.Buffer{}, "", 0))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		assert.NoFileExists(t, path)
+	})
+}
+
+func TestClientServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallboy.sock")
+	startServer(t, path)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	client, err := Dial(path)
+	require.NoError(t, err)
+	defer client.Close()
+
+	var got PaletteParams
+	require.NoError(t, client.Call("echo", PaletteParams{Top: 4}, &got))
+	assert.Equal(t, 4, got.Top)
+
+	assert.ErrorIs(t, client.Call(MethodInfo, nil, nil), core.ErrNoWallpaper)
+
+	var rpcErr *Error
+	require.ErrorAs(t, client.Call("fail", nil, nil), &rpcErr)
+	assert.Equal(t, CodeInternal, rpcErr.Code)
+	assert.Equal(t, "boom", rpcErr.Message)
+
+	require.ErrorAs(t, client.Call("missing", nil, nil), &rpcErr)
+	assert.Equal(t, CodeMethodNotFound, rpcErr.Code)
+}
+
+func TestServer_ParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallboy.sock")
+	startServer(t, path)
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("{not json}\n"))
+	require.NoError(t, err)
+
+	var resp Response
+	require.NoError(t, json.NewDecoder(conn).Decode(&resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, CodeParseError, resp.Error.Code)
+}
+
+func TestClient_Timeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallboy.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	client, err := Dial(path)
+	require.NoError(t, err)
+	defer client.Close()
+	client.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	err = client.Call(MethodInfo, nil, nil)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	(<-accepted).Close()
+}
+
+func TestListen_SocketInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallboy.sock")
+	startServer(t, path)
+
+	_, err := Listen(path, echoHandler, log.New(&bytes.Buffer{}, "", 0))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "another instance is listening")
+}
+
+func TestListen_RemovesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallboy.sock")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+
+	startServer(t, path)
+
+	client, err := Dial(path)
+	require.NoError(t, err)
+	client.Close()
+}
+
+func TestDefaultSocketPath(t *testing.T) {
+	t.Setenv("WALLBOY_SOCKET", "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	assert.Equal(t, "/run/user/1000/wallboy/wallboy.sock", DefaultSocketPath())
+
+	t.Setenv("WALLBOY_SOCKET", "/tmp/custom.sock")
+	assert.Equal(t, "/tmp/custom.sock", DefaultSocketPath())
+}
@@ -0,0 +1,151 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/core"
+)
+
+type Handler func(ctx context.Context, method string, params json.RawMessage) (any, error)
+
+type Server struct {
+	path     string
+	listener net.Listener
+	handler  Handler
+	logger   *log.Logger
+	wg       sync.WaitGroup
+}
+
+func Listen(path string, handler Handler, logger *log.Logger) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another instance is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	return &Server{path: path, listener: listener, handler: handler, logger: logger}, nil
+}
+
+func (s *Server) Path() string {
+	return s.path
+}
+
+func (s *Server) Serve(ctx context.Context) error {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-connCtx.Done()
+		s.listener.Close()
+	}()
+
+	defer func() {
+		cancel()
+		s.wg.Wait()
+		os.Remove(s.path)
+	}()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if connCtx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serveConn(connCtx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.SetDeadline(time.Now())
+	}()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				_ = enc.Encode(Response{JSONRPC: version, Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			}
+			return
+		}
+
+		if err := enc.Encode(s.dispatch(ctx, req)); err != nil {
+			s.logger.Printf("control: failed to write response: %v", err)
+			return
+		}
+	}
+}
+
+func (s *Server) dispatch(ctx context.Context, req Request) Response {
+	resp := Response{JSONRPC: version, ID: req.ID}
+	if req.Method == "" {
+		resp.Error = &Error{Code: CodeInvalidRequest, Message: "method is required"}
+		return resp
+	}
+
+	result, err := s.handler(ctx, req.Method, req.Params)
+	if err != nil {
+		resp.Error = newError(err)
+		return resp
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: CodeInternal, Message: fmt.Sprintf("failed to encode result: %v", err)}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+func newError(err error) *Error {
+	code := CodeInternal
+	switch {
+	case errors.Is(err, ErrMethodNotFound):
+		code = CodeMethodNotFound
+	case errors.Is(err, ErrInvalidParams):
+		code = CodeInvalidParams
+	case errors.Is(err, core.ErrNoWallpaper):
+		code = CodeNoWallpaper
+	}
+	return &Error{Code: code, Message: err.Error()}
+}
@@ -2,17 +2,28 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Artawower/wallboy/internal/colors"
 	"github.com/Artawower/wallboy/internal/config"
 	"github.com/Artawower/wallboy/internal/datasource"
+	"github.com/Artawower/wallboy/internal/history"
+	"github.com/Artawower/wallboy/internal/hooks"
 	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/Artawower/wallboy/internal/provider"
+	"github.com/Artawower/wallboy/internal/span"
 	"github.com/Artawower/wallboy/internal/state"
+	"github.com/Artawower/wallboy/internal/templates"
+	"github.com/Artawower/wallboy/internal/theme"
 )
 
 type Engine struct {
@@ -21,12 +32,40 @@ type Engine struct {
 	platform platform.Platform
 	manager  *datasource.Manager
 
+	managerTheme     Theme
 	themeOverride    string
 	providerOverride string
 	queryOverride    string
+	display          string
+	span             bool
 	dryRun           bool
+
+	hooks        *hooks.Runner
+	history      *history.Log
+	historyStore history.Store
+	logger       *log.Logger
+	providers    provider.Factory
+	store        state.Store
+	now          func() time.Time
+
+	pending     []string
+	imageFilter func(datasource.Image) bool
 }
 
+const DisplayAll = "all"
+
+var (
+	ErrNoWallpaper = errors.New("no wallpaper currently set")
+	ErrNoSources   = errors.New("no sources available")
+)
+
+const paletteSize = 8
+
+const (
+	manualSourceSuffix = "-manual"
+	urlSourceSuffix    = "-url"
+)
+
 type Option func(*Engine)
 
 func WithThemeOverride(theme string) Option {
@@ -45,6 +84,38 @@ func WithQueryOverride(query string) Option {
 	return func(e *Engine) { e.queryOverride = query }
 }
 
+func WithDisplay(display string) Option {
+	return func(e *Engine) { e.display = display }
+}
+
+func WithSpan(span bool) Option {
+	return func(e *Engine) { e.span = span }
+}
+
+func WithLogger(logger *log.Logger) Option {
+	return func(e *Engine) { e.logger = logger }
+}
+
+func WithPlatform(p platform.Platform) Option {
+	return func(e *Engine) { e.platform = p }
+}
+
+func WithProviderFactory(factory provider.Factory) Option {
+	return func(e *Engine) { e.providers = factory }
+}
+
+func WithStateStore(store state.Store) Option {
+	return func(e *Engine) { e.store = store }
+}
+
+func WithHistoryStore(store history.Store) Option {
+	return func(e *Engine) { e.historyStore = store }
+}
+
+func WithClock(now func() time.Time) Option {
+	return func(e *Engine) { e.now = now }
+}
+
 func New(configPath string, opts ...Option) (*Engine, error) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
@@ -55,19 +126,54 @@ func New(configPath string, opts ...Option) (*Engine, error) {
 		return nil, fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	st, err := state.Load(cfg.State.Path)
+	e := &Engine{
+		config: cfg,
+		logger: log.New(os.Stderr, "wallboy: ", 0),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.platform == nil {
+		e.platform = platform.Current()
+	}
+
+	if e.store != nil {
+		e.state, err = state.LoadFrom(e.store)
+	} else {
+		e.state, err = state.Load(cfg.State.Path)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to load state: %w", err)
 	}
+	e.state.SetClock(e.now)
 
-	e := &Engine{
-		config:   cfg,
-		state:    st,
-		platform: platform.Current(),
+	e.hooks = newHookRunner(cfg.Hooks, e.logger)
+	if e.historyStore != nil {
+		e.history = history.NewFrom(e.historyStore)
+	} else {
+		e.history = history.New(cfg.State.HistoryPath())
 	}
 
-	for _, opt := range opts {
-		opt(e)
+	if configurable, ok := e.platform.(platform.Configurable); ok {
+		if err := configurable.Configure(e.platformSettings()); err != nil {
+			return nil, fmt.Errorf("failed to configure platform: %w", err)
+		}
+	}
+
+	if cfg.Platform.HasCommands() {
+		e.platform, err = platform.NewCommandPlatform(e.platform, platform.CommandSettings{
+			SetCommand:   cfg.Platform.SetCommand,
+			GetCommand:   cfg.Platform.GetCommand,
+			ThemeCommand: cfg.Platform.ThemeCommand,
+			Theme: func() platform.Theme {
+				return e.detectTheme().ToPlatformTheme()
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure platform: %w", err)
+		}
 	}
 
 	e.initManager()
@@ -78,6 +184,7 @@ func New(configPath string, opts ...Option) (*Engine, error) {
 func (e *Engine) initManager() {
 	theme := e.detectTheme()
 	themeMode := theme.ToConfigMode()
+	e.managerTheme = theme
 	uploadDir := e.config.GetUploadDir(themeMode)
 	tempDir := config.GetTempDir()
 
@@ -93,9 +200,57 @@ func (e *Engine) initManager() {
 	queries := e.config.GetQueries(themeMode)
 	for name, providerCfg := range e.config.GetRemoteProviders(themeMode) {
 		id := fmt.Sprintf("%s-%s", theme, name)
-		source := datasource.NewRemoteSource(id, name, providerCfg.Auth, string(theme), uploadDir, tempDir, queries, providerCfg.Weight, e.state)
+		source := datasource.NewRemoteSourceWithProvider(id, e.newProvider(name, providerCfg.Auth), string(theme), uploadDir, tempDir, queries, providerCfg.Weight, e.state)
 		e.manager.AddRemoteSource(source)
 	}
+
+	e.manager.SetRatingStore(e.state)
+	e.manager.SetBanList(e.state)
+}
+
+func (e *Engine) newProvider(name, auth string) provider.Provider {
+	if e.providers != nil {
+		if p := e.providers(name, auth); p != nil {
+			return p
+		}
+	}
+	return provider.NewProvider(name, auth, nil)
+}
+
+func (e *Engine) clock() time.Time {
+	if e.now != nil {
+		return e.now()
+	}
+	return time.Now()
+}
+
+func newHookRunner(configs []config.HookConfig, logger *log.Logger) *hooks.Runner {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	list := make([]hooks.Hook, len(configs))
+	for i, h := range configs {
+		list[i] = hooks.Hook{
+			On:      string(h.On),
+			Command: h.Command,
+			Timeout: time.Duration(h.Timeout * float64(time.Second)),
+		}
+	}
+	return hooks.NewRunner(list, logger)
+}
+
+func (e *Engine) platformSettings() platform.Settings {
+	p := e.config.Platform
+	return platform.Settings{
+		Backend: p.Backend,
+		Mode:    p.Mode,
+		Transition: platform.TransitionSettings{
+			Type:     p.TransitionType,
+			Duration: time.Duration(p.TransitionDuration * float64(time.Second)),
+			FPS:      p.TransitionFPS,
+		},
+	}
 }
 
 func (e *Engine) detectTheme() Theme {
@@ -115,6 +270,8 @@ func (e *Engine) detectTheme() Theme {
 		return ThemeDark
 	case config.ThemeModeAuto:
 		return FromPlatformTheme(e.platform.Theme().Detect())
+	case config.ThemeModeSolar:
+		return Theme(theme.Solar(e.config.Theme, e.clock()))
 	default:
 		return ThemeLight
 	}
@@ -132,55 +289,506 @@ func (t Theme) ToConfigMode() config.ThemeMode {
 }
 
 func (e *Engine) Next(ctx context.Context) (*WallpaperResult, error) {
-	currentTheme := e.detectTheme()
-	themeName := string(currentTheme)
+	if e.span {
+		return e.nextSpan(ctx)
+	}
 
-	if e.state.HasCurrent() && e.state.IsTempWallpaper() {
-		os.Remove(e.state.Current.Path)
+	switch e.display {
+	case "":
+	case DisplayAll:
+		results, err := e.NextAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result := *results[0]
+		result.Displays = results
+		return &result, nil
+	default:
+		return e.nextOnDisplay(ctx, e.display)
 	}
 
-	var img *datasource.Image
-	var isTemp bool
-	var err error
+	themeName := string(e.detectTheme())
 
-	if e.providerOverride != "" {
-		img, isTemp, err = e.pickFromProvider(ctx, themeName, e.providerOverride)
-	} else if e.queryOverride != "" {
-		img, isTemp, err = e.pickFromRemote(ctx, themeName)
+	img, isTemp, err := e.pickImage(ctx, themeName)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.dryRun {
+		return newWallpaperResult(img, isTemp, e.clock(), ""), nil
+	}
+
+	if err := e.platform.Wallpaper().Set(img.Path); err != nil {
+		return nil, fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	previous := e.state.TempPaths()
+	e.state.SetCurrent(img.Path, img.SourceID, img.Theme, img.Query, img.URL, isTemp)
+	e.releaseTemp(previous)
+	_ = e.state.Save()
+
+	result := newWallpaperResult(img, isTemp, e.state.CurrentEntry().SetAt, "")
+	e.afterChange(ctx, config.HookSet, result)
+	return result, nil
+}
+
+func (e *Engine) Set(ctx context.Context, ref string) (*WallpaperResult, error) {
+	if e.span {
+		return nil, fmt.Errorf("span mode cannot be combined with an explicit image")
+	}
+
+	themeName := string(e.detectTheme())
+	display := e.targetDisplay()
+
+	var svc platform.DisplayWallpaperService
+	if display != "" {
+		var err error
+		if svc, err = e.displayWallpaper(); err != nil {
+			return nil, err
+		}
+		if displays, err := svc.Displays(); err == nil && !hasDisplay(displays, display) {
+			return nil, fmt.Errorf("unknown display: %s (available: %s)", display, displayNames(displays))
+		}
+	}
+
+	img := &datasource.Image{Theme: themeName}
+	isTemp := datasource.IsURL(ref)
+
+	if isTemp {
+		img.SourceID = themeName + urlSourceSuffix
+		img.URL = ref
+		img.Path = ref
+		if e.dryRun {
+			return newWallpaperResult(img, isTemp, e.clock(), display), nil
+		}
+
+		path, err := datasource.DownloadURL(ctx, ref, config.GetTempDir())
+		if err != nil {
+			return nil, fmt.Errorf("failed to download image: %w", err)
+		}
+		img.Path = path
 	} else {
-		img, isTemp, err = e.pickNext(ctx, themeName)
+		path, err := resolveImagePath(ref)
+		if err != nil {
+			return nil, err
+		}
+		img.SourceID = themeName + manualSourceSuffix
+		img.Path = path
+		img.IsLocal = true
+		if e.dryRun {
+			return newWallpaperResult(img, isTemp, e.clock(), display), nil
+		}
+	}
+
+	return e.apply(ctx, svc, display, img, isTemp)
+}
+
+func (e *Engine) apply(ctx context.Context, svc platform.DisplayWallpaperService, display string, img *datasource.Image, isTemp bool) (*WallpaperResult, error) {
+	if svc != nil {
+		if err := svc.SetDisplay(display, img.Path); err != nil {
+			return nil, fmt.Errorf("failed to set wallpaper: %w", err)
+		}
+	} else if err := e.platform.Wallpaper().Set(img.Path); err != nil {
+		return nil, fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	previous := e.state.TempPaths()
+	if display != "" {
+		e.state.SetDisplayCurrent(display, img.Path, img.SourceID, img.Theme, img.Query, img.URL, isTemp)
+	} else {
+		e.state.SetCurrent(img.Path, img.SourceID, img.Theme, img.Query, img.URL, isTemp)
+	}
+	e.releaseTemp(previous)
+	_ = e.state.Save()
+
+	result := newWallpaperResult(img, isTemp, e.state.CurrentEntry().SetAt, display)
+	e.afterChange(ctx, config.HookSet, result)
+	return result, nil
+}
+
+func absImagePath(ref string) (string, error) {
+	if strings.HasPrefix(ref, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		ref = filepath.Join(home, ref[2:])
+	}
+
+	path, err := filepath.Abs(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	return path, nil
+}
+
+func resolveImagePath(ref string) (string, error) {
+	path, err := absImagePath(ref)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("image not found: %s", ref)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("not an image file: %s", ref)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if !datasource.SupportedExtensions[ext] {
+		return "", fmt.Errorf("unsupported image format: %s (supported: %s)", ext, supportedExtensions())
+	}
+	return path, nil
+}
+
+func supportedExtensions() string {
+	exts := make([]string, 0, len(datasource.SupportedExtensions))
+	for ext := range datasource.SupportedExtensions {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return strings.Join(exts, ", ")
+}
+
+func (e *Engine) Prev(ctx context.Context) (*WallpaperResult, error) {
+	return e.stepHistory(ctx, -1)
+}
+
+func (e *Engine) Forward(ctx context.Context) (*WallpaperResult, error) {
+	return e.stepHistory(ctx, 1)
+}
+
+func (e *Engine) Advance(ctx context.Context) (*WallpaperResult, error) {
+	if !e.span && e.display == "" && e.providerOverride == "" && e.queryOverride == "" {
+		if _, ok := e.state.Peek(1, e.historyUsable); ok {
+			return e.stepHistory(ctx, 1)
+		}
+	}
+	return e.Next(ctx)
+}
+
+func (e *Engine) stepHistory(ctx context.Context, delta int) (*WallpaperResult, error) {
+	if e.span || e.display != "" {
+		return nil, fmt.Errorf("history navigation cannot be combined with --display or --span")
+	}
+
+	direction := "next"
+	if delta < 0 {
+		direction = "previous"
+	}
+
+	entry, ok := e.state.Peek(delta, e.historyUsable)
+	if !ok {
+		return nil, fmt.Errorf("no %s wallpaper in history", direction)
+	}
+
+	if e.dryRun {
+		result := currentResult(entry, "")
+		result.FromHistory = true
+		return result, nil
+	}
+
+	if err := e.platform.Wallpaper().Set(entry.Path); err != nil {
+		return nil, fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	previous := e.state.TempPaths()
+	entry, _ = e.state.Step(delta, e.historyUsable)
+	e.releaseTemp(previous)
+	_ = e.state.Save()
+
+	result := currentResult(entry, "")
+	result.FromHistory = true
+	e.afterChange(ctx, config.HookSet, result)
+	return result, nil
+}
+
+func (e *Engine) historyUsable(current state.CurrentWallpaper) bool {
+	if e.state.IsBanned(e.imageKey(current.Path)) {
+		return false
+	}
+	_, err := os.Stat(current.Path)
+	return err == nil
+}
+
+func (e *Engine) NextAll(ctx context.Context) ([]*WallpaperResult, error) {
+	svc, err := e.displayWallpaper()
+	if err != nil {
+		return nil, err
+	}
+
+	displays, err := svc.Displays()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list displays: %w", err)
+	}
+	if len(displays) == 0 {
+		return nil, fmt.Errorf("no displays found")
 	}
 
+	themeName := string(e.detectTheme())
+	previous := e.state.TempPaths()
+	results := make([]*WallpaperResult, 0, len(displays))
+
+	for i, d := range displays {
+		if i > 0 {
+			e.manager.WaitPrefetch()
+		}
+
+		img, isTemp, err := e.pickDistinctImage(ctx, themeName)
+		if err == nil && !e.dryRun {
+			err = svc.SetDisplay(d.Name, img.Path)
+			if err != nil {
+				err = fmt.Errorf("failed to set wallpaper on %s: %w", d.Name, err)
+			}
+		}
+		if err != nil {
+			if len(results) > 0 && !e.dryRun {
+				e.releaseTemp(previous)
+				_ = e.state.Save()
+			}
+			return nil, err
+		}
+
+		if e.dryRun {
+			results = append(results, newWallpaperResult(img, isTemp, e.clock(), d.Name))
+			continue
+		}
+
+		e.state.SetDisplayCurrent(d.Name, img.Path, img.SourceID, img.Theme, img.Query, img.URL, isTemp)
+		results = append(results, newWallpaperResult(img, isTemp, e.state.CurrentEntry().SetAt, d.Name))
+	}
+
+	if !e.dryRun {
+		e.releaseTemp(previous)
+		_ = e.state.Save()
+		for _, result := range results {
+			e.afterChange(ctx, config.HookSet, result)
+		}
+	}
+
+	return results, nil
+}
+
+func (e *Engine) nextOnDisplay(ctx context.Context, display string) (*WallpaperResult, error) {
+	svc, err := e.displayWallpaper()
+	if err != nil {
+		return nil, err
+	}
+
+	if displays, err := svc.Displays(); err == nil && !hasDisplay(displays, display) {
+		return nil, fmt.Errorf("unknown display: %s (available: %s)", display, displayNames(displays))
+	}
+
+	themeName := string(e.detectTheme())
+
+	img, isTemp, err := e.pickImage(ctx, themeName)
 	if err != nil {
 		return nil, err
 	}
 
 	if e.dryRun {
-		return &WallpaperResult{
-			Path:     img.Path,
-			Theme:    img.Theme,
-			SourceID: img.SourceID,
-			IsTemp:   isTemp,
-			SetAt:    time.Now(),
-			Query:    img.Query,
-		}, nil
+		return newWallpaperResult(img, isTemp, e.clock(), display), nil
 	}
 
-	if err := e.platform.Wallpaper().Set(img.Path); err != nil {
+	if err := svc.SetDisplay(display, img.Path); err != nil {
 		return nil, fmt.Errorf("failed to set wallpaper: %w", err)
 	}
 
-	e.state.SetCurrent(img.Path, img.SourceID, img.Theme, img.Query, isTemp)
+	previous := e.state.TempPaths()
+	e.state.SetDisplayCurrent(display, img.Path, img.SourceID, img.Theme, img.Query, img.URL, isTemp)
+	e.releaseTemp(previous)
 	_ = e.state.Save()
 
+	result := newWallpaperResult(img, isTemp, e.state.CurrentEntry().SetAt, display)
+	e.afterChange(ctx, config.HookSet, result)
+	return result, nil
+}
+
+func (e *Engine) nextSpan(ctx context.Context) (*WallpaperResult, error) {
+	if e.display != "" {
+		return nil, fmt.Errorf("span mode cannot target a single display")
+	}
+
+	svc, err := e.displayWallpaper()
+	if err != nil {
+		return nil, err
+	}
+
+	displays, err := svc.Displays()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list displays: %w", err)
+	}
+
+	layout, err := span.NewLayout(displays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build display layout: %w", err)
+	}
+
+	themeName := string(e.detectTheme())
+
+	img, isTemp, err := e.pickSpanImage(ctx, themeName, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newWallpaperResult(img, isTemp, e.clock(), "")
+	for _, d := range layout.Displays {
+		result.SpanDisplays = append(result.SpanDisplays, d.Name)
+	}
+
+	if e.dryRun {
+		return result, nil
+	}
+
+	crops, err := layout.Slice(img.Path, filepath.Join(config.GetTempDir(), "span"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to slice wallpaper: %w", err)
+	}
+
+	for _, crop := range crops {
+		if err := svc.SetDisplay(crop.Display, crop.Path); err != nil {
+			return nil, fmt.Errorf("failed to set wallpaper on %s: %w", crop.Display, err)
+		}
+	}
+
+	previous := e.state.TempPaths()
+	e.state.SetCurrent(img.Path, img.SourceID, img.Theme, img.Query, img.URL, isTemp)
+	e.releaseTemp(previous)
+	_ = e.state.Save()
+
+	result.SetAt = e.state.CurrentEntry().SetAt
+	e.afterChange(ctx, config.HookSet, result)
+	return result, nil
+}
+
+func (e *Engine) pickSpanImage(ctx context.Context, theme string, layout *span.Layout) (*datasource.Image, bool, error) {
+	const attempts = 5
+
+	e.imageFilter = func(img datasource.Image) bool {
+		return layout.MatchesFile(img.Path, span.DefaultTolerance)
+	}
+	defer func() { e.imageFilter = nil }()
+
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			e.manager.WaitPrefetch()
+		}
+
+		img, isTemp, err := e.pickImage(ctx, theme)
+		if err != nil {
+			return nil, false, err
+		}
+		if !isTemp || layout.MatchesFile(img.Path, span.DefaultTolerance) {
+			return img, isTemp, nil
+		}
+
+		e.pending = append(e.pending, img.Path)
+		if !e.state.IsReferenced(img.Path) {
+			os.Remove(img.Path)
+		}
+	}
+
+	return nil, false, fmt.Errorf("no image matches the display layout aspect ratio (%.2f)", layout.AspectRatio())
+}
+
+func (e *Engine) pickImage(ctx context.Context, theme string) (*datasource.Image, bool, error) {
+	if e.providerOverride != "" {
+		return e.pickFromProvider(ctx, theme, e.providerOverride)
+	}
+	if e.queryOverride != "" {
+		return e.pickFromRemote(ctx, theme)
+	}
+	return e.pickNext(ctx, theme)
+}
+
+func (e *Engine) pickDistinctImage(ctx context.Context, theme string) (*datasource.Image, bool, error) {
+	const attempts = 3
+
+	var img *datasource.Image
+	var isTemp bool
+	var err error
+
+	for i := 0; i < attempts; i++ {
+		img, isTemp, err = e.pickImage(ctx, theme)
+		if err != nil || !containsPath(e.pending, img.Path) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	e.pending = append(e.pending, img.Path)
+	return img, isTemp, nil
+}
+
+func (e *Engine) pickLocal(ctx context.Context, theme string) (*datasource.Image, error) {
+	return e.manager.PickRandomLocalFunc(ctx, theme, e.excludedPaths(), e.imageFilter)
+}
+
+func (e *Engine) excludedPaths() []string {
+	history := e.state.HistoryCopy()
+	if len(e.pending) == 0 {
+		return history
+	}
+	return append(history, e.pending...)
+}
+
+func (e *Engine) releaseTemp(paths []string) {
+	for _, path := range paths {
+		if !e.state.IsReferenced(path) {
+			os.Remove(path)
+		}
+	}
+}
+
+func (e *Engine) displayWallpaper() (platform.DisplayWallpaperService, error) {
+	svc, ok := e.platform.Wallpaper().(platform.DisplayWallpaperService)
+	if !ok {
+		return nil, fmt.Errorf("per-display wallpapers are not supported by this backend")
+	}
+	return svc, nil
+}
+
+func newWallpaperResult(img *datasource.Image, isTemp bool, setAt time.Time, display string) *WallpaperResult {
 	return &WallpaperResult{
 		Path:     img.Path,
 		Theme:    img.Theme,
 		SourceID: img.SourceID,
 		IsTemp:   isTemp,
-		SetAt:    e.state.Current.SetAt,
+		SetAt:    setAt,
 		Query:    img.Query,
-	}, nil
+		URL:      img.URL,
+		Display:  display,
+	}
+}
+
+func hasDisplay(displays []platform.Display, name string) bool {
+	for _, d := range displays {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func displayNames(displays []platform.Display) string {
+	names := make([]string, len(displays))
+	for i, d := range displays {
+		names[i] = d.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
 }
 
 func (e *Engine) pickNext(ctx context.Context, theme string) (*datasource.Image, bool, error) {
@@ -188,7 +796,7 @@ func (e *Engine) pickNext(ctx context.Context, theme string) (*datasource.Image,
 	hasRemote := e.manager.HasRemoteSources(theme)
 
 	if !hasLocal && !hasRemote {
-		return nil, false, fmt.Errorf("no sources available for theme: %s", theme)
+		return nil, false, fmt.Errorf("%w for theme: %s", ErrNoSources, theme)
 	}
 
 	useRemote := false
@@ -204,7 +812,7 @@ func (e *Engine) pickNext(ctx context.Context, theme string) (*datasource.Image,
 			return img, true, nil
 		}
 		if hasLocal {
-			img, err := e.manager.PickRandomLocal(ctx, theme, e.state.History)
+			img, err := e.pickLocal(ctx, theme)
 			if err == nil {
 				return img, false, nil
 			}
@@ -212,7 +820,7 @@ func (e *Engine) pickNext(ctx context.Context, theme string) (*datasource.Image,
 		return nil, false, fmt.Errorf("failed to fetch from remote: %w", err)
 	}
 
-	img, err := e.manager.PickRandomLocal(ctx, theme, e.state.History)
+	img, err := e.pickLocal(ctx, theme)
 	if err != nil {
 		if hasRemote {
 			img, err := e.manager.FetchRandomRemote(ctx, theme, e.queryOverride)
@@ -228,7 +836,7 @@ func (e *Engine) pickNext(ctx context.Context, theme string) (*datasource.Image,
 
 func (e *Engine) pickFromProvider(ctx context.Context, theme, providerName string) (*datasource.Image, bool, error) {
 	if providerName == "local" {
-		img, err := e.manager.PickRandomLocal(ctx, theme, e.state.History)
+		img, err := e.pickLocal(ctx, theme)
 		if err != nil {
 			return nil, false, fmt.Errorf("failed to pick from local: %w", err)
 		}
@@ -284,114 +892,269 @@ func (e *Engine) pickFromRemote(ctx context.Context, theme string) (*datasource.
 }
 
 func (e *Engine) Save() (*WallpaperResult, error) {
-	if !e.state.HasCurrent() {
-		return nil, fmt.Errorf("no wallpaper currently set")
+	current, ok := e.target()
+	if !ok {
+		return nil, ErrNoWallpaper
 	}
 
-	if !e.state.IsTempWallpaper() {
-		return &WallpaperResult{
-			Path:     e.state.Current.Path,
-			Theme:    e.state.Current.Theme,
-			SourceID: e.state.Current.SourceID,
-			IsTemp:   false,
-			SetAt:    e.state.Current.SetAt,
-		}, nil
+	if !current.IsTemp {
+		return currentResult(current, e.targetDisplay()), nil
 	}
 
-	remote, err := e.manager.GetRemoteSourceByID(e.state.Current.SourceID)
-	if err != nil {
+	save := func(path string) (string, error) {
+		uploadDir := e.config.GetUploadDir(Theme(current.Theme).ToConfigMode())
+		if uploadDir == "" {
+			return "", fmt.Errorf("no upload-dir configured for %s theme", current.Theme)
+		}
+		return datasource.SaveTo(path, uploadDir)
+	}
+	if remote, err := e.manager.GetRemoteSourceByID(current.SourceID); err == nil {
+		save = remote.Save
+	} else if !strings.HasSuffix(current.SourceID, urlSourceSuffix) {
 		return nil, fmt.Errorf("failed to get source: %w", err)
 	}
 
 	if e.dryRun {
-		return &WallpaperResult{
-			Path:     e.state.Current.Path,
-			Theme:    e.state.Current.Theme,
-			SourceID: e.state.Current.SourceID,
-			IsTemp:   true,
-			SetAt:    e.state.Current.SetAt,
-		}, nil
+		return currentResult(current, e.targetDisplay()), nil
 	}
 
-	newPath, err := remote.Save(e.state.Current.Path)
+	newPath, err := save(current.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save wallpaper: %w", err)
 	}
 
-	e.state.MarkSaved(newPath)
+	if display := e.targetDisplay(); display != "" {
+		e.state.MarkDisplaySaved(display, newPath)
+	} else {
+		e.state.MarkSaved(newPath)
+	}
 	_ = e.state.Save()
+	e.record(history.EventSaved, currentResult(current, e.targetDisplay()), newPath)
 
-	return &WallpaperResult{
-		Path:     newPath,
-		Theme:    e.state.Current.Theme,
-		SourceID: e.state.Current.SourceID,
-		IsTemp:   false,
-		SetAt:    e.state.Current.SetAt,
-	}, nil
+	current.Path = newPath
+	current.IsTemp = false
+	result := currentResult(current, e.targetDisplay())
+	e.afterChange(context.Background(), config.HookSave, result)
+	return result, nil
 }
 
 func (e *Engine) Delete(ctx context.Context) (*WallpaperResult, error) {
-	if !e.state.HasCurrent() {
-		return nil, fmt.Errorf("no wallpaper currently set")
+	current, ok := e.target()
+	if !ok {
+		return nil, ErrNoWallpaper
+	}
+
+	if e.dryRun {
+		return currentResult(current, e.targetDisplay()), nil
+	}
+
+	if current.IsTemp {
+		os.Remove(current.Path)
+	}
+
+	result, err := e.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	e.afterChange(ctx, config.HookDelete, currentResult(current, e.targetDisplay()))
+	return result, nil
+}
+
+func (e *Engine) Like() (*RatingResult, error) {
+	return e.Rate(datasource.MaxRating)
+}
+
+func (e *Engine) Dislike() (*RatingResult, error) {
+	return e.Rate(datasource.MinRating)
+}
+
+func (e *Engine) Rate(score int) (*RatingResult, error) {
+	if score < datasource.MinRating || score > datasource.MaxRating {
+		return nil, fmt.Errorf("rating must be between %d and %d", datasource.MinRating, datasource.MaxRating)
+	}
+
+	current, ok := e.target()
+	if !ok {
+		return nil, ErrNoWallpaper
+	}
+
+	result := &RatingResult{
+		Path:    current.Path,
+		Key:     e.imageKey(current.Path),
+		Rating:  score,
+		Display: e.targetDisplay(),
+	}
+
+	if e.dryRun {
+		return result, nil
 	}
 
-	currentPath := e.state.Current.Path
+	e.state.SetRating(result.Key, score)
+	if err := e.state.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save rating: %w", err)
+	}
+
+	return result, nil
+}
 
-	shouldDelete := e.state.IsTempWallpaper()
+func (e *Engine) Ban(ctx context.Context) (*WallpaperResult, error) {
+	current, ok := e.target()
+	if !ok {
+		return nil, ErrNoWallpaper
+	}
 
 	if e.dryRun {
-		return &WallpaperResult{
-			Path:     currentPath,
-			Theme:    e.state.Current.Theme,
-			SourceID: e.state.Current.SourceID,
-			IsTemp:   e.state.IsTempWallpaper(),
-			SetAt:    e.state.Current.SetAt,
-		}, nil
+		return currentResult(current, e.targetDisplay()), nil
 	}
 
-	if shouldDelete {
-		os.Remove(currentPath)
+	e.state.Ban(e.imageKey(current.Path))
+	if err := e.state.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save ban list: %w", err)
+	}
+	e.record(history.EventBanned, currentResult(current, e.targetDisplay()), "")
+
+	if current.IsTemp {
+		os.Remove(current.Path)
 	}
 
 	return e.Next(ctx)
 }
 
+func (e *Engine) Bans() []BanInfo {
+	banned := e.state.BansCopy()
+	bans := make([]BanInfo, 0, len(banned))
+	for key, bannedAt := range banned {
+		bans = append(bans, BanInfo{Key: key, BannedAt: bannedAt})
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if !bans[i].BannedAt.Equal(bans[j].BannedAt) {
+			return bans[i].BannedAt.Before(bans[j].BannedAt)
+		}
+		return bans[i].Key < bans[j].Key
+	})
+	return bans
+}
+
+func (e *Engine) Unban(ref string) (string, error) {
+	key := ref
+	if !e.state.IsBanned(key) {
+		if path, err := absImagePath(ref); err == nil {
+			key = path
+		}
+	}
+	if !e.state.IsBanned(key) {
+		return "", fmt.Errorf("not banned: %s", ref)
+	}
+	if e.dryRun {
+		return key, nil
+	}
+
+	e.state.Unban(key)
+	if err := e.state.Save(); err != nil {
+		return "", fmt.Errorf("failed to save ban list: %w", err)
+	}
+	return key, nil
+}
+
 func (e *Engine) Info() (*WallpaperInfo, error) {
-	if !e.state.HasCurrent() {
-		return nil, fmt.Errorf("no wallpaper currently set")
+	current, ok := e.target()
+	if !ok {
+		return nil, ErrNoWallpaper
+	}
+
+	info := newWallpaperInfo(current, e.targetDisplay())
+	info.Rating, _ = e.state.Rating(e.imageKey(current.Path))
+
+	if e.targetDisplay() == "" {
+		displays := e.state.DisplaysCopy()
+		names := make([]string, 0, len(displays))
+		for name := range displays {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			info.Displays = append(info.Displays, *newWallpaperInfo(displays[name], name))
+		}
+	}
+
+	return info, nil
+}
+
+func (e *Engine) target() (state.CurrentWallpaper, bool) {
+	if display := e.targetDisplay(); display != "" {
+		return e.state.DisplayCurrent(display)
+	}
+	current := e.state.CurrentEntry()
+	return current, current.Path != ""
+}
+
+func (e *Engine) targetDisplay() string {
+	if e.display == DisplayAll {
+		return ""
+	}
+	return e.display
+}
+
+func currentResult(current state.CurrentWallpaper, display string) *WallpaperResult {
+	return &WallpaperResult{
+		Path:     current.Path,
+		Theme:    current.Theme,
+		SourceID: current.SourceID,
+		IsTemp:   current.IsTemp,
+		SetAt:    current.SetAt,
+		Query:    current.Query,
+		URL:      current.URL,
+		Display:  display,
 	}
+}
 
-	_, err := os.Stat(e.state.Current.Path)
-	exists := err == nil
+func newWallpaperInfo(current state.CurrentWallpaper, display string) *WallpaperInfo {
+	_, err := os.Stat(current.Path)
 
 	return &WallpaperInfo{
-		Path:     e.state.Current.Path,
-		Theme:    e.state.Current.Theme,
-		SourceID: e.state.Current.SourceID,
-		IsTemp:   e.state.IsTempWallpaper(),
-		SetAt:    e.state.Current.SetAt,
-		Exists:   exists,
-		Query:    e.state.Current.Query,
-	}, nil
+		Path:     current.Path,
+		Theme:    current.Theme,
+		SourceID: current.SourceID,
+		IsTemp:   current.IsTemp,
+		SetAt:    current.SetAt,
+		Exists:   err == nil,
+		Query:    current.Query,
+		Display:  display,
+	}
 }
 
 func (e *Engine) AnalyzeColors(topN int) ([]Color, error) {
-	if !e.state.HasCurrent() {
-		return nil, fmt.Errorf("no wallpaper currently set")
+	current, ok := e.target()
+	if !ok {
+		return nil, ErrNoWallpaper
 	}
 
-	if _, err := os.Stat(e.state.Current.Path); os.IsNotExist(err) {
+	if _, err := os.Stat(current.Path); os.IsNotExist(err) {
 		return nil, fmt.Errorf("wallpaper file not found")
 	}
 
-	result, err := colors.Analyze(e.state.Current.Path, topN)
+	return analyzeColors(current.Path, topN)
+}
+
+func (e *Engine) AnalyzeFileColors(path string, topN int) ([]Color, error) {
+	resolved, err := resolveImagePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return analyzeColors(resolved, topN)
+}
+
+func analyzeColors(path string, topN int) ([]Color, error) {
+	result, err := colors.AnalyzeShares(path, topN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze colors: %w", err)
 	}
 
 	coreColors := make([]Color, len(result))
-	for i, c := range result {
-		coreColors[i] = Color{R: c.R, G: c.G, B: c.B}
+	for i, s := range result {
+		coreColors[i] = Color{R: s.Color.R, G: s.Color.G, B: s.Color.B, Share: s.Share}
 	}
 
 	return coreColors, nil
@@ -423,23 +1186,84 @@ func (e *Engine) ListSources() []SourceInfo {
 	return result
 }
 
-func (e *Engine) CurrentPath() string {
-	if e.state.HasCurrent() {
-		return e.state.Current.Path
+func (e *Engine) Doctor() *DoctorReport {
+	report := &DoctorReport{
+		Platform:  e.platform.Name(),
+		Supported: e.platform.IsSupported(),
+		Theme:     string(e.detectTheme()),
+		Scheduler: e.platform.Scheduler().IsSupported(),
+	}
+
+	if reporter, ok := e.platform.(platform.BackendReporter); ok {
+		info := reporter.BackendInfo()
+		report.Backend = info.Backend
+		report.Tool = info.Tool
+		for _, c := range info.Candidates {
+			report.Tools = append(report.Tools, ToolStatus{
+				Name:      c.Name,
+				Path:      c.Path,
+				Available: c.Available(),
+			})
+		}
 	}
-	return ""
+
+	return report
+}
+
+func (e *Engine) CurrentPath() string {
+	current, _ := e.target()
+	return current.Path
 }
 
 func (e *Engine) IsTempWallpaper() bool {
-	return e.state.IsTempWallpaper()
+	current, _ := e.target()
+	return current.IsTemp
 }
 
 func (e *Engine) GetCurrentWallpaperPath() (string, error) {
+	if display := e.targetDisplay(); display != "" {
+		svc, err := e.displayWallpaper()
+		if err != nil {
+			return "", err
+		}
+		return svc.GetDisplay(display)
+	}
 	return e.platform.Wallpaper().Get()
 }
 
+func (e *Engine) Displays() ([]DisplayInfo, error) {
+	svc, err := e.displayWallpaper()
+	if err != nil {
+		return nil, err
+	}
+
+	displays, err := svc.Displays()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list displays: %w", err)
+	}
+
+	result := make([]DisplayInfo, 0, len(displays))
+	for _, d := range displays {
+		info := DisplayInfo{
+			Name:    d.Name,
+			Primary: d.Primary,
+			X:       d.X,
+			Y:       d.Y,
+			Width:   d.Width,
+			Height:  d.Height,
+		}
+		if current, ok := e.state.DisplayCurrent(d.Name); ok {
+			info.Path = current.Path
+		} else if current := e.state.CurrentEntry(); current.Path != "" && len(e.state.DisplaysCopy()) == 0 {
+			info.Path = current.Path
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
 func (e *Engine) OpenInFinder() error {
-	path, err := e.platform.Wallpaper().Get()
+	path, err := e.GetCurrentWallpaperPath()
 	if err != nil {
 		return fmt.Errorf("failed to get current wallpaper: %w", err)
 	}
@@ -453,7 +1277,7 @@ func (e *Engine) OpenInFinder() error {
 }
 
 func (e *Engine) OpenImage() error {
-	path, err := e.platform.Wallpaper().Get()
+	path, err := e.GetCurrentWallpaperPath()
 	if err != nil {
 		return fmt.Errorf("failed to get current wallpaper: %w", err)
 	}
@@ -469,6 +1293,23 @@ func (e *Engine) OpenImage() error {
 const agentLabel = "com.wallboy.agent"
 
 func (e *Engine) InstallAgent(interval time.Duration) error {
+	return e.installAgent(platform.SchedulerConfig{
+		Args:      []string{"next"},
+		Interval:  interval,
+		RunAtLoad: true,
+	})
+}
+
+func (e *Engine) InstallDaemonAgent(interval time.Duration) error {
+	return e.installAgent(platform.SchedulerConfig{
+		Args:      []string{"daemon", "--interval", strconv.Itoa(int(interval.Seconds()))},
+		Interval:  interval,
+		RunAtLoad: true,
+		KeepAlive: true,
+	})
+}
+
+func (e *Engine) installAgent(cfg platform.SchedulerConfig) error {
 	scheduler := e.platform.Scheduler()
 	if !scheduler.IsSupported() {
 		return fmt.Errorf("scheduler not supported on %s", e.platform.Name())
@@ -488,14 +1329,9 @@ func (e *Engine) InstallAgent(interval time.Duration) error {
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
 
-	cfg := platform.SchedulerConfig{
-		Label:     agentLabel,
-		Command:   execPath,
-		Args:      []string{"next"},
-		Interval:  interval,
-		RunAtLoad: true,
-		LogPath:   logPath,
-	}
+	cfg.Label = agentLabel
+	cfg.Command = execPath
+	cfg.LogPath = logPath
 
 	return scheduler.Install(cfg)
 }
@@ -527,6 +1363,7 @@ func (e *Engine) AgentStatus() (*AgentStatus, error) {
 
 	status.Installed = platformStatus.Installed
 	status.Running = platformStatus.Running
+	status.Daemon = platformStatus.KeepAlive
 	status.Interval = platformStatus.Interval
 
 	return status, nil
@@ -543,3 +1380,321 @@ func (e *Engine) Config() *config.Config {
 func (e *Engine) WaitPrefetch() {
 	e.manager.WaitPrefetch()
 }
+
+func (e *Engine) Refresh() error {
+	if err := e.state.Reload(); err != nil {
+		return fmt.Errorf("failed to reload state: %w", err)
+	}
+	if e.managerTheme != "" && e.detectTheme() != e.managerTheme {
+		e.manager.WaitPrefetch()
+		e.initManager()
+	}
+	return nil
+}
+
+func (e *Engine) WatchTheme(ctx context.Context) <-chan platform.Theme {
+	if e.themeOverride != "" {
+		return nil
+	}
+	switch e.config.Theme.Mode {
+	case config.ThemeModeAuto:
+		return platform.WatchTheme(ctx, e.platform.Theme(), platform.DefaultThemePollInterval)
+	case config.ThemeModeSolar:
+		return platform.PollTheme(ctx, themeFunc(func() platform.Theme {
+			return e.detectTheme().ToPlatformTheme()
+		}), time.Minute)
+	default:
+		return nil
+	}
+}
+
+type themeFunc func() platform.Theme
+
+func (f themeFunc) Detect() platform.Theme { return f() }
+
+func (e *Engine) SwitchTheme(ctx context.Context) (*WallpaperResult, error) {
+	if err := e.Refresh(); err != nil {
+		return nil, err
+	}
+
+	result, err := e.switchTheme(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	e.afterChange(ctx, config.HookThemeChange, result)
+	return result, nil
+}
+
+func (e *Engine) switchTheme(ctx context.Context) (*WallpaperResult, error) {
+	theme := string(e.detectTheme())
+	last, ok := e.state.ThemeCurrent(theme)
+	if e.span || e.display != "" || !ok {
+		return e.Next(ctx)
+	}
+	if _, err := os.Stat(last.Path); err != nil {
+		return e.Next(ctx)
+	}
+
+	result := &WallpaperResult{
+		Path:     last.Path,
+		Theme:    theme,
+		SourceID: last.SourceID,
+		IsTemp:   last.IsTemp,
+		SetAt:    e.clock(),
+		Query:    last.Query,
+		URL:      last.URL,
+		Restored: true,
+	}
+
+	if e.dryRun {
+		return result, nil
+	}
+
+	if err := e.platform.Wallpaper().Set(last.Path); err != nil {
+		return nil, fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	previous := e.state.TempPaths()
+	e.state.SetCurrent(last.Path, last.SourceID, theme, last.Query, last.URL, last.IsTemp)
+	e.releaseTemp(previous)
+	_ = e.state.Save()
+
+	result.SetAt = e.state.CurrentEntry().SetAt
+	e.afterChange(ctx, config.HookSet, result)
+	return result, nil
+}
+
+func (e *Engine) afterChange(ctx context.Context, event config.HookEvent, result *WallpaperResult) {
+	if e.dryRun {
+		return
+	}
+
+	switch event {
+	case config.HookSet:
+		e.record(history.EventShown, result, "")
+	case config.HookDelete:
+		e.record(history.EventDeleted, result, "")
+	}
+
+	render := len(e.config.Templates) > 0 && (event == config.HookSet || event == config.HookSave)
+	runHooks := e.hooks.Has(string(event))
+	if !render && !runHooks {
+		return
+	}
+
+	var palette []colors.Color
+	if _, err := os.Stat(result.Path); err == nil {
+		palette, err = colors.Analyze(result.Path, paletteSize)
+		if err != nil {
+			e.logf("failed to analyze colors: %v", err)
+		}
+	}
+
+	if render {
+		if _, err := e.renderTemplates(result.Path, result.Theme, palette); err != nil {
+			e.logf("%v", err)
+		}
+	}
+
+	if runHooks {
+		hex := make([]string, len(palette))
+		for i, c := range palette {
+			hex[i] = c.Hex()
+		}
+		e.hooks.Run(ctx, hooks.Event{
+			Name:    string(event),
+			Path:    result.Path,
+			Theme:   result.Theme,
+			Source:  result.SourceID,
+			Query:   result.Query,
+			Display: result.Display,
+			Colors:  hex,
+		})
+	}
+}
+
+func (e *Engine) renderTemplates(path, theme string, palette []colors.Color) ([]string, error) {
+	return templates.RenderAll(e.config.Templates, templates.Data{
+		Palette:   colors.NewPalette(palette, theme == string(ThemeDark)),
+		Wallpaper: path,
+		Theme:     theme,
+	})
+}
+
+func (e *Engine) RenderTemplates() ([]string, error) {
+	current, ok := e.target()
+	if !ok {
+		return nil, ErrNoWallpaper
+	}
+	if len(e.config.Templates) == 0 {
+		return nil, fmt.Errorf("no templates configured")
+	}
+
+	palette, err := colors.Analyze(current.Path, paletteSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze colors: %w", err)
+	}
+
+	if e.dryRun {
+		rendered := make([]string, 0, len(e.config.Templates))
+		for _, dest := range e.config.Templates {
+			rendered = append(rendered, dest)
+		}
+		sort.Strings(rendered)
+		return rendered, nil
+	}
+
+	return e.renderTemplates(current.Path, current.Theme, palette)
+}
+
+func (e *Engine) record(event string, result *WallpaperResult, savedAs string) {
+	r := history.Record{
+		Event:    event,
+		Time:     e.clock(),
+		Path:     result.Path,
+		Theme:    result.Theme,
+		SourceID: result.SourceID,
+		Query:    result.Query,
+		URL:      result.URL,
+		Display:  result.Display,
+		IsTemp:   result.IsTemp,
+		SavedAs:  savedAs,
+	}
+	if key := e.imageKey(result.Path); key != result.Path {
+		r.ImageID = key
+	}
+	if err := e.history.Append(r); err != nil {
+		e.logf("failed to record history: %v", err)
+	}
+}
+
+func (e *Engine) imageKey(path string) string {
+	uploadDirs := []string{e.config.GetUploadDir(config.ThemeModeLight), e.config.GetUploadDir(config.ThemeModeDark)}
+	if e.manager == nil {
+		return datasource.ImageKey(path, append(uploadDirs, config.GetTempDir())...)
+	}
+	return e.manager.ImageKey(path, uploadDirs...)
+}
+
+func (e *Engine) History(filter history.Filter) ([]history.Entry, error) {
+	entries, err := e.history.Entries(e.clock())
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []history.Entry
+	for _, entry := range entries {
+		if filter.Match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, nil
+}
+
+func (e *Engine) ApplyHistory(ctx context.Context, index int) (*WallpaperResult, error) {
+	entries, err := e.history.Entries(e.clock())
+	if err != nil {
+		return nil, err
+	}
+	if index < 1 || index > len(entries) {
+		return nil, fmt.Errorf("no history entry %d (have %d)", index, len(entries))
+	}
+
+	entry := entries[index-1]
+	if e.span {
+		return nil, fmt.Errorf("span mode cannot be combined with an explicit image")
+	}
+
+	display := e.targetDisplay()
+	var svc platform.DisplayWallpaperService
+	if display != "" {
+		if svc, err = e.displayWallpaper(); err != nil {
+			return nil, err
+		}
+	}
+
+	img := &datasource.Image{
+		Path:     entry.Path,
+		SourceID: entry.SourceID,
+		Theme:    entry.Theme,
+		Query:    entry.Query,
+		URL:      entry.URL,
+	}
+	if img.Theme == "" {
+		img.Theme = string(e.detectTheme())
+	}
+	isTemp := entry.IsTemp
+
+	switch {
+	case entry.SavedPath != "" && exists(entry.SavedPath):
+		img.Path = entry.SavedPath
+		isTemp = false
+	case entry.Path != "" && exists(entry.Path):
+	case entry.URL != "":
+		isTemp = true
+		if e.dryRun {
+			break
+		}
+		path, err := e.redownload(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		img.Path = path
+	default:
+		return nil, fmt.Errorf("history entry %d is no longer available: %s", index, entry.Path)
+	}
+	img.IsLocal = !isTemp
+
+	if e.dryRun {
+		result := newWallpaperResult(img, isTemp, e.clock(), display)
+		result.FromHistory = true
+		return result, nil
+	}
+
+	result, err := e.apply(ctx, svc, display, img, isTemp)
+	if err != nil {
+		return nil, err
+	}
+	result.FromHistory = true
+	return result, nil
+}
+
+func (e *Engine) redownload(ctx context.Context, entry history.Entry) (string, error) {
+	dir := config.GetTempDir()
+	if remote, err := e.manager.GetRemoteSourceByID(entry.SourceID); err == nil {
+		dir = remote.TempDir()
+	}
+
+	var (
+		path string
+		err  error
+	)
+	if entry.ImageID != "" {
+		path, err = datasource.DownloadURLAs(ctx, entry.URL, dir, strings.Replace(entry.ImageID, ":", "_", 1))
+	} else {
+		path, err = datasource.DownloadURL(ctx, entry.URL, dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download image: %w", err)
+	}
+	return path, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (e *Engine) logf(format string, args ...interface{}) {
+	if e.logger != nil {
+		e.logger.Printf(format, args...)
+	}
+}
+
+func (e *Engine) Warm(ctx context.Context) {
+	if e.dryRun || e.providerOverride != "" {
+		return
+	}
+	e.manager.Prefetch(ctx, string(e.detectTheme()), e.queryOverride)
+}
@@ -1,15 +1,30 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Artawower/wallboy/internal/config"
 	"github.com/Artawower/wallboy/internal/datasource"
+	"github.com/Artawower/wallboy/internal/history"
 	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/Artawower/wallboy/internal/provider"
 	"github.com/Artawower/wallboy/internal/state"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -51,12 +66,12 @@ func TestColor_Hex(t *testing.T) {
 		color    Color
 		expected string
 	}{
-		{"black", Color{0, 0, 0}, "#000000"},
-		{"white", Color{255, 255, 255}, "#ffffff"},
-		{"red", Color{255, 0, 0}, "#ff0000"},
-		{"green", Color{0, 255, 0}, "#00ff00"},
-		{"blue", Color{0, 0, 255}, "#0000ff"},
-		{"gray", Color{128, 128, 128}, "#808080"},
+		{"black", Color{R: 0, G: 0, B: 0}, "#000000"},
+		{"white", Color{R: 255, G: 255, B: 255}, "#ffffff"},
+		{"red", Color{R: 255, G: 0, B: 0}, "#ff0000"},
+		{"green", Color{R: 0, G: 255, B: 0}, "#00ff00"},
+		{"blue", Color{R: 0, G: 0, B: 255}, "#0000ff"},
+		{"gray", Color{R: 128, G: 128, B: 128}, "#808080"},
 	}
 
 	for _, tt := range tests {
@@ -66,6 +81,29 @@ func TestColor_Hex(t *testing.T) {
 	}
 }
 
+func TestColor_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Color{R: 255, G: 128, B: 0, Share: 0.25})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"hex":"#ff8000","rgb":[255,128,0],"share":0.25}`, string(data))
+
+	var decoded Color
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, Color{R: 255, G: 128, B: 0, Share: 0.25}, decoded)
+}
+
+func TestAgentStatus_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(AgentStatus{Supported: true, Installed: true, Interval: 10 * time.Minute, LogPath: "/tmp/agent.log"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"supported":true,"installed":true,"running":false,"daemon":false,"interval_seconds":600,"log_path":"/tmp/agent.log"}`, string(data))
+}
+
+func TestWallpaperResult_JSONFields(t *testing.T) {
+	setAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := json.Marshal(WallpaperResult{Path: "/tmp/a.jpg", Theme: "dark", SourceID: "dark-local-1", SetAt: setAt})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path":"/tmp/a.jpg","theme":"dark","source_id":"dark-local-1","is_temp":false,"set_at":"2024-01-02T03:04:05Z","restored":false,"from_history":false}`, string(data))
+}
+
 func TestTheme_ToConfigMode(t *testing.T) {
 	assert.Equal(t, "light", string(ThemeLight.ToConfigMode()))
 	assert.Equal(t, "dark", string(ThemeDark.ToConfigMode()))
@@ -171,6 +209,32 @@ func TestWithOptions(t *testing.T) {
 
 	WithQueryOverride("nature landscape")(e)
 	assert.Equal(t, "nature landscape", e.queryOverride)
+
+	WithDisplay("DP-1")(e)
+	assert.Equal(t, "DP-1", e.display)
+
+	WithSpan(true)(e)
+	assert.True(t, e.span)
+
+	mock := &mockPlatform{}
+	WithPlatform(mock)(e)
+	assert.Same(t, mock, e.platform)
+
+	store := state.NewFileStore("/tmp/state.json")
+	WithStateStore(store)(e)
+	assert.Same(t, store, e.store)
+
+	historyStore := history.NewFileStore("/tmp/history.jsonl")
+	WithHistoryStore(historyStore)(e)
+	assert.Same(t, historyStore, e.historyStore)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	WithClock(func() time.Time { return now })(e)
+	assert.Equal(t, now, e.clock())
+
+	WithProviderFactory(func(name, auth string) provider.Provider { return nil })(e)
+	assert.Nil(t, e.newProvider("nope", ""))
+	assert.NotNil(t, e.newProvider("bing", ""))
 }
 
 func TestWithQueryOverride(t *testing.T) {
@@ -400,3 +464,1012 @@ func TestEngine_pickFromProvider(t *testing.T) {
 		}
 	})
 }
+
+func TestEngine_platformSettings(t *testing.T) {
+	e := &Engine{config: &config.Config{
+		Platform: config.PlatformConfig{
+			Backend:            "swww",
+			TransitionType:     "wipe",
+			TransitionDuration: 0.5,
+			TransitionFPS:      30,
+		},
+	}}
+
+	settings := e.platformSettings()
+	assert.Equal(t, "swww", settings.Backend)
+	assert.Equal(t, "wipe", settings.Transition.Type)
+	assert.Equal(t, 500*time.Millisecond, settings.Transition.Duration)
+	assert.Equal(t, 30, settings.Transition.FPS)
+}
+
+type reportingPlatform struct {
+	mockPlatform
+	info platform.BackendInfo
+}
+
+func (p *reportingPlatform) BackendInfo() platform.BackendInfo { return p.info }
+
+func TestEngine_Doctor(t *testing.T) {
+	cfg := &config.Config{Theme: config.ThemeSettings{Mode: config.ThemeModeDark}}
+
+	t.Run("platform without backend info", func(t *testing.T) {
+		e := &Engine{config: cfg, platform: &mockPlatform{}}
+
+		report := e.Doctor()
+		assert.Equal(t, "mock", report.Platform)
+		assert.True(t, report.Supported)
+		assert.Equal(t, "dark", report.Theme)
+		assert.Empty(t, report.Backend)
+		assert.Empty(t, report.Tools)
+	})
+
+	t.Run("platform with backend info", func(t *testing.T) {
+		p := &reportingPlatform{info: platform.BackendInfo{
+			Backend: "x11",
+			Tool:    "xwallpaper",
+			Candidates: []platform.ToolCandidate{
+				{Name: "feh"},
+				{Name: "xwallpaper", Path: "/usr/bin/xwallpaper"},
+			},
+		}}
+		e := &Engine{config: cfg, platform: p}
+
+		report := e.Doctor()
+		assert.Equal(t, "x11", report.Backend)
+		assert.Equal(t, "xwallpaper", report.Tool)
+		assert.Equal(t, []ToolStatus{
+			{Name: "feh"},
+			{Name: "xwallpaper", Path: "/usr/bin/xwallpaper", Available: true},
+		}, report.Tools)
+	})
+}
+
+type displayPlatform struct {
+	mockPlatform
+	displays []platform.Display
+	set      map[string]string
+}
+
+func (p *displayPlatform) Wallpaper() platform.WallpaperService      { return p }
+func (p *displayPlatform) Displays() ([]platform.Display, error)     { return p.displays, nil }
+func (p *displayPlatform) GetDisplay(display string) (string, error) { return p.set[display], nil }
+func (p *displayPlatform) SetDisplay(display, path string) error {
+	p.set[display] = path
+	return nil
+}
+
+func newDisplayEngine(t *testing.T, opts ...Option) (*Engine, *displayPlatform) {
+	tmpDir := t.TempDir()
+	localDir := filepath.Join(tmpDir, "local")
+	require.NoError(t, os.MkdirAll(localDir, 0755))
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(localDir, name), []byte("test"), 0644))
+	}
+
+	manager := datasource.NewManager(tmpDir, tmpDir)
+	manager.AddLocalSource(datasource.NewLocalSource("light-local-1", localDir, "light", false))
+
+	p := &displayPlatform{
+		displays: []platform.Display{{Name: "DP-1", Primary: true}, {Name: "HDMI-1"}},
+		set:      make(map[string]string),
+	}
+
+	e := &Engine{
+		config:   &config.Config{Theme: config.ThemeSettings{Mode: config.ThemeModeLight}},
+		state:    state.New(filepath.Join(tmpDir, "state.json")),
+		platform: p,
+		manager:  manager,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e, p
+}
+
+func TestEngine_NextAll(t *testing.T) {
+	e, p := newDisplayEngine(t, WithDisplay(DisplayAll))
+
+	results, err := e.NextAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "DP-1", results[0].Display)
+	assert.Equal(t, "HDMI-1", results[1].Display)
+	assert.NotEqual(t, results[0].Path, results[1].Path)
+	assert.Equal(t, results[0].Path, p.set["DP-1"])
+	assert.Equal(t, results[1].Path, p.set["HDMI-1"])
+
+	info, err := e.Info()
+	require.NoError(t, err)
+	require.Len(t, info.Displays, 2)
+	assert.Equal(t, "DP-1", info.Displays[0].Display)
+	assert.Equal(t, results[0].Path, info.Displays[0].Path)
+}
+
+func TestEngine_NextOnAllDisplays(t *testing.T) {
+	e, p := newDisplayEngine(t, WithDisplay(DisplayAll))
+
+	result, err := e.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Displays, 2)
+	assert.Equal(t, "DP-1", result.Display)
+	assert.Equal(t, result.Path, p.set["DP-1"])
+	assert.Equal(t, "HDMI-1", result.Displays[1].Display)
+	assert.Equal(t, result.Displays[1].Path, p.set["HDMI-1"])
+	assert.Empty(t, result.Displays[0].Displays)
+}
+
+func TestEngine_NextOnDisplay(t *testing.T) {
+	e, p := newDisplayEngine(t, WithDisplay("HDMI-1"))
+
+	result, err := e.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "HDMI-1", result.Display)
+	assert.Equal(t, result.Path, p.set["HDMI-1"])
+	assert.Empty(t, p.set["DP-1"])
+
+	info, err := e.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "HDMI-1", info.Display)
+	assert.Equal(t, result.Path, info.Path)
+
+	e.display = "DP-1"
+	_, err = e.Info()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no wallpaper currently set")
+
+	e.display = "VGA-1"
+	_, err = e.Next(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown display: VGA-1 (available: DP-1, HDMI-1)")
+}
+
+func TestEngine_DeleteOnDisplay(t *testing.T) {
+	e, p := newDisplayEngine(t, WithDisplay("DP-1"))
+
+	tempPath := filepath.Join(t.TempDir(), "bing.jpg")
+	require.NoError(t, os.WriteFile(tempPath, []byte("test"), 0644))
+	e.state.SetDisplayCurrent("DP-1", tempPath, "light-bing", "light", "", "", true)
+	e.state.SetDisplayCurrent("HDMI-1", "/pictures/keep.jpg", "light-local-1", "light", "", "", false)
+
+	result, err := e.Delete(context.Background())
+	require.NoError(t, err)
+
+	_, err = os.Stat(tempPath)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, result.Path, p.set["DP-1"])
+
+	kept, ok := e.state.DisplayCurrent("HDMI-1")
+	require.True(t, ok)
+	assert.Equal(t, "/pictures/keep.jpg", kept.Path)
+}
+
+func TestEngine_DisplaysUnsupported(t *testing.T) {
+	e := &Engine{
+		config:   &config.Config{},
+		state:    state.New(filepath.Join(t.TempDir(), "state.json")),
+		platform: &mockPlatform{},
+		display:  "DP-1",
+	}
+
+	_, err := e.Next(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported")
+
+	_, err = e.Displays()
+	require.Error(t, err)
+}
+
+func TestEngine_Displays(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	e.state.SetCurrent("/pictures/all.jpg", "light-local-1", "light", "", "", false)
+
+	displays, err := e.Displays()
+	require.NoError(t, err)
+	require.Len(t, displays, 2)
+	assert.True(t, displays[0].Primary)
+	assert.Equal(t, "/pictures/all.jpg", displays[1].Path)
+}
+
+func writeTestPNG(t *testing.T, path string, width, height int) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, width, height))))
+	require.NoError(t, f.Close())
+}
+
+func TestEngine_NextSpan(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	localDir := filepath.Join(tmpDir, "local")
+	require.NoError(t, os.MkdirAll(localDir, 0755))
+	writeTestPNG(t, filepath.Join(localDir, "wide.png"), 64, 18)
+	writeTestPNG(t, filepath.Join(localDir, "normal.png"), 32, 18)
+
+	manager := datasource.NewManager(tmpDir, tmpDir)
+	manager.AddLocalSource(datasource.NewLocalSource("light-local-1", localDir, "light", false))
+
+	p := &displayPlatform{
+		displays: []platform.Display{
+			{Name: "DP-1", Width: 1920, Height: 1080},
+			{Name: "HDMI-1", X: 1920, Width: 1920, Height: 1080},
+		},
+		set: make(map[string]string),
+	}
+
+	e := &Engine{
+		config:   &config.Config{Theme: config.ThemeSettings{Mode: config.ThemeModeLight}},
+		state:    state.New(filepath.Join(tmpDir, "state.json")),
+		platform: p,
+		manager:  manager,
+		span:     true,
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := e.Next(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "wide.png", filepath.Base(result.Path))
+		assert.Equal(t, []string{"DP-1", "HDMI-1"}, result.SpanDisplays)
+	}
+
+	require.Len(t, p.set, 2)
+	assert.NotEqual(t, p.set["DP-1"], p.set["HDMI-1"])
+	assert.FileExists(t, p.set["DP-1"])
+	assert.Equal(t, filepath.Join(localDir, "wide.png"), e.state.CurrentEntry().Path)
+	assert.Empty(t, e.state.DisplaysCopy())
+
+	e.display = "DP-1"
+	_, err := e.Next(context.Background())
+	assert.Error(t, err)
+}
+
+func TestEngine_NextSpan_NoMatchingImage(t *testing.T) {
+	e, _ := newDisplayEngine(t, WithSpan(true))
+	e.platform.(*displayPlatform).displays = []platform.Display{
+		{Name: "DP-1", Width: 1920, Height: 1080},
+		{Name: "HDMI-1", X: 1920, Width: 1920, Height: 1080},
+	}
+
+	_, err := e.Next(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no images available")
+}
+
+func TestEngine_Refresh(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	require.NoError(t, e.state.Save())
+
+	other, err := state.Load(e.state.Path())
+	require.NoError(t, err)
+	other.SetCurrent("/tmp/other.jpg", "light-local-1", "light", "", "", false)
+	require.NoError(t, other.Save())
+
+	manager := e.manager
+	require.NoError(t, e.Refresh())
+	assert.Equal(t, "/tmp/other.jpg", e.state.CurrentEntry().Path)
+	assert.Same(t, manager, e.manager)
+
+	e.managerTheme = ThemeDark
+	require.NoError(t, e.Refresh())
+	assert.NotSame(t, manager, e.manager)
+	assert.Equal(t, ThemeLight, e.managerTheme)
+}
+
+func TestEngine_SwitchTheme(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	e.config.Theme.Mode = config.ThemeModeAuto
+
+	dir := filepath.Dir(e.state.Path())
+	lightPath := filepath.Join(dir, "light.jpg")
+	require.NoError(t, os.WriteFile(lightPath, []byte("test"), 0644))
+
+	e.state.SetCurrent(lightPath, "light-local-1", "light", "", "", false)
+	e.state.SetCurrent(filepath.Join(dir, "dark.jpg"), "dark-local-1", "dark", "", "", false)
+	require.NoError(t, e.state.Save())
+
+	result, err := e.SwitchTheme(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Restored)
+	assert.Equal(t, lightPath, result.Path)
+	assert.Equal(t, lightPath, e.state.CurrentEntry().Path)
+	assert.Equal(t, "light", e.state.CurrentEntry().Theme)
+
+	require.NoError(t, os.Remove(lightPath))
+	result, err = e.SwitchTheme(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Restored)
+	assert.NotEqual(t, lightPath, result.Path)
+}
+
+func TestEngine_WatchTheme(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	assert.Nil(t, e.WatchTheme(context.Background()))
+
+	e.config.Theme.Mode = config.ThemeModeAuto
+	e.themeOverride = "dark"
+	assert.Nil(t, e.WatchTheme(context.Background()))
+
+	e.themeOverride = ""
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := e.WatchTheme(ctx)
+	require.NotNil(t, ch)
+	cancel()
+	for range ch {
+	}
+}
+
+func TestEngine_detectThemeSolar(t *testing.T) {
+	e := &Engine{
+		config: &config.Config{Theme: config.ThemeSettings{
+			Mode:      config.ThemeModeSolar,
+			LightFrom: "00:00",
+			DarkFrom:  "23:59",
+		}},
+		platform: &mockPlatform{},
+	}
+	now := time.Now()
+	expected := ThemeLight
+	if now.Hour() == 23 && now.Minute() == 59 {
+		expected = ThemeDark
+	}
+	assert.Equal(t, expected, e.detectTheme())
+
+	e.config.Theme.LightFrom, e.config.Theme.DarkFrom = "23:59", "00:00"
+	expected = ThemeDark
+	if now.Hour() == 23 && now.Minute() == 59 {
+		expected = ThemeLight
+	}
+	assert.Equal(t, expected, e.detectTheme())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := e.WatchTheme(ctx)
+	require.NotNil(t, ch)
+	cancel()
+	for range ch {
+	}
+}
+
+func TestEngine_HistoryNavigation(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	ctx := context.Background()
+
+	var shown []string
+	for i := 0; i < 3; i++ {
+		result, err := e.Next(ctx)
+		require.NoError(t, err)
+		shown = append(shown, result.Path)
+	}
+
+	_, err := e.Forward(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no next wallpaper in history")
+
+	result, err := e.Prev(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.FromHistory)
+	assert.Equal(t, shown[1], result.Path)
+	assert.Equal(t, shown[1], e.state.CurrentEntry().Path)
+
+	result, err = e.Prev(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, shown[0], result.Path)
+
+	_, err = e.Prev(ctx)
+	assert.Contains(t, err.Error(), "no previous wallpaper in history")
+
+	result, err = e.Advance(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.FromHistory)
+	assert.Equal(t, shown[1], result.Path)
+
+	require.NoError(t, os.Remove(shown[2]))
+	_, err = e.Forward(ctx)
+	require.Error(t, err)
+
+	result, err = e.Advance(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.FromHistory)
+	stack := e.state.StackCopy()
+	assert.Equal(t, result.Path, stack[len(stack)-1].Path)
+	assert.Len(t, stack, 3)
+
+	e.display = "DP-1"
+	_, err = e.Prev(ctx)
+	assert.Contains(t, err.Error(), "cannot be combined with --display")
+}
+
+func TestEngine_PrevThenDeletePicksNewImage(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := e.Next(ctx)
+		require.NoError(t, err)
+	}
+	_, err := e.Prev(ctx)
+	require.NoError(t, err)
+
+	result, err := e.Delete(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.FromHistory)
+	stack := e.state.StackCopy()
+	require.Len(t, stack, 3)
+	assert.Equal(t, result.Path, stack[2].Path)
+
+	_, err = e.Prev(ctx)
+	require.NoError(t, err)
+	result, err = e.Ban(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.FromHistory)
+	stack = e.state.StackCopy()
+	assert.Equal(t, result.Path, stack[len(stack)-1].Path)
+}
+
+func TestEngine_Set(t *testing.T) {
+	e, p := newDisplayEngine(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	t.Run("local path", func(t *testing.T) {
+		path := filepath.Join(dir, "explicit.png")
+		writeTestPNG(t, path, 4, 4)
+
+		result, err := e.Set(ctx, path)
+		require.NoError(t, err)
+		assert.Equal(t, path, result.Path)
+		assert.Equal(t, "light-manual", result.SourceID)
+		assert.False(t, result.IsTemp)
+		assert.Equal(t, path, e.state.CurrentEntry().Path)
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		path := filepath.Join(dir, "anim.gif")
+		require.NoError(t, os.WriteFile(path, []byte("GIF89a"), 0644))
+
+		_, err := e.Set(ctx, path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported image format: .gif")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := e.Set(ctx, filepath.Join(dir, "missing.jpg"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "image not found")
+	})
+
+	t.Run("url is downloaded as temp and can be saved", func(t *testing.T) {
+		t.Setenv("TMPDIR", t.TempDir())
+		e.config.Light.UploadDir = filepath.Join(dir, "saved")
+
+		src := filepath.Join(dir, "served.png")
+		writeTestPNG(t, src, 4, 4)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, src)
+		}))
+		defer server.Close()
+
+		result, err := e.Set(ctx, server.URL+"/image")
+		require.NoError(t, err)
+		assert.True(t, result.IsTemp)
+		assert.Equal(t, "light-url", result.SourceID)
+		assert.True(t, strings.HasPrefix(result.Path, config.GetTempDir()))
+		assert.FileExists(t, result.Path)
+
+		saved, err := e.Save()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "saved", filepath.Base(result.Path)), saved.Path)
+		assert.FileExists(t, saved.Path)
+	})
+
+	t.Run("targeted display", func(t *testing.T) {
+		path := filepath.Join(dir, "explicit.png")
+		e.display = "HDMI-1"
+		defer func() { e.display = "" }()
+
+		result, err := e.Set(ctx, path)
+		require.NoError(t, err)
+		assert.Equal(t, "HDMI-1", result.Display)
+		assert.Equal(t, path, p.set["HDMI-1"])
+	})
+}
+
+func TestEngine_History(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	e.history = history.New(filepath.Join(dir, "history.jsonl"))
+
+	now := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	first := filepath.Join(dir, "first.png")
+	second := filepath.Join(dir, "second.png")
+	writeTestPNG(t, first, 4, 4)
+	writeTestPNG(t, second, 4, 4)
+
+	_, err := e.Set(ctx, first)
+	require.NoError(t, err)
+	now = now.Add(5 * time.Minute)
+	_, err = e.Set(ctx, second)
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+
+	entries, err := e.History(history.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, first, entries[0].Path)
+	assert.Equal(t, history.OutcomeSkipped, entries[0].Outcome)
+	assert.Equal(t, 5*time.Minute, entries[0].Duration)
+	assert.Equal(t, "light-manual", entries[0].SourceID)
+	assert.Equal(t, history.OutcomeCurrent, entries[1].Outcome)
+
+	entries, err = e.History(history.Filter{Since: now.Add(-2 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Index)
+
+	result, err := e.ApplyHistory(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, first, result.Path)
+
+	_, err = e.Ban(ctx)
+	require.NoError(t, err)
+
+	entries, err = e.History(history.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, history.OutcomeBanned, entries[2].Outcome)
+	assert.Equal(t, "light-local-1", entries[3].SourceID)
+
+	_, err = e.ApplyHistory(ctx, 9)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no history entry 9")
+
+	require.NoError(t, os.Remove(second))
+	_, err = e.ApplyHistory(ctx, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no longer available")
+}
+
+func TestEngine_ApplyHistoryRemote(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	e.history = history.New(filepath.Join(dir, "history.jsonl"))
+
+	temp := filepath.Join(dir, "temp", "wallhaven_abc123.png")
+	require.NoError(t, os.MkdirAll(filepath.Dir(temp), 0755))
+	writeTestPNG(t, temp, 4, 4)
+	require.NoError(t, e.history.Append(history.Record{
+		Event:    history.EventShown,
+		Time:     time.Now().Add(-time.Hour),
+		Path:     temp,
+		Theme:    "light",
+		SourceID: "light-wallhaven",
+		Query:    "nature",
+		ImageID:  "wallhaven:abc123",
+		URL:      "https://example.com/abc123.png",
+		IsTemp:   true,
+	}))
+
+	_, err := e.Next(ctx)
+	require.NoError(t, err)
+	e.manager.AddRemoteSource(datasource.NewRemoteSourceWithProvider("light-wallhaven", &stubProvider{}, "light",
+		filepath.Join(dir, "saved"), filepath.Join(dir, "temp"), []string{"nature"}, 1, e.state))
+
+	result, err := e.ApplyHistory(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, temp, result.Path)
+	assert.Equal(t, "light-wallhaven", result.SourceID)
+	assert.Equal(t, "nature", result.Query)
+	assert.True(t, result.IsTemp)
+	assert.True(t, result.FromHistory)
+
+	current := e.state.CurrentEntry()
+	assert.Equal(t, "light-wallhaven", current.SourceID)
+	assert.Equal(t, "nature", current.Query)
+	assert.True(t, current.IsTemp)
+
+	saved, err := e.Save()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "saved", "wallhaven_abc123.png"), saved.Path)
+	assert.FileExists(t, saved.Path)
+	assert.NoFileExists(t, temp)
+
+	entries, err := e.History(history.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "wallhaven:abc123", entries[2].ImageID)
+	assert.Equal(t, "https://example.com/abc123.png", entries[2].URL)
+	assert.Equal(t, history.OutcomeSaved, entries[2].Outcome)
+	assert.Equal(t, saved.Path, entries[2].SavedPath)
+}
+
+func TestEngine_ApplyHistoryPrefetched(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "source.png")
+	writeTestPNG(t, src, 4, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, src)
+	}))
+	defer server.Close()
+
+	st := state.New(filepath.Join(dir, "state.json"))
+	manager := datasource.NewManager(dir, dir)
+	manager.AddRemoteSource(datasource.NewRemoteSourceWithProvider("light-wallhaven", &stubProvider{baseURL: server.URL}, "light",
+		filepath.Join(dir, "saved"), filepath.Join(dir, "temp"), []string{"nature"}, 1, st))
+
+	e := &Engine{
+		config:   &config.Config{Theme: config.ThemeSettings{Mode: config.ThemeModeLight}},
+		state:    st,
+		platform: &mockPlatform{},
+		manager:  manager,
+		history:  history.New(filepath.Join(dir, "history.jsonl")),
+	}
+	ctx := context.Background()
+
+	_, err := e.Next(ctx)
+	require.NoError(t, err)
+	e.WaitPrefetch()
+
+	prefetched, err := e.Next(ctx)
+	require.NoError(t, err)
+	e.WaitPrefetch()
+	assert.Equal(t, filepath.Join(dir, "temp", "wallhaven_img2.png"), prefetched.Path)
+	assert.Equal(t, server.URL+"/img2.png", prefetched.URL)
+
+	_, err = e.Next(ctx)
+	require.NoError(t, err)
+	e.WaitPrefetch()
+
+	if err := os.Remove(prefetched.Path); err != nil {
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+
+	entries, err := e.History(history.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, server.URL+"/img2.png", entries[1].URL)
+	assert.Equal(t, "wallhaven:img2", entries[1].ImageID)
+
+	result, err := e.ApplyHistory(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, prefetched.Path, result.Path)
+	assert.FileExists(t, result.Path)
+	assert.Equal(t, "light-wallhaven", result.SourceID)
+	assert.Equal(t, "nature", result.Query)
+	assert.Equal(t, server.URL+"/img2.png", result.URL)
+	assert.True(t, result.IsTemp)
+	e.WaitPrefetch()
+}
+
+func TestEngine_ApplyHistoryBannedURL(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	e.history = history.New(filepath.Join(dir, "history.jsonl"))
+
+	src := filepath.Join(dir, "served.png")
+	writeTestPNG(t, src, 4, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, src)
+	}))
+	defer server.Close()
+	url := server.URL + "/image"
+
+	shown, err := e.Set(ctx, url)
+	require.NoError(t, err)
+	defer os.Remove(shown.Path)
+	_, err = e.Next(ctx)
+	require.NoError(t, err)
+
+	back, err := e.Prev(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, shown.Path, back.Path)
+	assert.Equal(t, url, back.URL)
+	assert.Equal(t, url, e.state.CurrentEntry().URL)
+
+	_, err = e.Ban(ctx)
+	require.NoError(t, err)
+	assert.NoFileExists(t, shown.Path)
+
+	records, err := e.history.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	assert.Equal(t, history.EventShown, records[2].Event)
+	assert.Equal(t, url, records[2].URL)
+	assert.Equal(t, history.EventBanned, records[3].Event)
+	assert.Equal(t, url, records[3].URL)
+
+	result, err := e.ApplyHistory(ctx, 3)
+	require.NoError(t, err)
+	defer os.Remove(result.Path)
+	assert.FileExists(t, result.Path)
+	assert.Equal(t, url, result.URL)
+	assert.Equal(t, "light-url", result.SourceID)
+	assert.True(t, result.IsTemp)
+}
+
+func TestEngine_Rate(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+
+	_, err := e.Like()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no wallpaper currently set")
+
+	_, err = e.Next(context.Background())
+	require.NoError(t, err)
+	current := e.state.CurrentEntry().Path
+
+	result, err := e.Like()
+	require.NoError(t, err)
+	assert.Equal(t, current, result.Key)
+	assert.Equal(t, 5, result.Rating)
+
+	result, err = e.Rate(2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rating)
+
+	loaded, err := state.Load(e.state.Path())
+	require.NoError(t, err)
+	score, ok := loaded.Rating(current)
+	assert.True(t, ok)
+	assert.Equal(t, 2, score)
+
+	info, err := e.Info()
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Rating)
+
+	_, err = e.Rate(6)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rating must be between 1 and 5")
+}
+
+func TestEngine_Ban(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	e.manager.SetBanList(e.state)
+	ctx := context.Background()
+
+	_, err := e.Next(ctx)
+	require.NoError(t, err)
+	banned := e.state.CurrentEntry().Path
+
+	result, err := e.Ban(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, banned, result.Path)
+	assert.FileExists(t, banned)
+
+	for i := 0; i < 10; i++ {
+		result, err := e.Next(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, banned, result.Path)
+	}
+
+	for {
+		result, err := e.Prev(ctx)
+		if err != nil {
+			break
+		}
+		assert.NotEqual(t, banned, result.Path)
+	}
+
+	bans := e.Bans()
+	require.Len(t, bans, 1)
+	assert.Equal(t, banned, bans[0].Key)
+
+	key, err := e.Unban(banned)
+	require.NoError(t, err)
+	assert.Equal(t, banned, key)
+	assert.Empty(t, e.Bans())
+
+	e.state.Ban(banned)
+	t.Chdir(filepath.Dir(banned))
+	key, err = e.Unban(filepath.Base(banned))
+	require.NoError(t, err)
+	assert.Equal(t, banned, key)
+
+	_, err = e.Unban(banned)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not banned")
+}
+
+func TestEngine_RefreshPicksUpLocalCommands(t *testing.T) {
+	daemon, p := newDisplayEngine(t)
+	daemon.manager.SetBanList(daemon.state)
+	ctx := context.Background()
+
+	first, err := daemon.Next(ctx)
+	require.NoError(t, err)
+
+	other, err := state.Load(daemon.state.Path())
+	require.NoError(t, err)
+	manager := datasource.NewManager(t.TempDir(), t.TempDir())
+	manager.AddLocalSource(datasource.NewLocalSource("light-local-1", filepath.Dir(first.Path), "light", false))
+	manager.SetBanList(other)
+	local := &Engine{config: daemon.config, state: other, platform: p, manager: manager}
+
+	second, err := local.Ban(ctx)
+	require.NoError(t, err)
+	_, err = local.Rate(2)
+	require.NoError(t, err)
+
+	require.NoError(t, daemon.Refresh())
+
+	info, err := daemon.Info()
+	require.NoError(t, err)
+	assert.Equal(t, second.Path, info.Path)
+	assert.Equal(t, 2, info.Rating)
+
+	for i := 0; i < 10; i++ {
+		result, err := daemon.Next(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Path, result.Path)
+	}
+}
+
+func TestEngine_Hooks(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	ctx := context.Background()
+	out := filepath.Join(t.TempDir(), "events")
+	record := []string{"sh", "-c", `echo "$WALLBOY_EVENT $WALLBOY_THEME $WALLBOY_SOURCE $WALLBOY_PATH" >> "$0"`, out}
+
+	var logs bytes.Buffer
+	e.hooks = newHookRunner([]config.HookConfig{
+		{On: config.HookSet, Command: record},
+		{On: config.HookSet, Command: []string{"false"}},
+		{On: config.HookDelete, Command: record},
+	}, log.New(&logs, "", 0))
+
+	first, err := e.Next(ctx)
+	require.NoError(t, err)
+	second, err := e.Delete(ctx)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"set light light-local-1 " + first.Path,
+		"set light light-local-1 " + second.Path,
+		"delete light light-local-1 " + first.Path,
+	}, strings.Split(strings.TrimSpace(string(data)), "\n"))
+	assert.Contains(t, logs.String(), "hook set (false) failed")
+
+	t.Run("dry run skips hooks", func(t *testing.T) {
+		e.dryRun = true
+		defer func() { e.dryRun = false }()
+
+		_, err := e.Next(ctx)
+		require.NoError(t, err)
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3)
+	})
+}
+
+func TestEngine_Templates(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	dir := t.TempDir()
+
+	wallpaper := filepath.Join(dir, "red.png")
+	writeSolidPNG(t, wallpaper, color.RGBA{R: 200, A: 255})
+
+	src := filepath.Join(dir, "colors.tmpl")
+	require.NoError(t, os.WriteFile(src, []byte("{{.Wallpaper}} {{index .Colors 0 | printf \"%v\"}} {{(index .Colors 0).Hex}}"), 0644))
+	dest := filepath.Join(dir, "out", "colors.conf")
+
+	_, err := e.RenderTemplates()
+	require.Error(t, err)
+
+	e.config.Templates = map[string]string{src: dest}
+
+	_, err = e.Set(context.Background(), wallpaper)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, wallpaper+" {200 0 0} #c80000", string(content))
+
+	require.NoError(t, os.Remove(dest))
+	rendered, err := e.RenderTemplates()
+	require.NoError(t, err)
+	assert.Equal(t, []string{dest}, rendered)
+	assert.FileExists(t, dest)
+}
+
+func writeSolidPNG(t *testing.T, path string, c color.Color) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+}
+
+func TestEngine_AnalyzeFileColors(t *testing.T) {
+	e, _ := newDisplayEngine(t)
+	path := filepath.Join(t.TempDir(), "blue.png")
+	writeSolidPNG(t, path, color.RGBA{B: 255, A: 255})
+
+	result, err := e.AnalyzeFileColors(path, 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, result)
+	assert.Equal(t, "#0000ff", result[0].Hex())
+	assert.InDelta(t, 1.0, result[0].Share, 0.001)
+
+	_, err = e.AnalyzeFileColors(filepath.Join(t.TempDir(), "missing.png"), 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "image not found")
+}
+
+type stubProvider struct {
+	baseURL   string
+	downloads atomic.Int64
+}
+
+func (p *stubProvider) Name() string { return "wallhaven" }
+
+func (p *stubProvider) Search(ctx context.Context, queries []string) ([]provider.ImageMeta, error) {
+	id := fmt.Sprintf("img%d", p.downloads.Add(1))
+	baseURL := p.baseURL
+	if baseURL == "" {
+		baseURL = "https://example.com"
+	}
+	return []provider.ImageMeta{{ID: id, DownloadURL: baseURL + "/" + id + ".png"}}, nil
+}
+
+func (p *stubProvider) Download(ctx context.Context, meta provider.ImageMeta, dest string) (string, error) {
+	if err := os.WriteFile(dest, []byte(meta.ID), 0644); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func TestEngine_ConcurrentStateWriters(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "state.json")
+
+	require.NoError(t, state.New(statePath).Save())
+	st, err := state.Load(statePath)
+	require.NoError(t, err)
+	manager := datasource.NewManager(tmpDir, tmpDir)
+	manager.AddRemoteSource(datasource.NewRemoteSourceWithProvider("light-stub", &stubProvider{}, "light",
+		filepath.Join(tmpDir, "saved"), filepath.Join(tmpDir, "temp"), []string{"nature"}, 1, st))
+
+	e := &Engine{
+		config:   &config.Config{Theme: config.ThemeSettings{Mode: config.ThemeModeLight}},
+		state:    st,
+		platform: &mockPlatform{},
+		manager:  manager,
+	}
+
+	const rounds = 20
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other, err := state.Load(statePath)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for i := 0; i < rounds; i++ {
+			other.Ban(fmt.Sprintf("/tmp/banned-%d.jpg", i))
+			assert.NoError(t, other.Save())
+		}
+	}()
+
+	for i := 0; i < rounds; i++ {
+		_, err := e.Next(context.Background())
+		require.NoError(t, err)
+		_, err = e.Info()
+		require.NoError(t, err)
+		e.Bans()
+	}
+	wg.Wait()
+	e.WaitPrefetch()
+
+	reloaded, err := state.Load(statePath)
+	require.NoError(t, err)
+	assert.Len(t, reloaded.BansCopy(), rounds)
+	assert.Equal(t, e.state.CurrentEntry().Path, reloaded.CurrentEntry().Path)
+}
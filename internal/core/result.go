@@ -1,47 +1,129 @@
 package core
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/Artawower/wallboy/internal/platform"
 )
 
 type WallpaperResult struct {
-	Path     string
-	Theme    string
-	SourceID string
-	IsTemp   bool
-	SetAt    time.Time
-	Query    string
+	Path     string    `json:"path"`
+	Theme    string    `json:"theme"`
+	SourceID string    `json:"source_id"`
+	IsTemp   bool      `json:"is_temp"`
+	SetAt    time.Time `json:"set_at"`
+	Query    string    `json:"query,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Display  string    `json:"display,omitempty"`
+	Restored bool      `json:"restored"`
+
+	FromHistory  bool               `json:"from_history"`
+	SpanDisplays []string           `json:"span_displays,omitempty"`
+	Displays     []*WallpaperResult `json:"displays,omitempty"`
 }
 
 type WallpaperInfo struct {
-	Path     string
-	Theme    string
-	SourceID string
-	IsTemp   bool
-	SetAt    time.Time
-	Exists   bool
-	Query    string
+	Path     string          `json:"path"`
+	Theme    string          `json:"theme"`
+	SourceID string          `json:"source_id"`
+	IsTemp   bool            `json:"is_temp"`
+	SetAt    time.Time       `json:"set_at"`
+	Exists   bool            `json:"exists"`
+	Query    string          `json:"query,omitempty"`
+	Display  string          `json:"display,omitempty"`
+	Rating   int             `json:"rating,omitempty"`
+	Displays []WallpaperInfo `json:"displays,omitempty"`
+}
+
+type BanInfo struct {
+	Key      string    `json:"key"`
+	BannedAt time.Time `json:"banned_at"`
+}
+
+type RatingResult struct {
+	Path    string `json:"path"`
+	Key     string `json:"key"`
+	Rating  int    `json:"rating"`
+	Display string `json:"display,omitempty"`
+}
+
+type DisplayInfo struct {
+	Name    string `json:"name"`
+	Primary bool   `json:"primary"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Path    string `json:"path,omitempty"`
 }
 
 type SourceInfo struct {
-	ID          string
-	Theme       string
-	Type        string
-	Description string
+	ID          string `json:"id"`
+	Theme       string `json:"theme"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
 }
 
 type AgentStatus struct {
 	Supported bool
 	Installed bool
 	Running   bool
+	Daemon    bool
 	Interval  time.Duration
 	LogPath   string
 }
 
+func (s AgentStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Supported       bool    `json:"supported"`
+		Installed       bool    `json:"installed"`
+		Running         bool    `json:"running"`
+		Daemon          bool    `json:"daemon"`
+		IntervalSeconds float64 `json:"interval_seconds"`
+		LogPath         string  `json:"log_path,omitempty"`
+	}{s.Supported, s.Installed, s.Running, s.Daemon, s.Interval.Seconds(), s.LogPath})
+}
+
+type DoctorReport struct {
+	Platform  string       `json:"platform"`
+	Supported bool         `json:"supported"`
+	Backend   string       `json:"backend"`
+	Tool      string       `json:"tool"`
+	Tools     []ToolStatus `json:"tools"`
+	Theme     string       `json:"theme"`
+	Scheduler bool         `json:"scheduler"`
+}
+
+type ToolStatus struct {
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	Available bool   `json:"available"`
+}
+
 type Color struct {
 	R, G, B uint8
+	Share   float64
+}
+
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Hex   string   `json:"hex"`
+		RGB   [3]uint8 `json:"rgb"`
+		Share float64  `json:"share"`
+	}{c.Hex(), [3]uint8{c.R, c.G, c.B}, c.Share})
+}
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var v struct {
+		RGB   [3]uint8 `json:"rgb"`
+		Share float64  `json:"share"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = Color{R: v.RGB[0], G: v.RGB[1], B: v.RGB[2], Share: v.Share}
+	return nil
 }
 
 func (c Color) Hex() string {
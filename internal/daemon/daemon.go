@@ -0,0 +1,327 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/control"
+	"github.com/Artawower/wallboy/internal/core"
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+type Engine interface {
+	Next(ctx context.Context) (*core.WallpaperResult, error)
+	Advance(ctx context.Context) (*core.WallpaperResult, error)
+	SwitchTheme(ctx context.Context) (*core.WallpaperResult, error)
+	Prev(ctx context.Context) (*core.WallpaperResult, error)
+	Save() (*core.WallpaperResult, error)
+	Delete(ctx context.Context) (*core.WallpaperResult, error)
+	Info() (*core.WallpaperInfo, error)
+	AnalyzeColors(topN int) ([]core.Color, error)
+	WatchTheme(ctx context.Context) <-chan platform.Theme
+	Refresh() error
+	Warm(ctx context.Context)
+	WaitPrefetch()
+}
+
+type Factory func() (Engine, error)
+
+type EventType string
+
+const (
+	EventRotate      EventType = "rotate"
+	EventThemeChange EventType = "theme-change"
+)
+
+type Event struct {
+	Type   EventType
+	Theme  platform.Theme
+	Result *core.WallpaperResult
+	Err    error
+}
+
+const defaultPaletteSize = 10
+
+type Daemon struct {
+	factory  Factory
+	interval time.Duration
+	logger   *log.Logger
+	engine   Engine
+	listener func(Event)
+	socket   string
+	calls    chan call
+	paused   bool
+	retired  sync.WaitGroup
+
+	stopWatch context.CancelFunc
+}
+
+type call struct {
+	method string
+	params json.RawMessage
+	reply  chan callResult
+}
+
+type callResult struct {
+	result any
+	err    error
+}
+
+func New(factory Factory, interval time.Duration, logger *log.Logger) *Daemon {
+	return &Daemon{
+		factory:  factory,
+		interval: interval,
+		logger:   logger,
+		calls:    make(chan call),
+	}
+}
+
+func (d *Daemon) SetControlSocket(path string) {
+	d.socket = path
+}
+
+func (d *Daemon) SetListener(fn func(Event)) {
+	d.listener = fn
+}
+
+func (d *Daemon) notify(event Event) {
+	if d.listener != nil {
+		d.listener(event)
+	}
+}
+
+func (d *Daemon) Run(ctx context.Context, reload <-chan os.Signal) error {
+	engine, err := d.factory()
+	if err != nil {
+		return fmt.Errorf("failed to start engine: %w", err)
+	}
+	d.engine = engine
+
+	if d.socket != "" {
+		stop := d.serveControl(ctx)
+		defer stop()
+	}
+
+	var tick <-chan time.Time
+	if d.interval > 0 {
+		d.logger.Printf("daemon started, rotating every %s", d.interval)
+		d.rotate(ctx)
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	} else {
+		d.logger.Printf("daemon started, watching theme changes")
+	}
+
+	themes := d.watchTheme(ctx)
+	defer d.stopWatch()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.engine.WaitPrefetch()
+			d.retired.Wait()
+			d.logger.Printf("daemon stopped")
+			return nil
+		case <-reload:
+			if d.reload() {
+				themes = d.watchTheme(ctx)
+			}
+		case theme, ok := <-themes:
+			if !ok {
+				d.logger.Printf("theme watcher stopped")
+				themes = nil
+				continue
+			}
+			d.switchTheme(ctx, theme)
+		case c := <-d.calls:
+			result, err := d.handle(ctx, c.method, c.params)
+			c.reply <- callResult{result: result, err: err}
+			if err == nil && changesWallpaper(c.method) {
+				d.engine.Warm(ctx)
+			}
+		case <-tick:
+			if !d.paused {
+				d.rotate(ctx)
+			}
+		}
+	}
+}
+
+func (d *Daemon) watchTheme(ctx context.Context) <-chan platform.Theme {
+	if d.stopWatch != nil {
+		d.stopWatch()
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	d.stopWatch = cancel
+	return d.engine.WatchTheme(watchCtx)
+}
+
+func (d *Daemon) rotate(ctx context.Context) {
+	if err := d.engine.Refresh(); err != nil {
+		d.logger.Printf("refresh failed: %v", err)
+	}
+
+	result, err := d.engine.Next(ctx)
+	if err != nil {
+		d.logger.Printf("rotation failed: %v", err)
+		d.notify(Event{Type: EventRotate, Err: err})
+		return
+	}
+
+	d.logger.Printf("rotated: %s (source=%s theme=%s)", result.Path, result.SourceID, result.Theme)
+	d.notify(Event{Type: EventRotate, Result: result})
+	d.engine.Warm(ctx)
+}
+
+func (d *Daemon) switchTheme(ctx context.Context, theme platform.Theme) {
+	result, err := d.engine.SwitchTheme(ctx)
+	if err != nil {
+		d.logger.Printf("theme changed to %s, switch failed: %v", theme, err)
+		d.notify(Event{Type: EventThemeChange, Theme: theme, Err: err})
+		return
+	}
+
+	action := "rotated"
+	if result.Restored {
+		action = "restored"
+	}
+	d.logger.Printf("theme changed to %s, %s: %s (source=%s)", theme, action, result.Path, result.SourceID)
+	d.notify(Event{Type: EventThemeChange, Theme: theme, Result: result})
+	d.engine.Warm(ctx)
+}
+
+func (d *Daemon) reload() bool {
+	engine, err := d.factory()
+	if err != nil {
+		d.logger.Printf("reload failed, keeping previous config: %v", err)
+		return false
+	}
+
+	previous := d.engine
+	d.retired.Add(1)
+	go func() {
+		defer d.retired.Done()
+		previous.WaitPrefetch()
+	}()
+	d.engine = engine
+	d.logger.Printf("config reloaded")
+	return true
+}
+
+func (d *Daemon) serveControl(ctx context.Context) func() {
+	srv, err := control.Listen(d.socket, d.call, d.logger)
+	if err != nil {
+		d.logger.Printf("control socket disabled: %v", err)
+		return func() {}
+	}
+	d.logger.Printf("control socket listening on %s", srv.Path())
+
+	serveCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := srv.Serve(serveCtx); err != nil {
+			d.logger.Printf("control socket stopped: %v", err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (d *Daemon) call(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	c := call{method: method, params: params, reply: make(chan callResult, 1)}
+	select {
+	case d.calls <- c:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case r := <-c.reply:
+		return r.result, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (d *Daemon) handle(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	if method == control.MethodPause {
+		var p control.PauseParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		d.paused = !d.paused
+		if p.Paused != nil {
+			d.paused = *p.Paused
+		}
+		d.logger.Printf("rotation paused: %t", d.paused)
+		return control.PauseResult{Paused: d.paused}, nil
+	}
+
+	if err := d.engine.Refresh(); err != nil {
+		d.logger.Printf("refresh failed: %v", err)
+	}
+
+	switch method {
+	case control.MethodNext:
+		result, err := d.engine.Advance(ctx)
+		d.logChange("next", result, err)
+		return result, err
+	case control.MethodPrev:
+		result, err := d.engine.Prev(ctx)
+		d.logChange("prev", result, err)
+		return result, err
+	case control.MethodSave:
+		return d.engine.Save()
+	case control.MethodDelete:
+		result, err := d.engine.Delete(ctx)
+		d.logChange("delete", result, err)
+		return result, err
+	case control.MethodInfo:
+		return d.engine.Info()
+	case control.MethodPalette:
+		p := control.PaletteParams{Top: defaultPaletteSize}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return d.engine.AnalyzeColors(p.Top)
+	default:
+		return nil, fmt.Errorf("%w: %s", control.ErrMethodNotFound, method)
+	}
+}
+
+func (d *Daemon) logChange(action string, result *core.WallpaperResult, err error) {
+	if err != nil {
+		d.logger.Printf("%s failed: %v", action, err)
+		return
+	}
+	d.logger.Printf("%s: %s (source=%s theme=%s)", action, result.Path, result.SourceID, result.Theme)
+}
+
+func changesWallpaper(method string) bool {
+	switch method {
+	case control.MethodNext, control.MethodPrev, control.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return fmt.Errorf("%w: %v", control.ErrInvalidParams, err)
+	}
+	return nil
+}
@@ -0,0 +1,470 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/control"
+	"github.com/Artawower/wallboy/internal/core"
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/Artawower/wallboy/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEngine struct {
+	mu        sync.Mutex
+	id        int
+	nextErr   error
+	rotations int
+	refreshes int
+	warms     int
+	waits     int
+	switches  int
+	rotated   chan int
+	themes    chan platform.Theme
+}
+
+func (f *fakeEngine) Next(ctx context.Context) (*core.WallpaperResult, error) {
+	f.mu.Lock()
+	f.rotations++
+	f.mu.Unlock()
+	defer func() { f.rotated <- f.id }()
+
+	if f.nextErr != nil {
+		return nil, f.nextErr
+	}
+	return &core.WallpaperResult{Path: "/tmp/a.jpg", SourceID: "light-local-1", Theme: "light"}, nil
+}
+
+func (f *fakeEngine) SwitchTheme(ctx context.Context) (*core.WallpaperResult, error) {
+	f.mu.Lock()
+	f.switches++
+	f.mu.Unlock()
+	defer func() { f.rotated <- f.id }()
+
+	return &core.WallpaperResult{Path: "/tmp/dark.jpg", SourceID: "dark-local-1", Theme: "dark", Restored: true}, nil
+}
+
+func (f *fakeEngine) Prev(ctx context.Context) (*core.WallpaperResult, error) {
+	return &core.WallpaperResult{Path: "/tmp/prev.jpg", SourceID: "light-local-1", Theme: "light", FromHistory: true}, nil
+}
+
+func (f *fakeEngine) Save() (*core.WallpaperResult, error) {
+	return nil, core.ErrNoWallpaper
+}
+
+func (f *fakeEngine) Advance(ctx context.Context) (*core.WallpaperResult, error) {
+	return f.Next(ctx)
+}
+
+func (f *fakeEngine) Delete(ctx context.Context) (*core.WallpaperResult, error) {
+	return f.Next(ctx)
+}
+
+func (f *fakeEngine) Info() (*core.WallpaperInfo, error) {
+	return &core.WallpaperInfo{Path: "/tmp/a.jpg", SourceID: "light-local-1", Theme: "light", Exists: true}, nil
+}
+
+func (f *fakeEngine) AnalyzeColors(topN int) ([]core.Color, error) {
+	return make([]core.Color, topN), nil
+}
+
+func (f *fakeEngine) WatchTheme(ctx context.Context) <-chan platform.Theme {
+	return f.themes
+}
+
+func (f *fakeEngine) Refresh() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refreshes++
+	return nil
+}
+
+func (f *fakeEngine) Warm(ctx context.Context) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.warms++
+}
+
+func (f *fakeEngine) WaitPrefetch() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waits++
+}
+
+type fakeFactory struct {
+	engines []*fakeEngine
+	err     error
+	rotated chan int
+}
+
+func (f *fakeFactory) create() (Engine, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	engine := &fakeEngine{id: len(f.engines), rotated: f.rotated}
+	f.engines = append(f.engines, engine)
+	return engine, nil
+}
+
+func waitRotation(t *testing.T, rotated <-chan int) int {
+	select {
+	case id := <-rotated:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for rotation")
+		return -1
+	}
+}
+
+func TestDaemon_RotatesOnInterval(t *testing.T) {
+	factory := &fakeFactory{rotated: make(chan int, 16)}
+	var logs bytes.Buffer
+	d := New(factory.create, 10*time.Millisecond, log.New(&logs, "", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, nil) }()
+
+	for i := 0; i < 3; i++ {
+		waitRotation(t, factory.rotated)
+	}
+	cancel()
+	require.NoError(t, <-done)
+
+	require.Len(t, factory.engines, 1)
+	engine := factory.engines[0]
+	assert.GreaterOrEqual(t, engine.rotations, 3)
+	assert.Equal(t, engine.rotations, engine.refreshes)
+	assert.GreaterOrEqual(t, engine.warms, 3)
+	assert.Equal(t, 1, engine.waits)
+
+	assert.Contains(t, logs.String(), "daemon started, rotating every 10ms")
+	assert.Contains(t, logs.String(), "rotated: /tmp/a.jpg (source=light-local-1 theme=light)")
+	assert.Contains(t, logs.String(), "daemon stopped")
+}
+
+func TestDaemon_ReloadSwapsEngine(t *testing.T) {
+	factory := &fakeFactory{rotated: make(chan int, 16)}
+	var logs bytes.Buffer
+	d := New(factory.create, 10*time.Millisecond, log.New(&logs, "", 0))
+
+	reload := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, reload) }()
+
+	assert.Equal(t, 0, waitRotation(t, factory.rotated))
+	reload <- syscall.SIGHUP
+
+	for waitRotation(t, factory.rotated) != 1 {
+	}
+	cancel()
+	require.NoError(t, <-done)
+
+	require.Len(t, factory.engines, 2)
+	assert.Equal(t, 1, factory.engines[0].waits)
+	assert.Contains(t, logs.String(), "config reloaded")
+}
+
+func TestDaemon_SwitchesOnThemeChange(t *testing.T) {
+	themes := make(chan platform.Theme)
+	engine := &fakeEngine{rotated: make(chan int, 1), themes: themes}
+	var logs bytes.Buffer
+	d := New(func() (Engine, error) { return engine, nil }, 0, log.New(&logs, "", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, nil) }()
+
+	themes <- platform.ThemeDark
+	waitRotation(t, engine.rotated)
+	close(themes)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, 0, engine.rotations)
+	assert.Equal(t, 1, engine.switches)
+	assert.Equal(t, 1, engine.warms)
+	assert.Contains(t, logs.String(), "daemon started, watching theme changes")
+	assert.Contains(t, logs.String(), "theme changed to dark, restored: /tmp/dark.jpg (source=dark-local-1)")
+}
+
+func TestDaemon_ReloadFailureKeepsEngine(t *testing.T) {
+	factory := &fakeFactory{rotated: make(chan int, 16)}
+	var logs bytes.Buffer
+	d := New(factory.create, time.Hour, log.New(&logs, "", 0))
+
+	engine, err := factory.create()
+	require.NoError(t, err)
+	d.engine = engine
+
+	factory.err = errors.New("invalid config")
+	d.reload()
+
+	assert.Same(t, engine, d.engine)
+	assert.Contains(t, logs.String(), "reload failed, keeping previous config: invalid config")
+}
+
+func TestDaemon_RotationErrorIsLogged(t *testing.T) {
+	var logs bytes.Buffer
+	engine := &fakeEngine{nextErr: errors.New("no sources"), rotated: make(chan int, 1)}
+	d := New(nil, time.Hour, log.New(&logs, "", 0))
+	d.engine = engine
+
+	d.rotate(context.Background())
+
+	assert.Contains(t, logs.String(), "rotation failed: no sources")
+	assert.Equal(t, 0, engine.warms)
+}
+
+func TestDaemon_FactoryError(t *testing.T) {
+	factory := &fakeFactory{err: errors.New("bad config")}
+	d := New(factory.create, time.Minute, log.New(&bytes.Buffer{}, "", 0))
+
+	err := d.Run(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to start engine")
+}
+
+func TestDaemon_NotifiesListener(t *testing.T) {
+	engine := &fakeEngine{rotated: make(chan int, 3)}
+	d := New(nil, time.Hour, log.New(&bytes.Buffer{}, "", 0))
+	d.engine = engine
+
+	var events []Event
+	d.SetListener(func(e Event) { events = append(events, e) })
+
+	d.rotate(context.Background())
+	d.switchTheme(context.Background(), platform.ThemeDark)
+	engine.nextErr = errors.New("no sources")
+	d.rotate(context.Background())
+
+	require.Len(t, events, 3)
+	assert.Equal(t, EventRotate, events[0].Type)
+	assert.Equal(t, "/tmp/a.jpg", events[0].Result.Path)
+	assert.Equal(t, EventThemeChange, events[1].Type)
+	assert.Equal(t, platform.ThemeDark, events[1].Theme)
+	assert.True(t, events[1].Result.Restored)
+	assert.Equal(t, EventRotate, events[2].Type)
+	assert.Nil(t, events[2].Result)
+	assert.EqualError(t, events[2].Err, "no sources")
+}
+
+func dialControl(t *testing.T, path string) *control.Client {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client, err := control.Dial(path)
+		if err == nil {
+			return client
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("control socket not ready: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemon_ControlSocket(t *testing.T) {
+	engine := &fakeEngine{rotated: make(chan int, 4)}
+	var logs bytes.Buffer
+	d := New(func() (Engine, error) { return engine, nil }, time.Hour, log.New(&logs, "", 0))
+	socket := filepath.Join(t.TempDir(), "wallboy.sock")
+	d.SetControlSocket(socket)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, nil) }()
+	waitRotation(t, engine.rotated)
+
+	client := dialControl(t, socket)
+	defer client.Close()
+
+	var result core.WallpaperResult
+	require.NoError(t, client.Call(control.MethodNext, nil, &result))
+	assert.Equal(t, "/tmp/a.jpg", result.Path)
+
+	require.NoError(t, client.Call(control.MethodPrev, nil, &result))
+	assert.True(t, result.FromHistory)
+
+	var info core.WallpaperInfo
+	require.NoError(t, client.Call(control.MethodInfo, nil, &info))
+	assert.True(t, info.Exists)
+
+	var palette []core.Color
+	require.NoError(t, client.Call(control.MethodPalette, control.PaletteParams{Top: 3}, &palette))
+	assert.Len(t, palette, 3)
+
+	var pause control.PauseResult
+	require.NoError(t, client.Call(control.MethodPause, nil, &pause))
+	assert.True(t, pause.Paused)
+	paused := false
+	require.NoError(t, client.Call(control.MethodPause, control.PauseParams{Paused: &paused}, &pause))
+	assert.False(t, pause.Paused)
+
+	assert.ErrorIs(t, client.Call(control.MethodSave, nil, nil), core.ErrNoWallpaper)
+
+	var rpcErr *control.Error
+	require.ErrorAs(t, client.Call("bogus", nil, nil), &rpcErr)
+	assert.Equal(t, control.CodeMethodNotFound, rpcErr.Code)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.NoFileExists(t, socket)
+
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	assert.Equal(t, 2, engine.rotations)
+	assert.Equal(t, 7, engine.refreshes)
+	assert.Contains(t, logs.String(), "control socket listening on "+socket)
+	assert.Contains(t, logs.String(), "next: /tmp/a.jpg (source=light-local-1 theme=light)")
+}
+
+func TestDaemon_PauseAndInvalidParams(t *testing.T) {
+	engine := &fakeEngine{rotated: make(chan int, 1)}
+	d := New(nil, time.Hour, log.New(&bytes.Buffer{}, "", 0))
+	d.engine = engine
+
+	result, err := d.handle(context.Background(), control.MethodPause, nil)
+	require.NoError(t, err)
+	assert.Equal(t, control.PauseResult{Paused: true}, result)
+	assert.True(t, d.paused)
+
+	_, err = d.handle(context.Background(), control.MethodPalette, []byte(`{"top":"x"}`))
+	assert.ErrorIs(t, err, control.ErrInvalidParams)
+}
+
+type slowProvider struct {
+	mu        sync.Mutex
+	downloads int
+	started   chan struct{}
+	release   chan struct{}
+}
+
+func (p *slowProvider) Name() string { return "wallhaven" }
+
+func (p *slowProvider) Search(ctx context.Context, queries []string) ([]provider.ImageMeta, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := fmt.Sprintf("img%d", p.downloads)
+	return []provider.ImageMeta{{ID: id, DownloadURL: "https://example.com/" + id + ".jpg"}}, nil
+}
+
+func (p *slowProvider) Download(ctx context.Context, meta provider.ImageMeta, dest string) (string, error) {
+	p.mu.Lock()
+	p.downloads++
+	n := p.downloads
+	p.mu.Unlock()
+
+	if n == 2 {
+		close(p.started)
+		<-p.release
+	}
+	if err := os.WriteFile(dest, []byte(meta.ID), 0644); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+type memoryPlatform struct {
+	platform.Platform
+	mu      sync.Mutex
+	current string
+}
+
+func (p *memoryPlatform) IsSupported() bool                    { return true }
+func (p *memoryPlatform) Wallpaper() platform.WallpaperService { return p }
+func (p *memoryPlatform) Theme() platform.ThemeService         { return p }
+func (p *memoryPlatform) Detect() platform.Theme               { return platform.ThemeLight }
+
+func (p *memoryPlatform) Set(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = path
+	return nil
+}
+
+func (p *memoryPlatform) Get() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current, nil
+}
+
+func TestDaemon_PrefetchDoesNotBlockCalls(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(fmt.Sprintf(`
+[state]
+path = %q
+
+[theme]
+mode = "light"
+
+[providers.wallhaven]
+auth = "key"
+
+[light]
+dirs = []
+upload-dir = %q
+queries = ["nature"]
+
+[dark]
+dirs = []
+queries = ["space"]
+`, filepath.Join(dir, "state.json"), filepath.Join(dir, "saved"))), 0644))
+
+	slow := &slowProvider{started: make(chan struct{}), release: make(chan struct{})}
+	factory := func() (Engine, error) {
+		return core.New(cfgPath,
+			core.WithPlatform(&memoryPlatform{Platform: platform.Unsupported("test")}),
+			core.WithProviderFactory(func(name, auth string) provider.Provider { return slow }),
+		)
+	}
+
+	d := New(factory, 0, log.New(&bytes.Buffer{}, "", 0))
+	socket := filepath.Join(dir, "wallboy.sock")
+	d.SetControlSocket(socket)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, nil) }()
+
+	client := dialControl(t, socket)
+	defer client.Close()
+
+	var first core.WallpaperResult
+	require.NoError(t, client.Call(control.MethodNext, nil, &first))
+
+	select {
+	case <-slow.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prefetch did not start")
+	}
+
+	second := make(chan error, 1)
+	go func() {
+		var result core.WallpaperResult
+		second <- client.Call(control.MethodNext, nil, &result)
+	}()
+
+	select {
+	case err := <-second:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("second call blocked behind the prefetch")
+	}
+
+	close(slow.release)
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,20 @@
+package datasource
+
+type BanList interface {
+	IsBanned(key string) bool
+}
+
+func (m *Manager) SetBanList(bans BanList) {
+	m.bans = bans
+	for _, s := range m.remoteSources {
+		s.bans = bans
+	}
+}
+
+func (m *Manager) isBanned(img Image) bool {
+	return m.bans != nil && m.bans.IsBanned(m.ImageKey(img.Path))
+}
+
+func (s *RemoteSource) isBanned(key string) bool {
+	return s.bans != nil && s.bans.IsBanned(key)
+}
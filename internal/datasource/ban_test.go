@@ -0,0 +1,88 @@
+package datasource
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Artawower/wallboy/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type setBans map[string]bool
+
+func (b setBans) IsBanned(key string) bool { return b[key] }
+
+func TestManager_PickRandomLocal_SkipsBanned(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"keep.jpg", "banned.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte("test"), 0644))
+	}
+
+	m := NewManager("/upload", "/temp")
+	m.AddLocalSource(NewLocalSource("test-source", tmpDir, "light", false))
+	m.SetBanList(setBans{filepath.Join(tmpDir, "banned.jpg"): true})
+
+	t.Run("banned image is never picked", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			img, err := m.PickRandomLocal(context.Background(), "light", nil)
+			require.NoError(t, err)
+			assert.Equal(t, "keep.jpg", filepath.Base(img.Path))
+		}
+	})
+
+	t.Run("history fallback does not bring banned back", func(t *testing.T) {
+		img, err := m.PickRandomLocal(context.Background(), "light", []string{filepath.Join(tmpDir, "keep.jpg")})
+		require.NoError(t, err)
+		assert.Equal(t, "keep.jpg", filepath.Base(img.Path))
+	})
+
+	t.Run("everything banned", func(t *testing.T) {
+		m.SetBanList(setBans{
+			filepath.Join(tmpDir, "keep.jpg"):   true,
+			filepath.Join(tmpDir, "banned.jpg"): true,
+		})
+		_, err := m.PickRandomLocal(context.Background(), "light", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no images available")
+	})
+}
+
+func TestRemoteSource_FetchRandom_SkipsBanned(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	mock := &mockProvider{
+		name: "mock",
+		searchResults: []provider.ImageMeta{
+			{ID: "img1", DownloadURL: "http://example.com/img1.jpg"},
+			{ID: "img2", DownloadURL: "http://example.com/img2.jpg"},
+		},
+	}
+
+	source := &RemoteSource{
+		id:       "test-remote",
+		provider: mock,
+		tempDir:  filepath.Join(tmpDir, "temp"),
+		theme:    "light",
+		rng:      rand.New(rand.NewSource(42)),
+	}
+
+	m := NewManager("/upload", "/temp")
+	m.SetBanList(setBans{"mock:img1": true})
+	m.AddRemoteSource(source)
+
+	for i := 0; i < 10; i++ {
+		img, err := source.FetchRandom(context.Background(), "")
+		require.NoError(t, err)
+		assert.Equal(t, "mock_img2.jpg", filepath.Base(img.Path))
+	}
+	assert.Len(t, mock.searchResults, 2)
+
+	m.SetBanList(setBans{"mock:img1": true, "mock:img2": true})
+	_, err := source.FetchRandom(context.Background(), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no images found")
+}
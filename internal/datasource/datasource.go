@@ -2,6 +2,7 @@ package datasource
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -10,6 +11,8 @@ import (
 	"time"
 )
 
+var ErrNoImages = errors.New("no images available")
+
 var SupportedExtensions = map[string]bool{
 	".jpg":  true,
 	".jpeg": true,
@@ -27,8 +30,8 @@ type Image struct {
 }
 
 type PrefetchStore interface {
-	GetPrefetch(sourceID string) (path, query string, ok bool)
-	SetPrefetch(sourceID, path, query string)
+	GetPrefetch(sourceID string) (path, query, url string, ok bool)
+	SetPrefetch(sourceID, path, query, url string)
 	ClearPrefetch(sourceID string)
 	Save() error
 }
@@ -113,6 +116,8 @@ type Manager struct {
 	remoteSources []*RemoteSource
 	uploadDir     string
 	tempDir       string
+	ratings       RatingStore
+	bans          BanList
 	rng           *rand.Rand
 }
 
@@ -132,6 +137,9 @@ func (m *Manager) AddLocalSource(source *LocalSource) {
 }
 
 func (m *Manager) AddRemoteSource(source *RemoteSource) {
+	if m.bans != nil {
+		source.bans = m.bans
+	}
 	m.remoteSources = append(m.remoteSources, source)
 }
 
@@ -182,6 +190,10 @@ func (m *Manager) HasRemoteSources(theme string) bool {
 }
 
 func (m *Manager) PickRandomLocal(ctx context.Context, theme string, excludeHistory []string) (*Image, error) {
+	return m.PickRandomLocalFunc(ctx, theme, excludeHistory, nil)
+}
+
+func (m *Manager) PickRandomLocalFunc(ctx context.Context, theme string, excludeHistory []string, accept func(Image) bool) (*Image, error) {
 	sources := m.GetLocalSources(theme)
 	if len(sources) == 0 {
 		return nil, fmt.Errorf("no local sources for theme: %s", theme)
@@ -205,16 +217,17 @@ func (m *Manager) PickRandomLocal(ctx context.Context, theme string, excludeHist
 			lastErr = fmt.Errorf("source %s: %w", source.ID(), err)
 			continue
 		}
+		if accept != nil {
+			images = filterImages(images, accept)
+		}
+		if m.bans != nil {
+			images = filterImages(images, func(img Image) bool { return !m.isBanned(img) })
+		}
 		if len(images) == 0 {
 			continue
 		}
 
-		var filtered []Image
-		for _, img := range images {
-			if !historySet[img.Path] {
-				filtered = append(filtered, img)
-			}
-		}
+		filtered := filterImages(images, func(img Image) bool { return !historySet[img.Path] })
 
 		if len(filtered) == 0 {
 			filtered = images
@@ -225,16 +238,25 @@ func (m *Manager) PickRandomLocal(ctx context.Context, theme string, excludeHist
 
 	if len(available) == 0 {
 		if lastErr != nil {
-			return nil, fmt.Errorf("no images available for theme %s: %w", theme, lastErr)
+			return nil, fmt.Errorf("%w for theme %s: %w", ErrNoImages, theme, lastErr)
 		}
-		return nil, fmt.Errorf("no images available for theme: %s", theme)
+		return nil, fmt.Errorf("%w for theme: %s", ErrNoImages, theme)
 	}
 
 	sourceIdx := m.rng.Intn(len(available))
 	selected := available[sourceIdx]
 
-	imgIdx := m.rng.Intn(len(selected.images))
-	return &selected.images[imgIdx], nil
+	return m.pickWeighted(selected.images), nil
+}
+
+func filterImages(images []Image, keep func(Image) bool) []Image {
+	var filtered []Image
+	for _, img := range images {
+		if keep(img) {
+			filtered = append(filtered, img)
+		}
+	}
+	return filtered
 }
 
 func (m *Manager) PickRandomFromLocalSource(ctx context.Context, sourceID string, excludeHistory []string) (*Image, error) {
@@ -248,6 +270,10 @@ func (m *Manager) PickRandomFromLocalSource(ctx context.Context, sourceID string
 		return nil, fmt.Errorf("failed to list images: %w", err)
 	}
 
+	if m.bans != nil {
+		images = filterImages(images, func(img Image) bool { return !m.isBanned(img) })
+	}
+
 	if len(images) == 0 {
 		return nil, fmt.Errorf("no images in source: %s", sourceID)
 	}
@@ -268,8 +294,7 @@ func (m *Manager) PickRandomFromLocalSource(ctx context.Context, sourceID string
 		filtered = images
 	}
 
-	idx := m.rng.Intn(len(filtered))
-	return &filtered[idx], nil
+	return m.pickWeighted(filtered), nil
 }
 
 func (m *Manager) GetRemoteSourceByProvider(theme, providerName string) (*RemoteSource, error) {
@@ -357,6 +382,12 @@ func (m *Manager) CleanupTemp() {
 	}
 }
 
+func (m *Manager) Prefetch(ctx context.Context, theme, queryOverride string) {
+	for _, s := range m.GetRemoteSources(theme) {
+		s.Prefetch(ctx, queryOverride)
+	}
+}
+
 func (m *Manager) WaitPrefetch() {
 	for _, s := range m.remoteSources {
 		s.WaitPrefetch()
@@ -190,6 +190,39 @@ func TestManager_PickRandomLocal(t *testing.T) {
 	})
 }
 
+func TestManager_PickRandomLocalFunc(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"wide.jpg", "tall.jpg", "square.png"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte("test"), 0644))
+	}
+
+	m := NewManager("/upload", "/temp")
+	m.AddLocalSource(NewLocalSource("test-source", tmpDir, "light", false))
+
+	onlyWide := func(img Image) bool { return filepath.Base(img.Path) == "wide.jpg" }
+
+	t.Run("accept filter is strict", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			img, err := m.PickRandomLocalFunc(context.Background(), "light", nil, onlyWide)
+			require.NoError(t, err)
+			assert.Equal(t, "wide.jpg", filepath.Base(img.Path))
+		}
+	})
+
+	t.Run("history is ignored when it excludes every accepted image", func(t *testing.T) {
+		history := []string{filepath.Join(tmpDir, "wide.jpg")}
+		img, err := m.PickRandomLocalFunc(context.Background(), "light", history, onlyWide)
+		require.NoError(t, err)
+		assert.Equal(t, "wide.jpg", filepath.Base(img.Path))
+	})
+
+	t.Run("nothing accepted", func(t *testing.T) {
+		_, err := m.PickRandomLocalFunc(context.Background(), "light", nil, func(Image) bool { return false })
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no images available")
+	})
+}
+
 func TestManager_PickRandomLocal_MultipleSources(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -0,0 +1,87 @@
+package datasource
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	MinRating     = 1
+	MaxRating     = 5
+	DefaultRating = 3
+)
+
+var remoteProviders = []string{"bing", "generic", "unsplash", "wallhalla", "wallhaven"}
+
+type RatingStore interface {
+	Rating(key string) (int, bool)
+}
+
+func ImageKey(path string, dirs ...string) string {
+	if !inDirs(path, dirs) {
+		return path
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, p := range remoteProviders {
+		if id, ok := strings.CutPrefix(name, p+"_"); ok && id != "" {
+			return p + ":" + id
+		}
+	}
+	return path
+}
+
+func inDirs(path string, dirs []string) bool {
+	parent := filepath.Dir(path)
+	for _, dir := range dirs {
+		if dir != "" && filepath.Clean(dir) == parent {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) ImageKey(path string, dirs ...string) string {
+	dirs = append(dirs, m.tempDir, m.uploadDir)
+	for _, s := range m.remoteSources {
+		dirs = append(dirs, s.tempDir, s.uploadDir)
+	}
+	return ImageKey(path, dirs...)
+}
+
+func ratingWeight(score int) int {
+	if score < MinRating || score > MaxRating {
+		score = DefaultRating
+	}
+	return 1 << (score - 1)
+}
+
+func (m *Manager) SetRatingStore(store RatingStore) {
+	m.ratings = store
+}
+
+func (m *Manager) pickWeighted(images []Image) *Image {
+	if m.ratings == nil {
+		return &images[m.rng.Intn(len(images))]
+	}
+
+	weights := make([]int, len(images))
+	total := 0
+	for i, img := range images {
+		score, ok := m.ratings.Rating(m.ImageKey(img.Path))
+		if !ok {
+			score = DefaultRating
+		}
+		weights[i] = ratingWeight(score)
+		total += weights[i]
+	}
+
+	pick := m.rng.Intn(total)
+	for i, w := range weights {
+		if pick < w {
+			return &images[i]
+		}
+		pick -= w
+	}
+	return &images[len(images)-1]
+}
@@ -0,0 +1,78 @@
+package datasource
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapRatings map[string]int
+
+func (r mapRatings) Rating(key string) (int, bool) {
+	score, ok := r[key]
+	return score, ok
+}
+
+func TestImageKey(t *testing.T) {
+	dirs := []string{"/tmp/wallboy", "/home/user/saved/"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tmp/wallboy/wallhaven_abc123.jpg", "wallhaven:abc123"},
+		{"/home/user/saved/unsplash_Xy-9.png", "unsplash:Xy-9"},
+		{"/home/user/saved/mountains.jpg", "/home/user/saved/mountains.jpg"},
+		{"/home/user/saved/bing_.jpg", "/home/user/saved/bing_.jpg"},
+		{"/home/user/pics/bing_sunset.jpg", "/home/user/pics/bing_sunset.jpg"},
+		{"/home/user/saved/nested/wallhaven_abc.jpg", "/home/user/saved/nested/wallhaven_abc.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, ImageKey(tt.path, dirs...))
+		})
+	}
+
+	assert.Equal(t, "/tmp/wallboy/wallhaven_abc123.jpg", ImageKey("/tmp/wallboy/wallhaven_abc123.jpg"))
+}
+
+func TestManager_ImageKey(t *testing.T) {
+	m := NewManager("/upload", "/temp")
+	m.AddRemoteSource(NewRemoteSource("dark-bing", "bing", "", "dark", "/dark/saved", "/dark/temp", nil, 1, nil))
+
+	assert.Equal(t, "bing:a", m.ImageKey("/temp/bing_a.jpg"))
+	assert.Equal(t, "bing:b", m.ImageKey("/dark/saved/bing_b.jpg"))
+	assert.Equal(t, "bing:c", m.ImageKey("/light/saved/bing_c.jpg", "/light/saved"))
+	assert.Equal(t, "/pictures/bing_d.jpg", m.ImageKey("/pictures/bing_d.jpg"))
+}
+
+func TestManager_PickRandomLocal_Ratings(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"loved.jpg", "plain.jpg", "disliked.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte("test"), 0644))
+	}
+
+	m := NewManager("/upload", "/temp")
+	m.rng = rand.New(rand.NewSource(1))
+	m.AddLocalSource(NewLocalSource("test-source", tmpDir, "light", false))
+	m.SetRatingStore(mapRatings{
+		filepath.Join(tmpDir, "loved.jpg"):    MaxRating,
+		filepath.Join(tmpDir, "disliked.jpg"): MinRating,
+	})
+
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		img, err := m.PickRandomLocal(context.Background(), "light", nil)
+		require.NoError(t, err)
+		counts[filepath.Base(img.Path)]++
+	}
+
+	assert.Greater(t, counts["loved.jpg"], counts["plain.jpg"])
+	assert.Greater(t, counts["plain.jpg"], counts["disliked.jpg"])
+	assert.Positive(t, counts["disliked.jpg"])
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Artawower/wallboy/internal/provider"
@@ -22,13 +23,18 @@ type RemoteSource struct {
 	theme         string
 	weight        int
 	rng           *rand.Rand
+	rngMu         sync.Mutex
 	prefetchStore PrefetchStore
+	bans          BanList
 	prefetchWg    sync.WaitGroup
+	prefetching   atomic.Bool
 }
 
 func NewRemoteSource(id, providerName, auth, theme, uploadDir, tempDir string, queries []string, weight int, prefetchStore PrefetchStore) *RemoteSource {
-	p := provider.NewProvider(providerName, auth, nil)
+	return NewRemoteSourceWithProvider(id, provider.NewProvider(providerName, auth, nil), theme, uploadDir, tempDir, queries, weight, prefetchStore)
+}
 
+func NewRemoteSourceWithProvider(id string, p provider.Provider, theme, uploadDir, tempDir string, queries []string, weight int, prefetchStore PrefetchStore) *RemoteSource {
 	if weight < 1 {
 		weight = 1
 	}
@@ -126,23 +132,23 @@ func (s *RemoteSource) FetchRandom(ctx context.Context, queryOverride string) (*
 	}
 
 	if s.prefetchStore != nil {
-		if prefetchPath, prefetchQuery, ok := s.prefetchStore.GetPrefetch(s.id); ok {
+		if prefetchPath, prefetchQuery, prefetchURL, ok := s.prefetchStore.GetPrefetch(s.id); ok {
 			prefetchValid := false
 			if queryOverride != "" {
 				prefetchValid = (prefetchQuery == queryOverride)
 			} else {
 				prefetchValid = s.queryInList(prefetchQuery)
 			}
+			if s.isBanned(ImageKey(prefetchPath, s.tempDir, s.uploadDir)) {
+				prefetchValid = false
+				os.Remove(prefetchPath)
+			}
 
 			if prefetchValid {
 				s.prefetchStore.ClearPrefetch(s.id)
 				_ = s.prefetchStore.Save()
 
-				s.prefetchWg.Add(1)
-				go func() {
-					defer s.prefetchWg.Done()
-					s.doPrefetch(context.Background(), queryOverride)
-				}()
+				s.startPrefetch(context.Background(), queryOverride)
 
 				return &Image{
 					Path:     prefetchPath,
@@ -150,6 +156,7 @@ func (s *RemoteSource) FetchRandom(ctx context.Context, queryOverride string) (*
 					Theme:    s.theme,
 					IsLocal:  false,
 					Query:    prefetchQuery,
+					URL:      prefetchURL,
 				}, nil
 			}
 			s.prefetchStore.ClearPrefetch(s.id)
@@ -163,11 +170,7 @@ func (s *RemoteSource) FetchRandom(ctx context.Context, queryOverride string) (*
 	}
 
 	if s.prefetchStore != nil {
-		s.prefetchWg.Add(1)
-		go func() {
-			defer s.prefetchWg.Done()
-			s.doPrefetch(context.Background(), queryOverride)
-		}()
+		s.startPrefetch(context.Background(), queryOverride)
 	}
 
 	return img, nil
@@ -182,7 +185,7 @@ func (s *RemoteSource) doFetch(ctx context.Context, queryOverride string) (*Imag
 	if queryOverride != "" {
 		query = queryOverride
 	} else if len(s.queries) > 0 {
-		query = s.queries[s.rng.Intn(len(s.queries))]
+		query = s.queries[s.intn(len(s.queries))]
 	}
 
 	var queries []string
@@ -195,11 +198,21 @@ func (s *RemoteSource) doFetch(ctx context.Context, queryOverride string) (*Imag
 		return nil, fmt.Errorf("failed to search images: %w", err)
 	}
 
+	if s.bans != nil {
+		var allowed []provider.ImageMeta
+		for _, meta := range metas {
+			if !s.isBanned(s.provider.Name() + ":" + meta.ID) {
+				allowed = append(allowed, meta)
+			}
+		}
+		metas = allowed
+	}
+
 	if len(metas) == 0 {
 		return nil, fmt.Errorf("no images found for query: %q", query)
 	}
 
-	idx := s.rng.Intn(len(metas))
+	idx := s.intn(len(metas))
 	meta := metas[idx]
 
 	tempPath := s.getTempPath(meta)
@@ -224,6 +237,37 @@ func (s *RemoteSource) WaitPrefetch() {
 	s.prefetchWg.Wait()
 }
 
+func (s *RemoteSource) Prefetch(ctx context.Context, queryOverride string) {
+	if s.prefetchStore == nil {
+		return
+	}
+	if path, _, _, ok := s.prefetchStore.GetPrefetch(s.id); ok {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		s.prefetchStore.ClearPrefetch(s.id)
+	}
+	s.startPrefetch(ctx, queryOverride)
+}
+
+func (s *RemoteSource) startPrefetch(ctx context.Context, queryOverride string) {
+	if !s.prefetching.CompareAndSwap(false, true) {
+		return
+	}
+	s.prefetchWg.Add(1)
+	go func() {
+		defer s.prefetchWg.Done()
+		defer s.prefetching.Store(false)
+		s.doPrefetch(ctx, queryOverride)
+	}()
+}
+
+func (s *RemoteSource) intn(n int) int {
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	return s.rng.Intn(n)
+}
+
 func (s *RemoteSource) doPrefetch(ctx context.Context, queryOverride string) {
 	if s.prefetchStore == nil {
 		return
@@ -234,17 +278,21 @@ func (s *RemoteSource) doPrefetch(ctx context.Context, queryOverride string) {
 		return
 	}
 
-	s.prefetchStore.SetPrefetch(s.id, img.Path, img.Query)
+	s.prefetchStore.SetPrefetch(s.id, img.Path, img.Query, img.URL)
 	_ = s.prefetchStore.Save()
 }
 
 func (s *RemoteSource) Save(tempPath string) (string, error) {
-	if err := os.MkdirAll(s.uploadDir, 0755); err != nil {
+	return SaveTo(tempPath, s.uploadDir)
+}
+
+func SaveTo(tempPath, uploadDir string) (string, error) {
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create upload directory: %w", err)
 	}
 
 	filename := filepath.Base(tempPath)
-	destPath := filepath.Join(s.uploadDir, filename)
+	destPath := filepath.Join(uploadDir, filename)
 
 	if err := os.Rename(tempPath, destPath); err != nil {
 		if err := copyFile(tempPath, destPath); err != nil {
@@ -422,6 +422,7 @@ type mockPrefetchStore struct {
 	prefetches map[string]struct {
 		path  string
 		query string
+		url   string
 	}
 	saveCount int
 }
@@ -431,22 +432,24 @@ func newMockPrefetchStore() *mockPrefetchStore {
 		prefetches: make(map[string]struct {
 			path  string
 			query string
+			url   string
 		}),
 	}
 }
 
-func (m *mockPrefetchStore) GetPrefetch(sourceID string) (path, query string, ok bool) {
+func (m *mockPrefetchStore) GetPrefetch(sourceID string) (path, query, url string, ok bool) {
 	if entry, exists := m.prefetches[sourceID]; exists {
-		return entry.path, entry.query, true
+		return entry.path, entry.query, entry.url, true
 	}
-	return "", "", false
+	return "", "", "", false
 }
 
-func (m *mockPrefetchStore) SetPrefetch(sourceID, path, query string) {
+func (m *mockPrefetchStore) SetPrefetch(sourceID, path, query, url string) {
 	m.prefetches[sourceID] = struct {
 		path  string
 		query string
-	}{path: path, query: query}
+		url   string
+	}{path: path, query: query, url: url}
 }
 
 func (m *mockPrefetchStore) ClearPrefetch(sourceID string) {
@@ -479,7 +482,7 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 
 		prefetchStore := newMockPrefetchStore()
 		// Prefetch was done with "landscape" (one of the configured queries)
-		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "landscape")
+		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "landscape", "http://example.com/prefetched.jpg")
 
 		source := &RemoteSource{
 			id:            "test-remote",
@@ -499,9 +502,10 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 		// Should use prefetched image (query is in configured list)
 		assert.Equal(t, prefetchedFile, img.Path)
 		assert.Equal(t, "landscape", img.Query)
+		assert.Equal(t, "http://example.com/prefetched.jpg", img.URL)
 
 		// Prefetch should be cleared
-		_, _, ok := prefetchStore.GetPrefetch("test-remote")
+		_, _, _, ok := prefetchStore.GetPrefetch("test-remote")
 		assert.False(t, ok)
 
 		// Provider should NOT have been called (used prefetch)
@@ -524,7 +528,7 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 
 		prefetchStore := newMockPrefetchStore()
 		// Prefetch was done with "nature"
-		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "nature")
+		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "nature", "")
 
 		source := &RemoteSource{
 			id:            "test-remote",
@@ -547,7 +551,7 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 		assert.Equal(t, "mountains", img.Query)
 
 		// Stale prefetch should be cleared
-		_, _, ok := prefetchStore.GetPrefetch("test-remote")
+		_, _, _, ok := prefetchStore.GetPrefetch("test-remote")
 		assert.False(t, ok)
 
 		// Provider SHOULD have been called (new query)
@@ -571,7 +575,7 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 
 		prefetchStore := newMockPrefetchStore()
 		// Prefetch was done with "old query" which is NOT in the new list
-		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "old query")
+		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "old query", "")
 
 		source := &RemoteSource{
 			id:            "test-remote",
@@ -594,7 +598,7 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 		assert.True(t, img.Query == "new" || img.Query == "queries")
 
 		// Stale prefetch should be cleared
-		_, _, ok := prefetchStore.GetPrefetch("test-remote")
+		_, _, _, ok := prefetchStore.GetPrefetch("test-remote")
 		assert.False(t, ok)
 
 		// Provider SHOULD have been called with single query
@@ -618,7 +622,7 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 
 		prefetchStore := newMockPrefetchStore()
 		// Prefetch was done with override query "mountains"
-		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "mountains")
+		prefetchStore.SetPrefetch("test-remote", prefetchedFile, "mountains", "")
 
 		source := &RemoteSource{
 			id:            "test-remote",
@@ -641,7 +645,7 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 		assert.Equal(t, "mountains", img.Query)
 
 		// Prefetch should be cleared
-		_, _, ok := prefetchStore.GetPrefetch("test-remote")
+		_, _, _, ok := prefetchStore.GetPrefetch("test-remote")
 		assert.False(t, ok)
 
 		// Provider should NOT have been called
@@ -651,3 +655,45 @@ func TestRemoteSource_FetchRandom_PrefetchQueryMatching(t *testing.T) {
 		source.WaitPrefetch()
 	})
 }
+
+func TestRemoteSource_Prefetch(t *testing.T) {
+	tmpDir := t.TempDir()
+	mock := &mockProvider{
+		name: "mock",
+		searchResults: []provider.ImageMeta{
+			{ID: "img1", DownloadURL: "http://example.com/img1.jpg"},
+		},
+	}
+	prefetchStore := newMockPrefetchStore()
+
+	source := &RemoteSource{
+		id:            "test-remote",
+		provider:      mock,
+		queries:       []string{"nature"},
+		uploadDir:     filepath.Join(tmpDir, "upload"),
+		tempDir:       filepath.Join(tmpDir, "temp"),
+		theme:         "dark",
+		rng:           rand.New(rand.NewSource(42)),
+		prefetchStore: prefetchStore,
+	}
+
+	source.Prefetch(context.Background(), "")
+	source.WaitPrefetch()
+	path, query, url, ok := prefetchStore.GetPrefetch("test-remote")
+	require.True(t, ok)
+	assert.FileExists(t, path)
+	assert.Equal(t, "nature", query)
+	assert.Equal(t, "http://example.com/img1.jpg", url)
+	assert.Len(t, mock.searchQueries, 1)
+
+	source.Prefetch(context.Background(), "")
+	source.WaitPrefetch()
+	assert.Len(t, mock.searchQueries, 1)
+
+	require.NoError(t, os.Remove(path))
+	source.Prefetch(context.Background(), "")
+	source.WaitPrefetch()
+	assert.Len(t, mock.searchQueries, 2)
+	_, _, _, ok = prefetchStore.GetPrefetch("test-remote")
+	assert.True(t, ok)
+}
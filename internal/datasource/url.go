@@ -0,0 +1,77 @@
+package datasource
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/Artawower/wallboy/internal/provider"
+)
+
+var imageContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+func IsURL(ref string) bool {
+	u, err := url.Parse(ref)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func DownloadURL(ctx context.Context, rawURL, dir string) (string, error) {
+	if !IsURL(rawURL) {
+		return "", fmt.Errorf("invalid URL: %s", rawURL)
+	}
+
+	return DownloadURLAs(ctx, rawURL, dir, fmt.Sprintf("url_%x", sha1.Sum([]byte(rawURL)))[:16])
+}
+
+func DownloadURLAs(ctx context.Context, rawURL, dir, name string) (string, error) {
+	if !IsURL(rawURL) {
+		return "", fmt.Errorf("invalid URL: %s", rawURL)
+	}
+
+	tempPath := filepath.Join(dir, name+".download")
+
+	p := provider.NewGenericProvider("", []string{rawURL})
+	if _, err := p.Download(ctx, provider.ImageMeta{ID: name, DownloadURL: rawURL}, tempPath); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+
+	ext, err := sniffImageExtension(tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+
+	dest := filepath.Join(dir, name+ext)
+	if err := os.Rename(tempPath, dest); err != nil {
+		os.Remove(tempPath)
+		return "", fmt.Errorf("failed to store download: %w", err)
+	}
+	return dest, nil
+}
+
+func sniffImageExtension(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := f.Read(head)
+
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := imageContentTypes[contentType]
+	if !ok {
+		return "", fmt.Errorf("URL did not return a supported image (got %s)", contentType)
+	}
+	return ext, nil
+}
@@ -0,0 +1,64 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsURL(t *testing.T) {
+	assert.True(t, IsURL("https://example.com/a.jpg"))
+	assert.True(t, IsURL("http://example.com/img?id=1"))
+	assert.False(t, IsURL("/home/user/a.jpg"))
+	assert.False(t, IsURL("file:///home/user/a.jpg"))
+	assert.False(t, IsURL("https://"))
+}
+
+func TestDownloadURL(t *testing.T) {
+	var pngData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wall":
+			w.Write(pngData.Bytes())
+		case "/page.jpg":
+			w.Write([]byte("<html><body>not an image</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	path, err := DownloadURL(context.Background(), server.URL+"/wall", dir)
+	require.NoError(t, err)
+	assert.Equal(t, ".png", filepath.Ext(path))
+	assert.FileExists(t, path)
+
+	again, err := DownloadURL(context.Background(), server.URL+"/wall", dir)
+	require.NoError(t, err)
+	assert.Equal(t, path, again)
+
+	_, err = DownloadURL(context.Background(), server.URL+"/page.jpg", dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not return a supported image")
+
+	_, err = DownloadURL(context.Background(), server.URL+"/missing.jpg", dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,250 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	EventShown   = "shown"
+	EventSaved   = "saved"
+	EventDeleted = "deleted"
+	EventBanned  = "banned"
+)
+
+const (
+	OutcomeCurrent = "current"
+	OutcomeSkipped = "skipped"
+	OutcomeSaved   = "saved"
+	OutcomeDeleted = "deleted"
+	OutcomeBanned  = "banned"
+)
+
+type Record struct {
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	Path     string    `json:"path"`
+	Theme    string    `json:"theme,omitempty"`
+	SourceID string    `json:"source_id,omitempty"`
+	Query    string    `json:"query,omitempty"`
+	ImageID  string    `json:"image_id,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Display  string    `json:"display,omitempty"`
+	IsTemp   bool      `json:"is_temp,omitempty"`
+	SavedAs  string    `json:"saved_as,omitempty"`
+}
+
+type Entry struct {
+	Index     int
+	ShownAt   time.Time
+	Path      string
+	Theme     string
+	SourceID  string
+	Query     string
+	ImageID   string
+	URL       string
+	Display   string
+	IsTemp    bool
+	Duration  time.Duration
+	Outcome   string
+	SavedPath string
+}
+
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Index           int       `json:"index"`
+		ShownAt         time.Time `json:"shown_at"`
+		Path            string    `json:"path"`
+		Theme           string    `json:"theme,omitempty"`
+		SourceID        string    `json:"source_id,omitempty"`
+		Query           string    `json:"query,omitempty"`
+		ImageID         string    `json:"image_id,omitempty"`
+		URL             string    `json:"url,omitempty"`
+		Display         string    `json:"display,omitempty"`
+		IsTemp          bool      `json:"is_temp"`
+		DurationSeconds int64     `json:"duration_seconds"`
+		Outcome         string    `json:"outcome"`
+		SavedPath       string    `json:"saved_path,omitempty"`
+	}{
+		Index:           e.Index,
+		ShownAt:         e.ShownAt,
+		Path:            e.Path,
+		Theme:           e.Theme,
+		SourceID:        e.SourceID,
+		Query:           e.Query,
+		ImageID:         e.ImageID,
+		URL:             e.URL,
+		Display:         e.Display,
+		IsTemp:          e.IsTemp,
+		DurationSeconds: int64(e.Duration / time.Second),
+		Outcome:         e.Outcome,
+		SavedPath:       e.SavedPath,
+	})
+}
+
+type Filter struct {
+	Since  time.Time
+	Source string
+	Saved  bool
+}
+
+func (f Filter) Match(e Entry) bool {
+	if !f.Since.IsZero() && e.ShownAt.Before(f.Since) {
+		return false
+	}
+	if f.Source != "" && e.SourceID != f.Source {
+		return false
+	}
+	if f.Saved && e.Outcome != OutcomeSaved {
+		return false
+	}
+	return true
+}
+
+type Log struct {
+	store Store
+}
+
+func New(path string) *Log {
+	return NewFrom(NewFileStore(path))
+}
+
+func NewFrom(store Store) *Log {
+	return &Log{store: store}
+}
+
+func (l *Log) Path() string {
+	if fs, ok := l.store.(*FileStore); ok {
+		return fs.Path()
+	}
+	return ""
+}
+
+func (l *Log) Append(r Record) error {
+	if l == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+	return l.store.Append(append(data, '\n'))
+}
+
+func (l *Log) Records() ([]Record, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	data, err := l.store.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil || r.Event == "" {
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history log: %w", err)
+	}
+	return records, nil
+}
+
+func (l *Log) Entries(now time.Time) ([]Entry, error) {
+	records, err := l.Records()
+	if err != nil {
+		return nil, err
+	}
+	return Fold(records, now), nil
+}
+
+func Fold(records []Record, now time.Time) []Entry {
+	var entries []Entry
+	open := make(map[string]int)
+
+	closeEntry := func(i int, at time.Time, outcome string) {
+		entries[i].Duration = at.Sub(entries[i].ShownAt)
+		if entries[i].Outcome == "" {
+			entries[i].Outcome = outcome
+		}
+	}
+
+	find := func(path string) int {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Path == path || entries[i].SavedPath == path {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, r := range records {
+		switch r.Event {
+		case EventShown:
+			for display, i := range open {
+				if r.Display == "" || display == r.Display || display == "" {
+					closeEntry(i, r.Time, OutcomeSkipped)
+					delete(open, display)
+				}
+			}
+			entries = append(entries, Entry{
+				Index:    len(entries) + 1,
+				ShownAt:  r.Time,
+				Path:     r.Path,
+				Theme:    r.Theme,
+				SourceID: r.SourceID,
+				Query:    r.Query,
+				ImageID:  r.ImageID,
+				URL:      r.URL,
+				Display:  r.Display,
+				IsTemp:   r.IsTemp,
+			})
+			open[r.Display] = len(entries) - 1
+		case EventSaved:
+			if i := find(r.Path); i >= 0 {
+				entries[i].Outcome = OutcomeSaved
+				entries[i].SavedPath = r.SavedAs
+			}
+		case EventDeleted, EventBanned:
+			i := find(r.Path)
+			if i < 0 {
+				continue
+			}
+			for display, j := range open {
+				if j == i {
+					closeEntry(i, r.Time, "")
+					delete(open, display)
+				}
+			}
+			entries[i].Outcome = r.Event
+		}
+	}
+
+	for _, i := range open {
+		entries[i].Duration = now.Sub(entries[i].ShownAt)
+		if entries[i].Outcome == "" {
+			entries[i].Outcome = OutcomeCurrent
+		}
+	}
+
+	urls := make(map[string]string)
+	for i := range entries {
+		if entries[i].URL != "" {
+			urls[entries[i].Path] = entries[i].URL
+		} else if url, ok := urls[entries[i].Path]; ok {
+			entries[i].URL = url
+		}
+	}
+	return entries
+}
@@ -0,0 +1,155 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+func at(minutes int) time.Time {
+	return base.Add(time.Duration(minutes) * time.Minute)
+}
+
+func TestLog_AppendAndRecords(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "nested", "history.jsonl"))
+
+	records, err := log.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	require.NoError(t, log.Append(Record{Event: EventShown, Time: at(0), Path: "/tmp/a.jpg", SourceID: "dark-local"}))
+	require.NoError(t, log.Append(Record{Event: EventSaved, Time: at(1), Path: "/tmp/a.jpg", SavedAs: "/saved/a.jpg"}))
+
+	f, err := os.OpenFile(log.Path(), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("{\"event\":\"shown\",\"pa\n\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	records, err = log.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, EventShown, records[0].Event)
+	assert.Equal(t, "/saved/a.jpg", records[1].SavedAs)
+	assert.True(t, at(0).Equal(records[0].Time))
+}
+
+type memoryStore struct {
+	data []byte
+}
+
+func (m *memoryStore) Read() ([]byte, error) { return m.data, nil }
+
+func (m *memoryStore) Append(data []byte) error {
+	m.data = append(m.data, data...)
+	return nil
+}
+
+func TestLog_Store(t *testing.T) {
+	store := &memoryStore{}
+	log := NewFrom(store)
+	assert.Empty(t, log.Path())
+
+	require.NoError(t, log.Append(Record{Event: EventShown, Time: at(0), Path: "/tmp/a.jpg"}))
+	require.NoError(t, log.Append(Record{Event: EventBanned, Time: at(2), Path: "/tmp/a.jpg"}))
+	assert.Equal(t, 2, strings.Count(string(store.data), "\n"))
+
+	entries, err := log.Entries(at(5))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, OutcomeBanned, entries[0].Outcome)
+	assert.Equal(t, 2*time.Minute, entries[0].Duration)
+}
+
+func TestFold(t *testing.T) {
+	records := []Record{
+		{Event: EventShown, Time: at(0), Path: "/tmp/wallhaven_a.jpg", SourceID: "dark-wallhaven", ImageID: "wallhaven:a", URL: "https://w/a.jpg", IsTemp: true},
+		{Event: EventSaved, Time: at(2), Path: "/tmp/wallhaven_a.jpg", SavedAs: "/saved/wallhaven_a.jpg"},
+		{Event: EventShown, Time: at(10), Path: "/pics/b.jpg", SourceID: "dark-local"},
+		{Event: EventShown, Time: at(15), Path: "/pics/c.jpg", SourceID: "dark-local"},
+		{Event: EventShown, Time: at(16), Path: "/pics/d.jpg", SourceID: "dark-local"},
+		{Event: EventDeleted, Time: at(16), Path: "/pics/c.jpg"},
+		{Event: EventBanned, Time: at(20), Path: "/pics/d.jpg"},
+		{Event: EventShown, Time: at(20), Path: "/tmp/wallhaven_a.jpg", SourceID: "dark-wallhaven"},
+	}
+
+	entries := Fold(records, at(30))
+	require.Len(t, entries, 5)
+
+	assert.Equal(t, 1, entries[0].Index)
+	assert.Equal(t, OutcomeSaved, entries[0].Outcome)
+	assert.Equal(t, "/saved/wallhaven_a.jpg", entries[0].SavedPath)
+	assert.Equal(t, 10*time.Minute, entries[0].Duration)
+	assert.Equal(t, "wallhaven:a", entries[0].ImageID)
+
+	assert.Equal(t, OutcomeSkipped, entries[1].Outcome)
+	assert.Equal(t, 5*time.Minute, entries[1].Duration)
+
+	assert.Equal(t, OutcomeDeleted, entries[2].Outcome)
+	assert.Equal(t, time.Minute, entries[2].Duration)
+
+	assert.Equal(t, OutcomeBanned, entries[3].Outcome)
+	assert.Equal(t, 4*time.Minute, entries[3].Duration)
+
+	assert.Equal(t, 5, entries[4].Index)
+	assert.Equal(t, OutcomeCurrent, entries[4].Outcome)
+	assert.Equal(t, 10*time.Minute, entries[4].Duration)
+	assert.Equal(t, "https://w/a.jpg", entries[4].URL)
+}
+
+func TestFold_Displays(t *testing.T) {
+	records := []Record{
+		{Event: EventShown, Time: at(0), Path: "/pics/a.jpg", Display: "DP-1"},
+		{Event: EventShown, Time: at(0), Path: "/pics/b.jpg", Display: "DP-2"},
+		{Event: EventShown, Time: at(5), Path: "/pics/c.jpg", Display: "DP-1"},
+		{Event: EventShown, Time: at(8), Path: "/pics/d.jpg"},
+	}
+
+	entries := Fold(records, at(10))
+	require.Len(t, entries, 4)
+	assert.Equal(t, 5*time.Minute, entries[0].Duration)
+	assert.Equal(t, 8*time.Minute, entries[1].Duration)
+	assert.Equal(t, 3*time.Minute, entries[2].Duration)
+	assert.Equal(t, OutcomeCurrent, entries[3].Outcome)
+	assert.Equal(t, 2*time.Minute, entries[3].Duration)
+}
+
+func TestFilter(t *testing.T) {
+	entry := Entry{ShownAt: at(10), SourceID: "dark-local", Outcome: OutcomeSaved}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty", filter: Filter{}, want: true},
+		{name: "since before", filter: Filter{Since: at(5)}, want: true},
+		{name: "since after", filter: Filter{Since: at(11)}, want: false},
+		{name: "source match", filter: Filter{Source: "dark-local"}, want: true},
+		{name: "source mismatch", filter: Filter{Source: "dark-bing"}, want: false},
+		{name: "saved", filter: Filter{Saved: true}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(entry))
+		})
+	}
+
+	entry.Outcome = OutcomeSkipped
+	assert.False(t, Filter{Saved: true}.Match(entry))
+}
+
+func TestEntry_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Entry{Index: 3, ShownAt: at(0), Path: "/pics/a.jpg", Duration: 90 * time.Second, Outcome: OutcomeSkipped})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"index":3,"shown_at":"2024-05-06T12:00:00Z","path":"/pics/a.jpg","is_temp":false,"duration_seconds":90,"outcome":"skipped"}`, string(data))
+}
@@ -0,0 +1,52 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type Store interface {
+	Read() ([]byte, error)
+	Append(data []byte) error
+}
+
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (f *FileStore) Path() string {
+	return f.path
+}
+
+func (f *FileStore) Read() ([]byte, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history log: %w", err)
+	}
+	return data, nil
+}
+
+func (f *FileStore) Append(data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write history log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+type Hook struct {
+	On      string
+	Command []string
+	Timeout time.Duration
+}
+
+type Event struct {
+	Name    string
+	Path    string
+	Theme   string
+	Source  string
+	Query   string
+	Display string
+	Colors  []string
+}
+
+type Runner struct {
+	hooks  []Hook
+	logger *log.Logger
+}
+
+func NewRunner(hooks []Hook, logger *log.Logger) *Runner {
+	return &Runner{
+		hooks:  hooks,
+		logger: logger,
+	}
+}
+
+func (r *Runner) Has(event string) bool {
+	if r == nil {
+		return false
+	}
+	for _, h := range r.hooks {
+		if h.On == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) Run(ctx context.Context, event Event) {
+	if !r.Has(event.Name) {
+		return
+	}
+
+	env := append(os.Environ(), Env(event)...)
+
+	for _, h := range r.hooks {
+		if h.On != event.Name {
+			continue
+		}
+		if err := run(ctx, h, env); err != nil {
+			r.logf("hook %s (%s) failed: %v", h.On, h.Command[0], err)
+		}
+	}
+}
+
+func (r *Runner) logf(format string, args ...interface{}) {
+	if r.logger != nil {
+		r.logger.Printf(format, args...)
+	}
+}
+
+func Env(event Event) []string {
+	env := []string{
+		"WALLBOY_EVENT=" + event.Name,
+		"WALLBOY_PATH=" + event.Path,
+		"WALLBOY_THEME=" + event.Theme,
+		"WALLBOY_SOURCE=" + event.Source,
+		"WALLBOY_QUERY=" + event.Query,
+		"WALLBOY_DISPLAY=" + event.Display,
+	}
+	if len(event.Colors) > 0 {
+		env = append(env, "WALLBOY_COLORS="+strings.Join(event.Colors, " "))
+		for i, c := range event.Colors {
+			env = append(env, fmt.Sprintf("WALLBOY_COLOR%d=%s", i, c))
+		}
+	}
+	return env
+}
+
+func run(ctx context.Context, h Hook, env []string) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Env = env
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv(t *testing.T) {
+	env := Env(Event{
+		Name:   "set",
+		Path:   "/tmp/a.jpg",
+		Theme:  "dark",
+		Source: "dark-local-1",
+		Colors: []string{"#112233", "#aabbcc"},
+	})
+
+	assert.Contains(t, env, "WALLBOY_EVENT=set")
+	assert.Contains(t, env, "WALLBOY_PATH=/tmp/a.jpg")
+	assert.Contains(t, env, "WALLBOY_THEME=dark")
+	assert.Contains(t, env, "WALLBOY_SOURCE=dark-local-1")
+	assert.Contains(t, env, "WALLBOY_QUERY=")
+	assert.Contains(t, env, "WALLBOY_COLORS=#112233 #aabbcc")
+	assert.Contains(t, env, "WALLBOY_COLOR0=#112233")
+	assert.Contains(t, env, "WALLBOY_COLOR1=#aabbcc")
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "wall.jpg")
+	require.NoError(t, os.WriteFile(image, []byte("test"), 0644))
+	out := filepath.Join(dir, "out")
+
+	var logs bytes.Buffer
+	r := NewRunner([]Hook{
+		{On: "set", Command: []string{"sh", "-c", `echo "$WALLBOY_PATH $WALLBOY_COLOR0" > "$0"`, out}},
+		{On: "set", Command: []string{"sh", "-c", "echo broken >&2; exit 3"}},
+		{On: "save", Command: []string{"sh", "-c", "touch \"$0\"", filepath.Join(dir, "saved")}},
+	}, log.New(&logs, "", 0))
+
+	r.Run(context.Background(), Event{Name: "set", Path: image, Colors: []string{"#000000"}})
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, image+" #000000\n", string(data))
+	assert.Contains(t, logs.String(), "hook set (sh) failed")
+	assert.Contains(t, logs.String(), "broken")
+	assert.NoFileExists(t, filepath.Join(dir, "saved"))
+
+	assert.True(t, r.Has("save"))
+	assert.False(t, r.Has("delete"))
+
+	t.Run("timeout", func(t *testing.T) {
+		logs.Reset()
+		r := NewRunner([]Hook{
+			{On: "set", Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond},
+		}, log.New(&logs, "", 0))
+
+		start := time.Now()
+		r.Run(context.Background(), Event{Name: "set", Path: image})
+		assert.Less(t, time.Since(start), 3*time.Second)
+		assert.True(t, strings.Contains(logs.String(), "timed out"), logs.String())
+	})
+}
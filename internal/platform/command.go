@@ -0,0 +1,271 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type CommandSettings struct {
+	SetCommand   string
+	GetCommand   string
+	ThemeCommand string
+	Theme        func() Theme
+}
+
+type commandPlatform struct {
+	Platform
+	settings  CommandSettings
+	wallpaper WallpaperService
+	theme     ThemeService
+}
+
+func NewCommandPlatform(base Platform, settings CommandSettings) (Platform, error) {
+	for _, template := range []string{settings.SetCommand, settings.GetCommand, settings.ThemeCommand} {
+		if template == "" {
+			continue
+		}
+		if _, err := SplitCommand(template); err != nil {
+			return nil, fmt.Errorf("invalid command %q: %w", template, err)
+		}
+	}
+
+	p := &commandPlatform{
+		Platform:  base,
+		settings:  settings,
+		wallpaper: base.Wallpaper(),
+		theme:     base.Theme(),
+	}
+
+	if settings.ThemeCommand != "" {
+		p.theme = &commandTheme{command: settings.ThemeCommand, fallback: base.Theme()}
+	}
+
+	if settings.SetCommand != "" || settings.GetCommand != "" {
+		themeFn := settings.Theme
+		if themeFn == nil {
+			themeFn = p.theme.Detect
+		}
+		p.wallpaper = &commandWallpaper{
+			setCommand: settings.SetCommand,
+			getCommand: settings.GetCommand,
+			fallback:   base.Wallpaper(),
+			theme:      themeFn,
+		}
+	}
+
+	return p, nil
+}
+
+func (p *commandPlatform) IsSupported() bool {
+	return p.settings.SetCommand != "" || p.Platform.IsSupported()
+}
+
+func (p *commandPlatform) Wallpaper() WallpaperService { return p.wallpaper }
+func (p *commandPlatform) Theme() ThemeService         { return p.theme }
+
+func (p *commandPlatform) BackendInfo() BackendInfo {
+	if p.settings.SetCommand != "" {
+		args, _ := SplitCommand(p.settings.SetCommand)
+		return BackendInfo{Backend: "command", Tool: args[0]}
+	}
+	if reporter, ok := p.Platform.(BackendReporter); ok {
+		return reporter.BackendInfo()
+	}
+	return BackendInfo{}
+}
+
+type commandWallpaper struct {
+	setCommand string
+	getCommand string
+	fallback   WallpaperService
+	theme      func() Theme
+}
+
+func (s *commandWallpaper) Set(path string) error {
+	if s.setCommand == "" {
+		return s.fallback.Set(path)
+	}
+	return s.set("", path)
+}
+
+func (s *commandWallpaper) Get() (string, error) {
+	if s.getCommand == "" {
+		return s.fallback.Get()
+	}
+	return s.get("")
+}
+
+func (s *commandWallpaper) Displays() ([]Display, error) {
+	displays, ok := s.fallback.(DisplayWallpaperService)
+	if !ok {
+		return nil, fmt.Errorf("failed to list displays: %w", ErrUnsupported)
+	}
+	return displays.Displays()
+}
+
+func (s *commandWallpaper) SetDisplay(display, path string) error {
+	if s.setCommand != "" {
+		return s.set(display, path)
+	}
+	displays, ok := s.fallback.(DisplayWallpaperService)
+	if !ok {
+		return fmt.Errorf("failed to set wallpaper: %w", ErrUnsupported)
+	}
+	return displays.SetDisplay(display, path)
+}
+
+func (s *commandWallpaper) GetDisplay(display string) (string, error) {
+	if s.getCommand != "" {
+		return s.get(display)
+	}
+	displays, ok := s.fallback.(DisplayWallpaperService)
+	if !ok {
+		return "", fmt.Errorf("failed to get wallpaper: %w", ErrUnsupported)
+	}
+	return displays.GetDisplay(display)
+}
+
+func (s *commandWallpaper) set(display, path string) error {
+	args, err := ExpandCommand(s.setCommand, map[string]string{
+		"path":    path,
+		"theme":   string(s.theme()),
+		"display": display,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	if _, err := runCommand(args); err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+	return nil
+}
+
+func (s *commandWallpaper) get(display string) (string, error) {
+	args, err := ExpandCommand(s.getCommand, map[string]string{
+		"theme":   string(s.theme()),
+		"display": display,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+
+	output, err := runCommand(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+type commandTheme struct {
+	command  string
+	fallback ThemeService
+}
+
+func (s *commandTheme) Detect() Theme {
+	args, err := ExpandCommand(s.command, nil)
+	if err != nil {
+		return s.fallback.Detect()
+	}
+
+	output, err := runCommand(args)
+	if err != nil {
+		return s.fallback.Detect()
+	}
+
+	if strings.Contains(strings.ToLower(string(output)), "dark") {
+		return ThemeDark
+	}
+	return ThemeLight
+}
+
+var runCommand = func(args []string) ([]byte, error) {
+	output, err := exec.Command(args[0], args[1:]...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return output, fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return output, err
+}
+
+func ExpandCommand(template string, vars map[string]string) ([]string, error) {
+	args, err := SplitCommand(template)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	for i, arg := range args {
+		args[i] = replacer.Replace(expandHome(arg))
+	}
+	return args, nil
+}
+
+func expandHome(arg string) string {
+	if arg != "~" && !strings.HasPrefix(arg, "~/") {
+		return arg
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return arg
+	}
+	return filepath.Join(home, arg[1:])
+}
+
+func SplitCommand(template string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range template {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return args, nil
+}
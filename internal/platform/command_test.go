@@ -0,0 +1,196 @@
+package platform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubRunCommand(t *testing.T, output string, err error) *[][]string {
+	var calls [][]string
+	original := runCommand
+	runCommand = func(args []string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte(output), err
+	}
+	t.Cleanup(func() { runCommand = original })
+	return &calls
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{"simple", "hyprctl hyprpaper wallpaper ,{path}", []string{"hyprctl", "hyprpaper", "wallpaper", ",{path}"}, false},
+		{"double quotes", `swaymsg "output * bg {path} fill"`, []string{"swaymsg", "output * bg {path} fill"}, false},
+		{"single quotes keep backslash", `sh -c 'echo \n'`, []string{"sh", "-c", `echo \n`}, false},
+		{"escaped space", `my\ tool {path}`, []string{"my tool", "{path}"}, false},
+		{"empty quoted arg", `tool "" x`, []string{"tool", "", "x"}, false},
+		{"extra whitespace", "  a   b\t c ", []string{"a", "b", "c"}, false},
+		{"unterminated quote", `tool "oops`, nil, true},
+		{"trailing backslash", `tool \`, nil, true},
+		{"empty", "   ", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := SplitCommand(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
+
+func TestExpandCommand(t *testing.T) {
+	args, err := ExpandCommand("hyprctl hyprpaper wallpaper {display},{path}", map[string]string{
+		"path":    "/home/user/My Pictures/it's.jpg",
+		"display": "",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hyprctl", "hyprpaper", "wallpaper", ",/home/user/My Pictures/it's.jpg"}, args)
+
+	args, err = ExpandCommand("set-bg --theme={theme} {unknown}", map[string]string{"theme": "dark"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"set-bg", "--theme=dark", "{unknown}"}, args)
+
+	t.Setenv("HOME", "/home/user")
+	args, err = ExpandCommand("cat ~/.cache/current-wallpaper ~ a~/b {path}", map[string]string{"path": "~/x.jpg"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cat", "/home/user/.cache/current-wallpaper", "/home/user", "a~/b", "~/x.jpg"}, args)
+}
+
+func TestNewCommandPlatform(t *testing.T) {
+	base := &unsupportedPlatform{name: "plan9"}
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := NewCommandPlatform(base, CommandSettings{SetCommand: `tool "oops`})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unterminated")
+	})
+
+	t.Run("set command makes platform supported", func(t *testing.T) {
+		p, err := NewCommandPlatform(base, CommandSettings{SetCommand: "feh --bg-fill {path}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "plan9", p.Name())
+		assert.True(t, p.IsSupported())
+		assert.Equal(t, BackendInfo{Backend: "command", Tool: "feh"}, p.(BackendReporter).BackendInfo())
+	})
+
+	t.Run("theme only keeps base wallpaper service", func(t *testing.T) {
+		p, err := NewCommandPlatform(base, CommandSettings{ThemeCommand: "darkman get"})
+		require.NoError(t, err)
+
+		assert.False(t, p.IsSupported())
+		assert.ErrorIs(t, p.Wallpaper().Set("/tmp/a.jpg"), ErrUnsupported)
+	})
+}
+
+func TestCommandWallpaper(t *testing.T) {
+	base := &unsupportedPlatform{name: "plan9"}
+
+	t.Run("set expands placeholders", func(t *testing.T) {
+		calls := stubRunCommand(t, "", nil)
+		p, err := NewCommandPlatform(base, CommandSettings{
+			SetCommand: "hyprctl hyprpaper wallpaper {display},{path} --{theme}",
+			Theme:      func() Theme { return ThemeDark },
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.Wallpaper().Set("/tmp/my wall.jpg"))
+		assert.Equal(t, [][]string{{"hyprctl", "hyprpaper", "wallpaper", ",/tmp/my wall.jpg", "--dark"}}, *calls)
+	})
+
+	t.Run("set failure", func(t *testing.T) {
+		stubRunCommand(t, "", errors.New("exit status 1"))
+		p, err := NewCommandPlatform(base, CommandSettings{SetCommand: "false {path}"})
+		require.NoError(t, err)
+
+		err = p.Wallpaper().Set("/tmp/a.jpg")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to set wallpaper")
+	})
+
+	t.Run("get trims output", func(t *testing.T) {
+		stubRunCommand(t, "/tmp/current.jpg\n", nil)
+		p, err := NewCommandPlatform(base, CommandSettings{GetCommand: "cat ~/.current"})
+		require.NoError(t, err)
+
+		path, err := p.Wallpaper().Get()
+		require.NoError(t, err)
+		assert.Equal(t, "/tmp/current.jpg", path)
+	})
+
+	t.Run("get falls back to base", func(t *testing.T) {
+		p, err := NewCommandPlatform(base, CommandSettings{SetCommand: "tool {path}"})
+		require.NoError(t, err)
+
+		_, err = p.Wallpaper().Get()
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("set display fills placeholder", func(t *testing.T) {
+		calls := stubRunCommand(t, "", nil)
+		p, err := NewCommandPlatform(base, CommandSettings{SetCommand: "hyprctl hyprpaper wallpaper {display},{path}"})
+		require.NoError(t, err)
+
+		svc, ok := p.Wallpaper().(DisplayWallpaperService)
+		require.True(t, ok)
+		require.NoError(t, svc.SetDisplay("DP-1", "/tmp/a.jpg"))
+		assert.Equal(t, [][]string{{"hyprctl", "hyprpaper", "wallpaper", "DP-1,/tmp/a.jpg"}}, *calls)
+	})
+
+	t.Run("get display fills placeholder", func(t *testing.T) {
+		calls := stubRunCommand(t, "/tmp/b.jpg\n", nil)
+		p, err := NewCommandPlatform(base, CommandSettings{GetCommand: "wallpaper-of {display}"})
+		require.NoError(t, err)
+
+		path, err := p.Wallpaper().(DisplayWallpaperService).GetDisplay("HDMI-A-1")
+		require.NoError(t, err)
+		assert.Equal(t, "/tmp/b.jpg", path)
+		assert.Equal(t, [][]string{{"wallpaper-of", "HDMI-A-1"}}, *calls)
+	})
+
+	t.Run("displays require base support", func(t *testing.T) {
+		p, err := NewCommandPlatform(base, CommandSettings{SetCommand: "tool {path}"})
+		require.NoError(t, err)
+
+		_, err = p.Wallpaper().(DisplayWallpaperService).Displays()
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+}
+
+func TestCommandTheme(t *testing.T) {
+	base := &unsupportedPlatform{name: "plan9"}
+
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		expected Theme
+	}{
+		{"dark", "dark\n", nil, ThemeDark},
+		{"prefer-dark", "'prefer-dark'\n", nil, ThemeDark},
+		{"light", "light\n", nil, ThemeLight},
+		{"failure falls back", "", errors.New("exit status 1"), ThemeLight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubRunCommand(t, tt.output, tt.err)
+			p, err := NewCommandPlatform(base, CommandSettings{ThemeCommand: "darkman get"})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, p.Theme().Detect())
+		})
+	}
+}
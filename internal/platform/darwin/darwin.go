@@ -33,4 +33,13 @@ func (p *Platform) Theme() platform.ThemeService             { return p.theme }
 func (p *Platform) Scheduler() platform.SchedulerService     { return p.scheduler }
 func (p *Platform) FileManager() platform.FileManagerService { return p.fileManager }
 
-var _ platform.Platform = (*Platform)(nil)
+func (p *Platform) BackendInfo() platform.BackendInfo {
+	return platform.BackendInfo{Backend: "macos", Tool: "osascript"}
+}
+
+var (
+	_ platform.Platform        = (*Platform)(nil)
+	_ platform.BackendReporter = (*Platform)(nil)
+
+	_ platform.DisplayWallpaperService = (*WallpaperService)(nil)
+)
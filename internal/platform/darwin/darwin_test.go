@@ -110,3 +110,79 @@ func TestFileManagerService(t *testing.T) {
 	// Just verify the service is created
 	assert.NotNil(t, svc)
 }
+
+func TestParseDisplayNames(t *testing.T) {
+	displays := parseDisplayNames("Built-in Retina Display, DELL U2720Q\n")
+
+	require.Len(t, displays, 2)
+	assert.Equal(t, platform.Display{Name: "Built-in Retina Display", Primary: true}, displays[0])
+	assert.Equal(t, platform.Display{Name: "DELL U2720Q"}, displays[1])
+
+	assert.Empty(t, parseDisplayNames("\n"))
+	assert.Equal(t, `say \"hi\"`, escapeAppleScript(`say "hi"`))
+}
+
+func TestApplyDisplayResolutions(t *testing.T) {
+	profile := []byte(`{"SPDisplaysDataType": [{"spdisplays_ndrvs": [
+		{"_name": "DELL U2720Q", "_spdisplays_resolution": "3840 x 2160 (2160p/4K UHD 1 - Ultra High Definition)"},
+		{"_name": "Color LCD", "_spdisplays_resolution": "1728 x 1117 @ 120.00Hz", "spdisplays_main": "spdisplays_yes"}
+	]}]}`)
+
+	displays := parseDisplayNames("Built-in Retina Display, DELL U2720Q")
+	applyDisplayResolutions(displays, profile)
+
+	assert.Equal(t, 1728, displays[0].Width)
+	assert.Equal(t, 1117, displays[0].Height)
+	assert.Equal(t, 3840, displays[1].Width)
+	assert.Equal(t, 2160, displays[1].Height)
+}
+
+func TestRenderPlist(t *testing.T) {
+	interval := renderPlist(platform.SchedulerConfig{
+		Label:    "com.test.agent",
+		Command:  "/usr/local/bin/wallboy",
+		Args:     []string{"next"},
+		Interval: 5 * time.Minute,
+	})
+	assert.Contains(t, interval, "<key>StartInterval</key>\n    <integer>300</integer>")
+	assert.NotContains(t, interval, "KeepAlive")
+	assert.False(t, keepAliveRe.MatchString(interval))
+
+	daemon := renderPlist(platform.SchedulerConfig{
+		Label:     "com.test.agent",
+		Command:   "/usr/local/bin/wallboy",
+		Args:      []string{"daemon", "--interval", "300"},
+		KeepAlive: true,
+	})
+	assert.Contains(t, daemon, "<string>daemon</string>")
+	assert.NotContains(t, daemon, "StartInterval")
+	assert.Contains(t, daemon, "<key>RunAtLoad</key>\n    <true/>")
+	assert.True(t, keepAliveRe.MatchString(daemon))
+}
+
+func TestSchedulerService_StatusDaemonRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	svc := NewSchedulerService()
+
+	label := "com.wallboy.test.daemon"
+	plistPath, err := svc.getPlistPath(label)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(plistPath), 0755))
+
+	content := renderPlist(platform.SchedulerConfig{
+		Label:     label,
+		Command:   "/usr/local/bin/wallboy",
+		Args:      []string{"daemon", "--interval", "300"},
+		LogPath:   "/tmp/wallboy.log",
+		KeepAlive: true,
+	})
+	require.NoError(t, os.WriteFile(plistPath, []byte(content), 0644))
+
+	status, err := svc.Status(label)
+	require.NoError(t, err)
+	assert.True(t, status.Installed)
+	assert.True(t, status.KeepAlive)
+	assert.Equal(t, 5*time.Minute, status.Interval)
+	assert.Equal(t, "/tmp/wallboy.log", status.LogPath)
+}
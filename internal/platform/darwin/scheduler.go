@@ -23,9 +23,7 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
     <key>ProgramArguments</key>
     <array>
         <string>%s</string>%s
-    </array>
-    <key>StartInterval</key>
-    <integer>%d</integer>
+    </array>%s
     <key>RunAtLoad</key>
     <%s/>
     <key>StandardOutPath</key>
@@ -36,6 +34,11 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 </plist>
 `
 
+var (
+	keepAliveRe      = regexp.MustCompile(`<key>KeepAlive</key>\s*<true/>`)
+	daemonIntervalRe = regexp.MustCompile(`<string>--interval(?:</string>\s*<string>|=)(\d+)</string>`)
+)
+
 type SchedulerService struct{}
 
 func NewSchedulerService() *SchedulerService {
@@ -66,26 +69,7 @@ func (s *SchedulerService) Install(config platform.SchedulerConfig) error {
 		_ = exec.Command("launchctl", "unload", plistPath).Run()
 	}
 
-	argsStr := ""
-	for _, arg := range config.Args {
-		argsStr += fmt.Sprintf("\n        <string>%s</string>", arg)
-	}
-
-	runAtLoad := "false"
-	if config.RunAtLoad {
-		runAtLoad = "true"
-	}
-
-	intervalSeconds := int(config.Interval.Seconds())
-	plistContent := fmt.Sprintf(plistTemplate,
-		config.Label,
-		config.Command,
-		argsStr,
-		intervalSeconds,
-		runAtLoad,
-		config.LogPath,
-		config.LogPath,
-	)
+	plistContent := renderPlist(config)
 
 	if err := os.WriteFile(plistPath, []byte(plistContent), 0644); err != nil {
 		return fmt.Errorf("failed to write plist: %w", err)
@@ -142,10 +126,40 @@ func (s *SchedulerService) Status(label string) (platform.SchedulerStatus, error
 		status.Interval = interval
 		status.LogPath = logPath
 	}
+	if data, err := os.ReadFile(plistPath); err == nil {
+		status.KeepAlive = keepAliveRe.MatchString(string(data))
+	}
 
 	return status, nil
 }
 
+func renderPlist(config platform.SchedulerConfig) string {
+	argsStr := ""
+	for _, arg := range config.Args {
+		argsStr += fmt.Sprintf("\n        <string>%s</string>", arg)
+	}
+
+	schedule := fmt.Sprintf("\n    <key>StartInterval</key>\n    <integer>%d</integer>", int(config.Interval.Seconds()))
+	if config.KeepAlive {
+		schedule = "\n    <key>KeepAlive</key>\n    <true/>"
+	}
+
+	runAtLoad := "false"
+	if config.RunAtLoad || config.KeepAlive {
+		runAtLoad = "true"
+	}
+
+	return fmt.Sprintf(plistTemplate,
+		config.Label,
+		config.Command,
+		argsStr,
+		schedule,
+		runAtLoad,
+		config.LogPath,
+		config.LogPath,
+	)
+}
+
 func (s *SchedulerService) getPlistPath(label string) (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -165,6 +179,9 @@ func (s *SchedulerService) parsePlist(plistPath string) (time.Duration, string,
 	intervalRe := regexp.MustCompile(`<key>StartInterval</key>\s*<integer>(\d+)</integer>`)
 	intervalMatches := intervalRe.FindStringSubmatch(content)
 	var interval time.Duration
+	if len(intervalMatches) < 2 {
+		intervalMatches = daemonIntervalRe.FindStringSubmatch(content)
+	}
 	if len(intervalMatches) >= 2 {
 		seconds, _ := strconv.Atoi(intervalMatches[1])
 		interval = time.Duration(seconds) * time.Second
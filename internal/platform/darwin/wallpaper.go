@@ -3,9 +3,14 @@
 package darwin
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/Artawower/wallboy/internal/platform"
 )
 
 type WallpaperService struct{}
@@ -40,3 +45,117 @@ func (s *WallpaperService) Get() (string, error) {
 	path := strings.TrimSpace(string(output))
 	return path, nil
 }
+
+func (s *WallpaperService) Displays() ([]platform.Display, error) {
+	script := `tell application "System Events" to get display name of every desktop`
+
+	output, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list displays: %w", err)
+	}
+
+	displays := parseDisplayNames(string(output))
+
+	if profile, err := exec.Command("system_profiler", "SPDisplaysDataType", "-json").Output(); err == nil {
+		applyDisplayResolutions(displays, profile)
+	}
+
+	return displays, nil
+}
+
+func (s *WallpaperService) SetDisplay(display, path string) error {
+	script := fmt.Sprintf(`tell application "System Events"
+		tell (first desktop whose display name is "%s")
+			set picture to "%s"
+		end tell
+	end tell`, escapeAppleScript(display), path)
+
+	cmd := exec.Command("osascript", "-e", script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w (output: %s)", err, string(output))
+	}
+	return nil
+}
+
+func (s *WallpaperService) GetDisplay(display string) (string, error) {
+	script := fmt.Sprintf(`tell application "System Events" to get picture of (first desktop whose display name is "%s")`, escapeAppleScript(display))
+
+	output, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+func parseDisplayNames(output string) []platform.Display {
+	var displays []platform.Display
+	for i, name := range strings.Split(strings.TrimSpace(output), ", ") {
+		if name == "" {
+			continue
+		}
+		displays = append(displays, platform.Display{Name: name, Primary: i == 0})
+	}
+	return displays
+}
+
+var resolutionPattern = regexp.MustCompile(`(\d+)\s*x\s*(\d+)`)
+
+type profiledDisplay struct {
+	Name       string `json:"_name"`
+	Resolution string `json:"_spdisplays_resolution"`
+	Pixels     string `json:"_spdisplays_pixels"`
+	Main       string `json:"spdisplays_main"`
+}
+
+func applyDisplayResolutions(displays []platform.Display, data []byte) {
+	var profile struct {
+		Adapters []struct {
+			Displays []profiledDisplay `json:"spdisplays_ndrvs"`
+		} `json:"SPDisplaysDataType"`
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return
+	}
+
+	var profiled []profiledDisplay
+	for _, adapter := range profile.Adapters {
+		for _, d := range adapter.Displays {
+			if d.Main == "spdisplays_yes" {
+				profiled = append([]profiledDisplay{d}, profiled...)
+			} else {
+				profiled = append(profiled, d)
+			}
+		}
+	}
+
+	for i := range displays {
+		match := -1
+		for j, p := range profiled {
+			if p.Name == displays[i].Name {
+				match = j
+				break
+			}
+		}
+		if match == -1 && len(profiled) == len(displays) {
+			match = i
+		}
+		if match == -1 {
+			continue
+		}
+
+		resolution := profiled[match].Resolution
+		if resolution == "" {
+			resolution = profiled[match].Pixels
+		}
+		if m := resolutionPattern.FindStringSubmatch(resolution); m != nil {
+			displays[i].Width, _ = strconv.Atoi(m[1])
+			displays[i].Height, _ = strconv.Atoi(m[2])
+		}
+	}
+}
+
+func escapeAppleScript(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return replacer.Replace(s)
+}
@@ -41,7 +41,11 @@ func newPlatform() Platform {
 		return builder()
 	}
 
-	return &unsupportedPlatform{name: runtime.GOOS}
+	return Unsupported(runtime.GOOS)
+}
+
+func Unsupported(name string) Platform {
+	return &unsupportedPlatform{name: name}
 }
 
 type unsupportedPlatform struct {
@@ -0,0 +1,192 @@
+//go:build linux
+
+package linux
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+var xrandrMonitorGeometry = regexp.MustCompile(`^(\d+)/\d+x(\d+)/\d+([+-]\d+)([+-]\d+)$`)
+
+func waylandDisplays(run CommandRunner) ([]platform.Display, error) {
+	queries := []struct {
+		name  string
+		args  []string
+		parse func([]byte) ([]platform.Display, error)
+	}{
+		{"swaymsg", []string{"-t", "get_outputs", "-r"}, parseSwayOutputs},
+		{"hyprctl", []string{"monitors", "-j"}, parseHyprlandMonitors},
+		{"wlr-randr", []string{"--json"}, parseWlrRandr},
+	}
+
+	for _, q := range queries {
+		output, err := run(q.name, q.args...)
+		if err != nil {
+			continue
+		}
+		displays, err := q.parse(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s output: %w", q.name, err)
+		}
+		return displays, nil
+	}
+
+	return nil, fmt.Errorf("failed to list displays (install one of: swaymsg, hyprctl, wlr-randr)")
+}
+
+func x11Displays(run CommandRunner) ([]platform.Display, error) {
+	output, err := run("xrandr", "--listmonitors")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list displays: %w", err)
+	}
+	return parseXrandrMonitors(string(output))
+}
+
+func parseSwayOutputs(data []byte) ([]platform.Display, error) {
+	var outputs []struct {
+		Name   string `json:"name"`
+		Active bool   `json:"active"`
+		Rect   struct {
+			X      int `json:"x"`
+			Y      int `json:"y"`
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"rect"`
+	}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, err
+	}
+
+	var displays []platform.Display
+	for _, o := range outputs {
+		if !o.Active {
+			continue
+		}
+		displays = append(displays, platform.Display{
+			Name:   o.Name,
+			X:      o.Rect.X,
+			Y:      o.Rect.Y,
+			Width:  o.Rect.Width,
+			Height: o.Rect.Height,
+		})
+	}
+	return displays, nil
+}
+
+func parseHyprlandMonitors(data []byte) ([]platform.Display, error) {
+	var monitors []struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		X        int    `json:"x"`
+		Y        int    `json:"y"`
+		Width    int    `json:"width"`
+		Height   int    `json:"height"`
+		Disabled bool   `json:"disabled"`
+	}
+	if err := json.Unmarshal(data, &monitors); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].ID < monitors[j].ID })
+
+	var displays []platform.Display
+	for _, m := range monitors {
+		if m.Disabled {
+			continue
+		}
+		displays = append(displays, platform.Display{
+			Name:   m.Name,
+			X:      m.X,
+			Y:      m.Y,
+			Width:  m.Width,
+			Height: m.Height,
+		})
+	}
+	return displays, nil
+}
+
+func parseWlrRandr(data []byte) ([]platform.Display, error) {
+	var outputs []struct {
+		Name     string `json:"name"`
+		Enabled  bool   `json:"enabled"`
+		Position struct {
+			X int `json:"x"`
+			Y int `json:"y"`
+		} `json:"position"`
+		Modes []struct {
+			Width   int  `json:"width"`
+			Height  int  `json:"height"`
+			Current bool `json:"current"`
+		} `json:"modes"`
+	}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, err
+	}
+
+	var displays []platform.Display
+	for _, o := range outputs {
+		if !o.Enabled {
+			continue
+		}
+		d := platform.Display{Name: o.Name, X: o.Position.X, Y: o.Position.Y}
+		for _, mode := range o.Modes {
+			if mode.Current {
+				d.Width, d.Height = mode.Width, mode.Height
+				break
+			}
+		}
+		displays = append(displays, d)
+	}
+	return displays, nil
+}
+
+func parseXrandrMonitors(output string) ([]platform.Display, error) {
+	var displays []platform.Display
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "Monitors:") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("unexpected xrandr line: %q", line)
+		}
+
+		match := xrandrMonitorGeometry.FindStringSubmatch(fields[2])
+		if match == nil {
+			return nil, fmt.Errorf("unexpected monitor geometry: %q", fields[2])
+		}
+
+		d := platform.Display{
+			Name:    fields[len(fields)-1],
+			Primary: strings.Contains(fields[1], "*"),
+		}
+		d.Width, _ = strconv.Atoi(match[1])
+		d.Height, _ = strconv.Atoi(match[2])
+		d.X, _ = strconv.Atoi(match[3])
+		d.Y, _ = strconv.Atoi(match[4])
+		displays = append(displays, d)
+	}
+
+	return displays, scanner.Err()
+}
+
+func displayIndex(displays []platform.Display, name string) int {
+	for i, d := range displays {
+		if d.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+//go:build linux
+
+package linux
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const xrandrTwoMonitors = `Monitors: 2
+ 0: +*DP-1 2560/597x1440/336+0+0  DP-1
+ 1: +HDMI-1 1920/527x1080/296+2560+180  HDMI-1
+`
+
+func TestParseXrandrMonitors(t *testing.T) {
+	displays, err := parseXrandrMonitors(xrandrTwoMonitors)
+	require.NoError(t, err)
+
+	assert.Equal(t, []platform.Display{
+		{Name: "DP-1", Primary: true, X: 0, Y: 0, Width: 2560, Height: 1440},
+		{Name: "HDMI-1", X: 2560, Y: 180, Width: 1920, Height: 1080},
+	}, displays)
+
+	_, err = parseXrandrMonitors("Monitors: 1\n 0: +eDP-1 broken eDP-1\n")
+	assert.Error(t, err)
+}
+
+func TestParseSwayOutputs(t *testing.T) {
+	displays, err := parseSwayOutputs([]byte(`[
+		{"name": "eDP-1", "active": true, "rect": {"x": 0, "y": 0, "width": 1920, "height": 1200}},
+		{"name": "DP-2", "active": false, "rect": {"x": 0, "y": 0, "width": 0, "height": 0}},
+		{"name": "DP-3", "active": true, "rect": {"x": 1920, "y": 0, "width": 2560, "height": 1440}}
+	]`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []platform.Display{
+		{Name: "eDP-1", Width: 1920, Height: 1200},
+		{Name: "DP-3", X: 1920, Width: 2560, Height: 1440},
+	}, displays)
+}
+
+func TestParseHyprlandMonitors(t *testing.T) {
+	displays, err := parseHyprlandMonitors([]byte(`[
+		{"id": 1, "name": "HDMI-A-1", "x": 2560, "y": 0, "width": 1920, "height": 1080, "disabled": false},
+		{"id": 0, "name": "DP-1", "x": 0, "y": 0, "width": 2560, "height": 1440, "disabled": false}
+	]`))
+	require.NoError(t, err)
+
+	require.Len(t, displays, 2)
+	assert.Equal(t, "DP-1", displays[0].Name)
+	assert.Equal(t, "HDMI-A-1", displays[1].Name)
+	assert.Equal(t, 2560, displays[1].X)
+}
+
+func TestParseWlrRandr(t *testing.T) {
+	displays, err := parseWlrRandr([]byte(`[
+		{"name": "DP-1", "enabled": true, "position": {"x": 0, "y": 0},
+		 "modes": [{"width": 1920, "height": 1080, "current": false}, {"width": 3840, "height": 2160, "current": true}]}
+	]`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []platform.Display{{Name: "DP-1", Width: 3840, Height: 2160}}, displays)
+}
+
+func TestWaylandDisplays_FallsThroughCompositors(t *testing.T) {
+	runner := newFakeRunner()
+	runner.errs["swaymsg -t get_outputs -r"] = errors.New("not found")
+	runner.outputs["hyprctl monitors -j"] = `[{"id": 0, "name": "DP-1", "width": 2560, "height": 1440}]`
+
+	displays, err := waylandDisplays(runner.run)
+	require.NoError(t, err)
+	require.Len(t, displays, 1)
+	assert.Equal(t, "DP-1", displays[0].Name)
+
+	runner = newFakeRunner()
+	for _, key := range []string{"swaymsg -t get_outputs -r", "hyprctl monitors -j", "wlr-randr --json"} {
+		runner.errs[key] = errors.New("not found")
+	}
+	_, err = waylandDisplays(runner.run)
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+type FileManagerService struct {
+	run CommandRunner
+}
+
+func NewFileManagerService(run CommandRunner) *FileManagerService {
+	return &FileManagerService{run: run}
+}
+
+func (s *FileManagerService) Reveal(path string) error {
+	if _, err := s.run("xdg-open", filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to reveal in file manager: %w", err)
+	}
+	return nil
+}
+
+func (s *FileManagerService) Open(path string) error {
+	if _, err := s.run("xdg-open", path); err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	gnomeBackgroundSchema = "org.gnome.desktop.background"
+	gnomePictureURIKey    = "picture-uri"
+	gnomePictureURIDark   = "picture-uri-dark"
+)
+
+type GnomeWallpaperService struct {
+	run CommandRunner
+}
+
+func NewGnomeWallpaperService(run CommandRunner) *GnomeWallpaperService {
+	return &GnomeWallpaperService{run: run}
+}
+
+func (s *GnomeWallpaperService) Set(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	value := quoteGVariantString(fileURI(absPath))
+	for _, key := range []string{gnomePictureURIKey, gnomePictureURIDark} {
+		if _, err := s.run("gsettings", "set", gnomeBackgroundSchema, key, value); err != nil {
+			return fmt.Errorf("failed to set wallpaper: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *GnomeWallpaperService) Get() (string, error) {
+	output, err := s.run("gsettings", "get", gnomeBackgroundSchema, gnomePictureURIKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+
+	path, err := parseFileURI(unquoteGVariantString(string(output)))
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	return path, nil
+}
+
+func fileURI(path string) string {
+	u := url.URL{Scheme: "file", Path: path}
+	return u.String()
+}
+
+func parseFileURI(uri string) (string, error) {
+	if uri == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(uri, "file://") {
+		return uri, nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid wallpaper uri %q: %w", uri, err)
+	}
+	return u.Path, nil
+}
+
+func quoteGVariantString(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(s) + "'"
+}
+
+func unquoteGVariantString(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+		replacer := strings.NewReplacer(`\\`, `\`, `\'`, `'`, `\"`, `"`)
+		s = replacer.Replace(s)
+	}
+	return s
+}
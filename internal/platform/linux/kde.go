@@ -0,0 +1,213 @@
+//go:build linux
+
+package linux
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+const (
+	plasmaApplyTool   = "plasma-apply-wallpaperimage"
+	plasmaImagePlugin = "org.kde.image"
+	plasmaAppletsrc   = "plasma-org.kde.plasma.desktop-appletsrc"
+)
+
+var qdbusTools = []string{"qdbus6", "qdbus", "qdbus-qt5"}
+
+const plasmaScriptTemplate = `var allDesktops = desktops();
+for (var i = 0; i < allDesktops.length; i++) {
+    var d = allDesktops[i];
+    d.wallpaperPlugin = "org.kde.image";
+    d.currentConfigGroup = ["Wallpaper", "org.kde.image", "General"];
+    d.writeConfig("Image", %s);
+}`
+
+type KDEWallpaperService struct {
+	appletsrcPath string
+
+	run      CommandRunner
+	lookPath func(string) (string, error)
+}
+
+func NewKDEWallpaperService(run CommandRunner) *KDEWallpaperService {
+	return &KDEWallpaperService{
+		run:      run,
+		lookPath: exec.LookPath,
+	}
+}
+
+func (s *KDEWallpaperService) Set(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	tool, err := findTool(s.lookPath, "", s.tools())
+	if err != nil {
+		return err
+	}
+
+	if tool == plasmaApplyTool {
+		_, err = s.run(plasmaApplyTool, absPath)
+	} else {
+		_, err = s.run(tool, "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript", plasmaScript(absPath))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+	return nil
+}
+
+func (s *KDEWallpaperService) Get() (string, error) {
+	configPath, err := s.getAppletsrcPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+
+	f, err := os.Open(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	defer f.Close()
+
+	image, err := parseAppletsrc(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	return parseFileURI(image)
+}
+
+func (s *KDEWallpaperService) Tool() string {
+	tool, _ := findTool(s.lookPath, "", s.tools())
+	return tool
+}
+
+func (s *KDEWallpaperService) Candidates() []platform.ToolCandidate {
+	return toolCandidates(s.lookPath, s.tools())
+}
+
+func (s *KDEWallpaperService) tools() []string {
+	return append([]string{plasmaApplyTool}, qdbusTools...)
+}
+
+func (s *KDEWallpaperService) getAppletsrcPath() (string, error) {
+	if s.appletsrcPath != "" {
+		return s.appletsrcPath, nil
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, plasmaAppletsrc), nil
+}
+
+func plasmaScript(path string) string {
+	literal, _ := json.Marshal(fileURI(path))
+	return fmt.Sprintf(plasmaScriptTemplate, literal)
+}
+
+func parseAppletsrc(r io.Reader) (string, error) {
+	type containment struct {
+		plugin          string
+		wallpaperPlugin string
+		image           string
+	}
+
+	containments := make(map[int]*containment)
+	get := func(id int) *containment {
+		if c, ok := containments[id]; ok {
+			return c
+		}
+		c := &containment{}
+		containments[id] = c
+		return c
+	}
+
+	var current []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			current = parseGroupHeader(line)
+			continue
+		}
+
+		if len(current) < 2 || current[0] != "Containments" {
+			continue
+		}
+		id, err := strconv.Atoi(current[1])
+		if err != nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(current) == 2 && key == "plugin":
+			get(id).plugin = value
+		case len(current) == 2 && key == "wallpaperplugin":
+			get(id).wallpaperPlugin = value
+		case len(current) == 5 && current[2] == "Wallpaper" && current[3] == plasmaImagePlugin && current[4] == "General" && key == "Image":
+			get(id).image = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	ids := make([]int, 0, len(containments))
+	for id := range containments {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		c := containments[id]
+		if c.image == "" || c.plugin == "org.kde.panel" {
+			continue
+		}
+		if c.wallpaperPlugin != "" && c.wallpaperPlugin != plasmaImagePlugin {
+			continue
+		}
+		return c.image, nil
+	}
+
+	return "", fmt.Errorf("no %s wallpaper found in %s", plasmaImagePlugin, plasmaAppletsrc)
+}
+
+func parseGroupHeader(line string) []string {
+	var parts []string
+	for strings.HasPrefix(line, "[") {
+		end := strings.Index(line, "]")
+		if end == -1 {
+			break
+		}
+		parts = append(parts, line[1:end])
+		line = line[end+1:]
+	}
+	return parts
+}
@@ -0,0 +1,97 @@
+//go:build linux
+
+package linux
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKDE(available ...string) (*KDEWallpaperService, *fakeRunner) {
+	runner := newFakeRunner()
+	svc := NewKDEWallpaperService(runner.run)
+	svc.lookPath = func(name string) (string, error) {
+		for _, a := range available {
+			if a == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	return svc, runner
+}
+
+func TestParseAppletsrc(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected string
+		wantErr  bool
+	}{
+		{"basic", "testdata/appletsrc_basic", "file:///home/user/Pictures/Wallpapers/mountains.jpg", false},
+		{"multi screen skips panel and picks lowest desktop", "testdata/appletsrc_multi_screen", "file:///home/user/Pictures/Wallpapers/first%20screen.png", false},
+		{"slideshow plugin has no image", "testdata/appletsrc_no_image", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.file)
+			require.NoError(t, err)
+			defer f.Close()
+
+			image, err := parseAppletsrc(f)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, image)
+		})
+	}
+}
+
+func TestKDEWallpaperService_Get(t *testing.T) {
+	svc, _ := newTestKDE()
+
+	svc.appletsrcPath = "testdata/appletsrc_multi_screen"
+	path, err := svc.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "/home/user/Pictures/Wallpapers/first screen.png", path)
+
+	svc.appletsrcPath = "testdata/does_not_exist"
+	_, err = svc.Get()
+	assert.Error(t, err)
+}
+
+func TestKDEWallpaperService_SetWithPlasmaApply(t *testing.T) {
+	svc, runner := newTestKDE("plasma-apply-wallpaperimage", "qdbus")
+
+	require.NoError(t, svc.Set("/tmp/wall.jpg"))
+	assert.Equal(t, [][]string{{"plasma-apply-wallpaperimage", "/tmp/wall.jpg"}}, runner.calls)
+	assert.Equal(t, "plasma-apply-wallpaperimage", svc.Tool())
+}
+
+func TestKDEWallpaperService_SetWithQdbus(t *testing.T) {
+	svc, runner := newTestKDE("qdbus")
+
+	require.NoError(t, svc.Set("/tmp/my wall.jpg"))
+	require.Len(t, runner.calls, 1)
+
+	call := runner.calls[0]
+	assert.Equal(t, []string{"qdbus", "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript"}, call[:4])
+	assert.Contains(t, call[4], `d.writeConfig("Image", "file:///tmp/my%20wall.jpg");`)
+	assert.Contains(t, call[4], `d.wallpaperPlugin = "org.kde.image";`)
+}
+
+func TestKDEWallpaperService_NoTool(t *testing.T) {
+	svc, _ := newTestKDE()
+
+	err := svc.Set("/tmp/wall.jpg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plasma-apply-wallpaperimage")
+	assert.Len(t, svc.Candidates(), 4)
+}
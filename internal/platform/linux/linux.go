@@ -0,0 +1,147 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+const (
+	backendAuto    = "auto"
+	backendGnome   = "gnome"
+	backendKDE     = "kde"
+	backendWlroots = "wlroots"
+	backendSwww    = "swww"
+	backendSwaybg  = "swaybg"
+
+	backendX11        = "x11"
+	backendFeh        = "feh"
+	backendXwallpaper = "xwallpaper"
+	backendNitrogen   = "nitrogen"
+	backendHsetroot   = "hsetroot"
+)
+
+func init() {
+	platform.Register("linux", func() platform.Platform {
+		return New()
+	})
+}
+
+type CommandRunner func(name string, args ...string) ([]byte, error)
+
+func execRunner(name string, args ...string) ([]byte, error) {
+	output, err := exec.Command(name, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return output, fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return output, err
+}
+
+type Platform struct {
+	wallpaper   platform.WallpaperService
+	theme       *ThemeService
+	scheduler   *SchedulerService
+	fileManager *FileManagerService
+
+	backend string
+	run     CommandRunner
+	getenv  func(string) string
+}
+
+func New() *Platform {
+	p := &Platform{
+		theme:       NewThemeService(execRunner),
+		scheduler:   NewSchedulerService(execRunner),
+		fileManager: NewFileManagerService(execRunner),
+		run:         execRunner,
+		getenv:      os.Getenv,
+	}
+	_ = p.Configure(platform.Settings{})
+	return p
+}
+
+func (p *Platform) Name() string                             { return "linux" }
+func (p *Platform) IsSupported() bool                        { return true }
+func (p *Platform) Wallpaper() platform.WallpaperService     { return p.wallpaper }
+func (p *Platform) Theme() platform.ThemeService             { return p.theme }
+func (p *Platform) Scheduler() platform.SchedulerService     { return p.scheduler }
+func (p *Platform) FileManager() platform.FileManagerService { return p.fileManager }
+func (p *Platform) Backend() string                          { return p.backend }
+
+func (p *Platform) BackendInfo() platform.BackendInfo {
+	info := platform.BackendInfo{Backend: p.backend}
+	switch svc := p.wallpaper.(type) {
+	case toolReporter:
+		info.Tool = svc.Tool()
+		info.Candidates = svc.Candidates()
+	case *GnomeWallpaperService:
+		info.Tool = "gsettings"
+	}
+	return info
+}
+
+func (p *Platform) Configure(settings platform.Settings) error {
+	backend := settings.Backend
+	if backend == "" || backend == backendAuto {
+		backend = detectBackend(p.getenv)
+	}
+
+	switch backend {
+	case backendGnome:
+		p.wallpaper = NewGnomeWallpaperService(p.run)
+	case backendKDE:
+		p.wallpaper = NewKDEWallpaperService(p.run)
+	case backendWlroots:
+		p.wallpaper = NewWlrootsWallpaperService("", settings.Transition, p.run)
+	case backendSwww, backendSwaybg:
+		p.wallpaper = NewWlrootsWallpaperService(backend, settings.Transition, p.run)
+	case backendX11:
+		p.wallpaper = NewX11WallpaperService("", settings.Mode, p.run)
+	case backendFeh, backendXwallpaper, backendNitrogen, backendHsetroot:
+		p.wallpaper = NewX11WallpaperService(backend, settings.Mode, p.run)
+	default:
+		return fmt.Errorf("unknown wallpaper backend: %s", backend)
+	}
+
+	p.backend = backend
+	return nil
+}
+
+func detectBackend(getenv func(string) string) string {
+	for _, desktop := range strings.Split(strings.ToLower(getenv("XDG_CURRENT_DESKTOP")), ":") {
+		switch desktop {
+		case "gnome", "unity", "budgie":
+			return backendGnome
+		case "kde":
+			return backendKDE
+		case "sway", "hyprland", "river", "wayfire", "labwc", "niri", "wlroots":
+			return backendWlroots
+		case "i3", "bspwm", "awesome", "dwm", "openbox", "xmonad", "herbstluftwm", "qtile", "fluxbox", "leftwm":
+			return backendX11
+		}
+	}
+
+	if getenv("SWAYSOCK") != "" || getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" {
+		return backendWlroots
+	}
+
+	if getenv("XDG_CURRENT_DESKTOP") == "" && getenv("DISPLAY") != "" && getenv("WAYLAND_DISPLAY") == "" {
+		return backendX11
+	}
+
+	return backendGnome
+}
+
+var (
+	_ platform.Platform        = (*Platform)(nil)
+	_ platform.Configurable    = (*Platform)(nil)
+	_ platform.BackendReporter = (*Platform)(nil)
+
+	_ platform.DisplayWallpaperService = (*WlrootsWallpaperService)(nil)
+	_ platform.DisplayWallpaperService = (*X11WallpaperService)(nil)
+)
@@ -0,0 +1,259 @@
+//go:build linux
+
+package linux
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRunner struct {
+	calls   [][]string
+	outputs map[string]string
+	errs    map[string]error
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{
+		outputs: make(map[string]string),
+		errs:    make(map[string]error),
+	}
+}
+
+func (f *fakeRunner) run(name string, args ...string) ([]byte, error) {
+	call := append([]string{name}, args...)
+	f.calls = append(f.calls, call)
+	key := strings.Join(call, " ")
+	return []byte(f.outputs[key]), f.errs[key]
+}
+
+func TestPlatformInterface(t *testing.T) {
+	p := New()
+
+	var _ platform.Platform = p
+
+	assert.Equal(t, "linux", p.Name())
+	assert.True(t, p.IsSupported())
+	assert.NotNil(t, p.Wallpaper())
+	assert.NotNil(t, p.Theme())
+	assert.NotNil(t, p.Scheduler())
+	assert.NotNil(t, p.FileManager())
+}
+
+func TestGnomeWallpaperService_Set(t *testing.T) {
+	runner := newFakeRunner()
+	svc := NewGnomeWallpaperService(runner.run)
+
+	err := svc.Set("/home/user/Pictures/my wall.jpg")
+	require.NoError(t, err)
+
+	require.Len(t, runner.calls, 2)
+	assert.Equal(t, []string{"gsettings", "set", "org.gnome.desktop.background", "picture-uri", "'file:///home/user/Pictures/my%20wall.jpg'"}, runner.calls[0])
+	assert.Equal(t, []string{"gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", "'file:///home/user/Pictures/my%20wall.jpg'"}, runner.calls[1])
+}
+
+func TestGnomeWallpaperService_SetError(t *testing.T) {
+	runner := newFakeRunner()
+	runner.errs["gsettings set org.gnome.desktop.background picture-uri 'file:///tmp/a.jpg'"] = errors.New("no schema")
+	svc := NewGnomeWallpaperService(runner.run)
+
+	err := svc.Set("/tmp/a.jpg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to set wallpaper")
+	assert.Len(t, runner.calls, 1)
+}
+
+func TestGnomeWallpaperService_Get(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"quoted uri", "'file:///home/user/Pictures/wall.jpg'\n", "/home/user/Pictures/wall.jpg"},
+		{"escaped uri", "'file:///home/user/Pictures/my%20wall.jpg'\n", "/home/user/Pictures/my wall.jpg"},
+		{"plain path", "'/home/user/wall.png'\n", "/home/user/wall.png"},
+		{"empty", "''\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner()
+			runner.outputs["gsettings get org.gnome.desktop.background picture-uri"] = tt.output
+			svc := NewGnomeWallpaperService(runner.run)
+
+			path, err := svc.Get()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, path)
+		})
+	}
+}
+
+func TestQuoteGVariantString(t *testing.T) {
+	assert.Equal(t, `'file:///tmp/a.jpg'`, quoteGVariantString("file:///tmp/a.jpg"))
+	assert.Equal(t, `'it\'s'`, quoteGVariantString("it's"))
+	assert.Equal(t, "it's", unquoteGVariantString(quoteGVariantString("it's")))
+}
+
+func TestFileManagerService(t *testing.T) {
+	runner := newFakeRunner()
+	svc := NewFileManagerService(runner.run)
+
+	require.NoError(t, svc.Reveal("/home/user/Pictures/wall.jpg"))
+	require.NoError(t, svc.Open("/home/user/Pictures/wall.jpg"))
+
+	assert.Equal(t, []string{"xdg-open", "/home/user/Pictures"}, runner.calls[0])
+	assert.Equal(t, []string{"xdg-open", "/home/user/Pictures/wall.jpg"}, runner.calls[1])
+}
+
+func TestThemeService_Detect(t *testing.T) {
+	tests := []struct {
+		name        string
+		colorScheme string
+		gtkTheme    string
+		gtkEnv      string
+		expected    platform.Theme
+	}{
+		{"prefer-dark", "'prefer-dark'\n", "'Adwaita'\n", "", platform.ThemeDark},
+		{"prefer-light", "'prefer-light'\n", "'Adwaita-dark'\n", "", platform.ThemeLight},
+		{"default falls back to gtk-theme", "'default'\n", "'Adwaita-dark'\n", "", platform.ThemeDark},
+		{"default with light gtk-theme", "'default'\n", "'Adwaita'\n", "", platform.ThemeLight},
+		{"GTK_THEME env variant", "'default'\n", "'Adwaita'\n", "Adwaita:dark", platform.ThemeDark},
+		{"GTK_THEME env name", "", "", "Arc-Dark", platform.ThemeDark},
+		{"nothing available", "", "", "", platform.ThemeLight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner()
+			if tt.colorScheme != "" {
+				runner.outputs["gsettings get org.gnome.desktop.interface color-scheme"] = tt.colorScheme
+			} else {
+				runner.errs["gsettings get org.gnome.desktop.interface color-scheme"] = errors.New("no schema")
+			}
+			if tt.gtkTheme != "" {
+				runner.outputs["gsettings get org.gnome.desktop.interface gtk-theme"] = tt.gtkTheme
+			} else {
+				runner.errs["gsettings get org.gnome.desktop.interface gtk-theme"] = errors.New("no schema")
+			}
+
+			svc := NewThemeService(runner.run)
+			svc.getenv = func(key string) string {
+				if key == "GTK_THEME" {
+					return tt.gtkEnv
+				}
+				return ""
+			}
+
+			assert.Equal(t, tt.expected, svc.Detect())
+		})
+	}
+}
+
+func TestThemeService_Watch(t *testing.T) {
+	var mu sync.Mutex
+	scheme := "'prefer-light'\n"
+	setScheme := func(value string) {
+		mu.Lock()
+		defer mu.Unlock()
+		scheme = value
+	}
+
+	svc := NewThemeService(func(name string, args ...string) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(scheme), nil
+	})
+	svc.getenv = func(string) string { return "" }
+
+	lines := make(chan string)
+	var monitored []string
+	svc.monitor = func(ctx context.Context, name string, args ...string) (<-chan string, error) {
+		monitored = append([]string{name}, args...)
+		return lines, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	themes := svc.Watch(ctx)
+	assert.Equal(t, []string{"gsettings", "monitor", "org.gnome.desktop.interface", "color-scheme"}, monitored)
+
+	setScheme("'prefer-dark'\n")
+	lines <- "color-scheme: 'prefer-dark'"
+	assert.Equal(t, platform.ThemeDark, <-themes)
+
+	lines <- "color-scheme: 'prefer-dark'"
+	setScheme("'prefer-light'\n")
+	lines <- "color-scheme: 'prefer-light'"
+	assert.Equal(t, platform.ThemeLight, <-themes)
+
+	close(lines)
+	_, ok := <-themes
+	assert.False(t, ok)
+}
+
+func TestDetectBackend(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"gnome", map[string]string{"XDG_CURRENT_DESKTOP": "ubuntu:GNOME"}, backendGnome},
+		{"kde", map[string]string{"XDG_CURRENT_DESKTOP": "KDE"}, backendKDE},
+		{"sway", map[string]string{"XDG_CURRENT_DESKTOP": "sway"}, backendWlroots},
+		{"hyprland", map[string]string{"XDG_CURRENT_DESKTOP": "Hyprland"}, backendWlroots},
+		{"swaysock only", map[string]string{"SWAYSOCK": "/run/user/1000/sway-ipc.sock"}, backendWlroots},
+		{"i3", map[string]string{"XDG_CURRENT_DESKTOP": "i3", "DISPLAY": ":0"}, backendX11},
+		{"bare X11 session", map[string]string{"DISPLAY": ":0"}, backendX11},
+		{"xwayland display", map[string]string{"DISPLAY": ":0", "WAYLAND_DISPLAY": "wayland-1"}, backendGnome},
+		{"nothing set", map[string]string{}, backendGnome},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, detectBackend(func(key string) string { return tt.env[key] }))
+		})
+	}
+}
+
+func TestPlatform_Configure(t *testing.T) {
+	p := New()
+
+	require.NoError(t, p.Configure(platform.Settings{Backend: "swaybg"}))
+	assert.Equal(t, "swaybg", p.Backend())
+	assert.IsType(t, &WlrootsWallpaperService{}, p.Wallpaper())
+
+	require.NoError(t, p.Configure(platform.Settings{Backend: "gnome"}))
+	assert.Equal(t, "gnome", p.Backend())
+	assert.IsType(t, &GnomeWallpaperService{}, p.Wallpaper())
+
+	require.NoError(t, p.Configure(platform.Settings{Backend: "feh", Mode: "tile"}))
+	assert.Equal(t, "feh", p.Backend())
+	assert.IsType(t, &X11WallpaperService{}, p.Wallpaper())
+
+	err := p.Configure(platform.Settings{Backend: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestPlatform_BackendInfo(t *testing.T) {
+	p := New()
+
+	require.NoError(t, p.Configure(platform.Settings{Backend: "gnome"}))
+	info := p.BackendInfo()
+	assert.Equal(t, "gnome", info.Backend)
+	assert.Equal(t, "gsettings", info.Tool)
+
+	require.NoError(t, p.Configure(platform.Settings{Backend: "x11"}))
+	info = p.BackendInfo()
+	assert.Equal(t, "x11", info.Backend)
+	require.Len(t, info.Candidates, 4)
+	assert.Equal(t, []string{"feh", "xwallpaper", "nitrogen", "hsetroot"}, []string{
+		info.Candidates[0].Name, info.Candidates[1].Name, info.Candidates[2].Name, info.Candidates[3].Name,
+	})
+}
@@ -0,0 +1,111 @@
+//go:build linux
+
+package linux
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+type wallpaperRecord struct {
+	Tool     string            `json:"tool"`
+	Path     string            `json:"path"`
+	PID      int               `json:"pid,omitempty"`
+	Displays map[string]string `json:"displays,omitempty"`
+}
+
+func (r wallpaperRecord) pathFor(display string) string {
+	if path, ok := r.Displays[display]; ok {
+		return path
+	}
+	return r.Path
+}
+
+func (r wallpaperRecord) current() string {
+	if r.Path != "" || len(r.Displays) == 0 {
+		return r.Path
+	}
+	names := make([]string, 0, len(r.Displays))
+	for name := range r.Displays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return r.Displays[names[0]]
+}
+
+func (r *wallpaperRecord) setDisplay(display, path string) {
+	if r.Displays == nil {
+		r.Displays = make(map[string]string)
+	}
+	r.Displays[display] = path
+}
+
+func defaultRecordPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "wallboy", "wallpaper.json")
+}
+
+func loadRecord(path string) (wallpaperRecord, error) {
+	var record wallpaperRecord
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return record, nil
+		}
+		return record, fmt.Errorf("failed to read wallpaper record: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("failed to parse wallpaper record: %w", err)
+	}
+	return record, nil
+}
+
+func saveRecord(path string, record wallpaperRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create record directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal wallpaper record: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write wallpaper record: %w", err)
+	}
+	return nil
+}
+
+func spawnDetached(name string, args ...string) (int, error) {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	go func() { _ = cmd.Wait() }()
+	return cmd.Process.Pid, nil
+}
+
+func processRunning(pid int, name string) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(data)) == name
+}
+
+func killProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
@@ -0,0 +1,359 @@
+//go:build linux
+
+package linux
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+const serviceTemplate = `[Unit]
+Description=Wallboy wallpaper rotation (%s)
+
+[Service]
+Type=oneshot
+ExecStart=%s
+StandardOutput=append:%s
+StandardError=append:%s
+`
+
+const daemonServiceTemplate = `[Unit]
+Description=Wallboy wallpaper daemon (%s)
+
+[Service]
+Type=simple
+ExecStart=%s
+Restart=on-failure
+RestartSec=10
+StandardOutput=append:%s
+StandardError=append:%s
+
+[Install]
+WantedBy=default.target
+`
+
+const timerTemplate = `[Unit]
+Description=Wallboy wallpaper rotation timer (%s)
+
+[Timer]
+OnActiveSec=%s
+OnUnitActiveSec=%s
+Unit=%s.service
+
+[Install]
+WantedBy=timers.target
+`
+
+type SchedulerService struct {
+	run      CommandRunner
+	lookPath func(string) (string, error)
+	unitDir  string
+}
+
+func NewSchedulerService(run CommandRunner) *SchedulerService {
+	return &SchedulerService{
+		run:      run,
+		lookPath: exec.LookPath,
+	}
+}
+
+func (s *SchedulerService) IsSupported() bool {
+	_, err := s.lookPath("systemctl")
+	return err == nil
+}
+
+func (s *SchedulerService) Install(config platform.SchedulerConfig) error {
+	servicePath, timerPath, err := s.getUnitPaths(config.Label)
+	if err != nil {
+		return fmt.Errorf("failed to get unit path: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(servicePath), 0755); err != nil {
+		return fmt.Errorf("failed to create systemd user directory: %w", err)
+	}
+
+	if config.LogPath != "" {
+		if err := os.MkdirAll(filepath.Dir(config.LogPath), 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+
+	if _, err := os.Stat(timerPath); err == nil {
+		_, _ = s.run("systemctl", "--user", "disable", "--now", config.Label+".timer")
+	}
+	if _, err := os.Stat(servicePath); err == nil {
+		_, _ = s.run("systemctl", "--user", "disable", "--now", config.Label+".service")
+	}
+
+	service, timer := s.renderUnits(config)
+
+	if err := os.WriteFile(servicePath, []byte(service), 0644); err != nil {
+		return fmt.Errorf("failed to write service unit: %w", err)
+	}
+
+	if config.KeepAlive {
+		if err := os.Remove(timerPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove timer unit: %w", err)
+		}
+		if _, err := s.run("systemctl", "--user", "daemon-reload"); err != nil {
+			return fmt.Errorf("failed to reload systemd: %w", err)
+		}
+		if _, err := s.run("systemctl", "--user", "enable", "--now", config.Label+".service"); err != nil {
+			return fmt.Errorf("failed to enable service: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(timerPath, []byte(timer), 0644); err != nil {
+		return fmt.Errorf("failed to write timer unit: %w", err)
+	}
+
+	if _, err := s.run("systemctl", "--user", "daemon-reload"); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+	if _, err := s.run("systemctl", "--user", "enable", "--now", config.Label+".timer"); err != nil {
+		return fmt.Errorf("failed to enable timer: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SchedulerService) Uninstall(label string) error {
+	servicePath, timerPath, err := s.getUnitPaths(label)
+	if err != nil {
+		return fmt.Errorf("failed to get unit path: %w", err)
+	}
+
+	_, timerErr := os.Stat(timerPath)
+	_, serviceErr := os.Stat(servicePath)
+	if os.IsNotExist(timerErr) && os.IsNotExist(serviceErr) {
+		return nil
+	}
+
+	if timerErr == nil {
+		_, _ = s.run("systemctl", "--user", "disable", "--now", label+".timer")
+	} else {
+		_, _ = s.run("systemctl", "--user", "disable", "--now", label+".service")
+	}
+
+	for _, path := range []string{timerPath, servicePath} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove unit: %w", err)
+		}
+	}
+
+	_, _ = s.run("systemctl", "--user", "daemon-reload")
+
+	return nil
+}
+
+func (s *SchedulerService) Status(label string) (platform.SchedulerStatus, error) {
+	servicePath, timerPath, err := s.getUnitPaths(label)
+	if err != nil {
+		return platform.SchedulerStatus{}, fmt.Errorf("failed to get unit path: %w", err)
+	}
+
+	status := platform.SchedulerStatus{}
+
+	service, _ := parseUnitFile(servicePath)
+
+	unit := label + ".timer"
+	if _, err := os.Stat(timerPath); os.IsNotExist(err) {
+		if service == nil || service["Type"] != "simple" {
+			return status, nil
+		}
+		unit = label + ".service"
+		status.KeepAlive = true
+	}
+	status.Installed = true
+
+	output, err := s.run("systemctl", "--user", "is-active", unit)
+	status.Running = err == nil && strings.TrimSpace(string(output)) == "active"
+
+	if !status.KeepAlive {
+		if timer, err := parseUnitFile(timerPath); err == nil {
+			if interval, err := parseTimespan(timer["OnUnitActiveSec"]); err == nil {
+				status.Interval = interval
+			}
+		}
+	}
+
+	if service != nil {
+		status.LogPath = unescapeSystemdPath(strings.TrimPrefix(service["StandardOutput"], "append:"))
+	}
+
+	return status, nil
+}
+
+func (s *SchedulerService) renderUnits(config platform.SchedulerConfig) (string, string) {
+	execArgs := make([]string, 0, len(config.Args)+1)
+	execArgs = append(execArgs, quoteSystemdArg(config.Command))
+	for _, arg := range config.Args {
+		execArgs = append(execArgs, quoteSystemdArg(arg))
+	}
+
+	logPath := escapeSystemdPath(config.LogPath)
+	if logPath == "" {
+		logPath = os.DevNull
+	}
+
+	template := serviceTemplate
+	if config.KeepAlive {
+		template = daemonServiceTemplate
+	}
+
+	service := fmt.Sprintf(template,
+		config.Label,
+		strings.Join(execArgs, " "),
+		logPath,
+		logPath,
+	)
+
+	if config.KeepAlive {
+		return service, ""
+	}
+
+	firstRun := formatTimespan(config.Interval)
+	if config.RunAtLoad {
+		firstRun = "0"
+	}
+
+	timer := fmt.Sprintf(timerTemplate,
+		config.Label,
+		firstRun,
+		formatTimespan(config.Interval),
+		config.Label,
+	)
+
+	return service, timer
+}
+
+func (s *SchedulerService) getUnitPaths(label string) (string, string, error) {
+	dir := s.unitDir
+	if dir == "" {
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", "", err
+			}
+			configHome = filepath.Join(home, ".config")
+		}
+		dir = filepath.Join(configHome, "systemd", "user")
+	}
+	return filepath.Join(dir, label+".service"), filepath.Join(dir, label+".timer"), nil
+}
+
+func parseUnitFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return values, scanner.Err()
+}
+
+func formatTimespan(d time.Duration) string {
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
+var timespanUnits = map[string]time.Duration{
+	"":        time.Second,
+	"us":      time.Microsecond,
+	"ms":      time.Millisecond,
+	"s":       time.Second,
+	"sec":     time.Second,
+	"second":  time.Second,
+	"seconds": time.Second,
+	"m":       time.Minute,
+	"min":     time.Minute,
+	"minute":  time.Minute,
+	"minutes": time.Minute,
+	"h":       time.Hour,
+	"hr":      time.Hour,
+	"hour":    time.Hour,
+	"hours":   time.Hour,
+	"d":       24 * time.Hour,
+	"day":     24 * time.Hour,
+	"days":    24 * time.Hour,
+}
+
+func parseTimespan(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty timespan")
+	}
+
+	var total time.Duration
+	rest := value
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ")
+		numEnd := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if numEnd == -1 {
+			numEnd = len(rest)
+		}
+		if numEnd == 0 {
+			return 0, fmt.Errorf("invalid timespan: %q", value)
+		}
+		n, err := strconv.Atoi(rest[:numEnd])
+		if err != nil {
+			return 0, fmt.Errorf("invalid timespan: %q", value)
+		}
+		rest = strings.TrimLeft(rest[numEnd:], " ")
+
+		unitEnd := strings.IndexFunc(rest, func(r rune) bool { return r == ' ' || (r >= '0' && r <= '9') })
+		if unitEnd == -1 {
+			unitEnd = len(rest)
+		}
+		unit, ok := timespanUnits[rest[:unitEnd]]
+		if !ok {
+			return 0, fmt.Errorf("invalid timespan unit in %q", value)
+		}
+		rest = rest[unitEnd:]
+
+		total += time.Duration(n) * unit
+	}
+
+	return total, nil
+}
+
+func escapeSystemdPath(path string) string {
+	return strings.ReplaceAll(path, "%", "%%")
+}
+
+func unescapeSystemdPath(path string) string {
+	return strings.ReplaceAll(path, "%%", "%")
+}
+
+func quoteSystemdArg(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	arg = strings.ReplaceAll(arg, "$", "$$")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(arg) + `"`
+}
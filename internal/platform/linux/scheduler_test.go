@@ -0,0 +1,225 @@
+//go:build linux
+
+package linux
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScheduler(t *testing.T) (*SchedulerService, *fakeRunner, string) {
+	runner := newFakeRunner()
+	dir := t.TempDir()
+	svc := NewSchedulerService(runner.run)
+	svc.unitDir = dir
+	svc.lookPath = func(string) (string, error) { return "/usr/bin/systemctl", nil }
+	return svc, runner, dir
+}
+
+func TestSchedulerService_IsSupported(t *testing.T) {
+	svc, _, _ := newTestScheduler(t)
+	assert.True(t, svc.IsSupported())
+
+	svc.lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	assert.False(t, svc.IsSupported())
+}
+
+func TestSchedulerService_GetUnitPaths(t *testing.T) {
+	svc := NewSchedulerService(newFakeRunner().run)
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+
+	servicePath, timerPath, err := svc.getUnitPaths("com.test.agent")
+
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/xdg/systemd/user/com.test.agent.service", servicePath)
+	assert.Equal(t, "/tmp/xdg/systemd/user/com.test.agent.timer", timerPath)
+}
+
+func TestSchedulerService_Install(t *testing.T) {
+	svc, runner, dir := newTestScheduler(t)
+	logPath := filepath.Join(dir, "logs", "agent.log")
+
+	err := svc.Install(platform.SchedulerConfig{
+		Label:     "com.test.agent",
+		Command:   "/opt/my apps/wallboy",
+		Args:      []string{"next"},
+		Interval:  10 * time.Minute,
+		RunAtLoad: true,
+		LogPath:   logPath,
+	})
+	require.NoError(t, err)
+
+	service, err := os.ReadFile(filepath.Join(dir, "com.test.agent.service"))
+	require.NoError(t, err)
+	assert.Contains(t, string(service), `ExecStart="/opt/my apps/wallboy" next`)
+	assert.Contains(t, string(service), "StandardOutput=append:"+logPath)
+
+	timer, err := os.ReadFile(filepath.Join(dir, "com.test.agent.timer"))
+	require.NoError(t, err)
+	assert.Contains(t, string(timer), "OnActiveSec=0\n")
+	assert.Contains(t, string(timer), "OnUnitActiveSec=600s\n")
+	assert.Contains(t, string(timer), "Unit=com.test.agent.service\n")
+
+	assert.DirExists(t, filepath.Dir(logPath))
+	assert.Equal(t, [][]string{
+		{"systemctl", "--user", "daemon-reload"},
+		{"systemctl", "--user", "enable", "--now", "com.test.agent.timer"},
+	}, runner.calls)
+}
+
+func TestSchedulerService_Status(t *testing.T) {
+	svc, runner, dir := newTestScheduler(t)
+
+	status, err := svc.Status("com.test.agent")
+	require.NoError(t, err)
+	assert.False(t, status.Installed)
+	assert.False(t, status.Running)
+
+	require.NoError(t, svc.Install(platform.SchedulerConfig{
+		Label:    "com.test.agent",
+		Command:  "/usr/bin/wallboy",
+		Args:     []string{"next"},
+		Interval: 5 * time.Minute,
+		LogPath:  filepath.Join(dir, "agent.log"),
+	}))
+	runner.outputs["systemctl --user is-active com.test.agent.timer"] = "active\n"
+
+	status, err = svc.Status("com.test.agent")
+	require.NoError(t, err)
+	assert.True(t, status.Installed)
+	assert.True(t, status.Running)
+	assert.Equal(t, 5*time.Minute, status.Interval)
+	assert.Equal(t, filepath.Join(dir, "agent.log"), status.LogPath)
+}
+
+func TestSchedulerService_Uninstall(t *testing.T) {
+	svc, runner, dir := newTestScheduler(t)
+
+	require.NoError(t, svc.Uninstall("com.test.agent"))
+	assert.Empty(t, runner.calls)
+
+	require.NoError(t, svc.Install(platform.SchedulerConfig{
+		Label:    "com.test.agent",
+		Command:  "/usr/bin/wallboy",
+		Interval: time.Minute,
+	}))
+	require.NoError(t, svc.Uninstall("com.test.agent"))
+
+	assert.NoFileExists(t, filepath.Join(dir, "com.test.agent.service"))
+	assert.NoFileExists(t, filepath.Join(dir, "com.test.agent.timer"))
+}
+
+func TestParseTimespan(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"600s", 10 * time.Minute, false},
+		{"600", 10 * time.Minute, false},
+		{"10min", 10 * time.Minute, false},
+		{"1h 30min", 90 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"2 days", 48 * time.Hour, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"10parsecs", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := parseTimespan(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
+
+func TestQuoteSystemdArg(t *testing.T) {
+	assert.Equal(t, "next", quoteSystemdArg("next"))
+	assert.Equal(t, `"/opt/my apps/wallboy"`, quoteSystemdArg("/opt/my apps/wallboy"))
+	assert.Equal(t, `"say \"hi\""`, quoteSystemdArg(`say "hi"`))
+	assert.Equal(t, "100%%", quoteSystemdArg("100%"))
+	assert.Equal(t, `""`, quoteSystemdArg(""))
+}
+
+func TestSchedulerService_InstallLogPathEscaping(t *testing.T) {
+	svc, runner, dir := newTestScheduler(t)
+	logPath := filepath.Join(dir, "my logs", "100%", "agent.log")
+
+	require.NoError(t, svc.Install(platform.SchedulerConfig{
+		Label:    "com.test.agent",
+		Command:  "/usr/bin/wallboy",
+		Args:     []string{"next"},
+		Interval: 10 * time.Minute,
+		LogPath:  logPath,
+	}))
+
+	service, err := os.ReadFile(filepath.Join(dir, "com.test.agent.service"))
+	require.NoError(t, err)
+	escaped := filepath.Join(dir, "my logs", "100%%", "agent.log")
+	assert.Contains(t, string(service), "StandardOutput=append:"+escaped+"\n")
+	assert.Contains(t, string(service), "StandardError=append:"+escaped+"\n")
+	assert.DirExists(t, filepath.Dir(logPath))
+
+	runner.outputs["systemctl --user is-active com.test.agent.timer"] = "active\n"
+	status, err := svc.Status("com.test.agent")
+	require.NoError(t, err)
+	assert.Equal(t, logPath, status.LogPath)
+}
+
+func TestSchedulerService_InstallKeepAlive(t *testing.T) {
+	svc, runner, dir := newTestScheduler(t)
+
+	require.NoError(t, svc.Install(platform.SchedulerConfig{
+		Label:    "com.test.agent",
+		Command:  "/usr/bin/wallboy",
+		Args:     []string{"next"},
+		Interval: 10 * time.Minute,
+	}))
+	require.FileExists(t, filepath.Join(dir, "com.test.agent.timer"))
+	runner.calls = nil
+
+	require.NoError(t, svc.Install(platform.SchedulerConfig{
+		Label:     "com.test.agent",
+		Command:   "/usr/bin/wallboy",
+		Args:      []string{"daemon", "--interval", "600"},
+		KeepAlive: true,
+	}))
+
+	service, err := os.ReadFile(filepath.Join(dir, "com.test.agent.service"))
+	require.NoError(t, err)
+	assert.Contains(t, string(service), "Type=simple\n")
+	assert.Contains(t, string(service), "ExecStart=/usr/bin/wallboy daemon --interval 600\n")
+	assert.Contains(t, string(service), "Restart=on-failure\n")
+	assert.Contains(t, string(service), "WantedBy=default.target\n")
+	assert.NoFileExists(t, filepath.Join(dir, "com.test.agent.timer"))
+
+	assert.Equal(t, [][]string{
+		{"systemctl", "--user", "disable", "--now", "com.test.agent.timer"},
+		{"systemctl", "--user", "disable", "--now", "com.test.agent.service"},
+		{"systemctl", "--user", "daemon-reload"},
+		{"systemctl", "--user", "enable", "--now", "com.test.agent.service"},
+	}, runner.calls)
+
+	runner.outputs["systemctl --user is-active com.test.agent.service"] = "active\n"
+	status, err := svc.Status("com.test.agent")
+	require.NoError(t, err)
+	assert.True(t, status.Installed)
+	assert.True(t, status.Running)
+	assert.True(t, status.KeepAlive)
+
+	require.NoError(t, svc.Uninstall("com.test.agent"))
+	assert.NoFileExists(t, filepath.Join(dir, "com.test.agent.service"))
+}
@@ -0,0 +1,121 @@
+//go:build linux
+
+package linux
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+const gnomeInterfaceSchema = "org.gnome.desktop.interface"
+
+type LineStreamer func(ctx context.Context, name string, args ...string) (<-chan string, error)
+
+type ThemeService struct {
+	run     CommandRunner
+	monitor LineStreamer
+	getenv  func(string) string
+}
+
+func NewThemeService(run CommandRunner) *ThemeService {
+	return &ThemeService{
+		run:     run,
+		monitor: execLineStreamer,
+		getenv:  os.Getenv,
+	}
+}
+
+func (s *ThemeService) Detect() platform.Theme {
+	if output, err := s.run("gsettings", "get", gnomeInterfaceSchema, "color-scheme"); err == nil {
+		switch unquoteGVariantString(string(output)) {
+		case "prefer-dark":
+			return platform.ThemeDark
+		case "prefer-light":
+			return platform.ThemeLight
+		}
+	}
+
+	if gtkTheme := s.getenv("GTK_THEME"); gtkTheme != "" {
+		return themeFromGtkName(gtkTheme)
+	}
+
+	if output, err := s.run("gsettings", "get", gnomeInterfaceSchema, "gtk-theme"); err == nil {
+		return themeFromGtkName(unquoteGVariantString(string(output)))
+	}
+
+	return platform.ThemeLight
+}
+
+func (s *ThemeService) Watch(ctx context.Context) <-chan platform.Theme {
+	if _, err := s.run("gsettings", "get", gnomeInterfaceSchema, "color-scheme"); err != nil {
+		return platform.PollTheme(ctx, s, platform.DefaultThemePollInterval)
+	}
+
+	lines, err := s.monitor(ctx, "gsettings", "monitor", gnomeInterfaceSchema, "color-scheme")
+	if err != nil {
+		return platform.PollTheme(ctx, s, platform.DefaultThemePollInterval)
+	}
+
+	last := s.Detect()
+	ch := make(chan platform.Theme)
+	go func() {
+		defer close(ch)
+
+		for range lines {
+			theme := s.Detect()
+			if theme == last {
+				continue
+			}
+			last = theme
+
+			select {
+			case ch <- theme:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+func execLineStreamer(ctx context.Context, name string, args ...string) (<-chan string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer cmd.Wait()
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return lines, nil
+}
+
+func themeFromGtkName(name string) platform.Theme {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.HasSuffix(name, "-dark") || strings.HasSuffix(name, ":dark") {
+		return platform.ThemeDark
+	}
+	return platform.ThemeLight
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+type toolReporter interface {
+	Tool() string
+	Candidates() []platform.ToolCandidate
+}
+
+func findTool(lookPath func(string) (string, error), preferred string, order []string) (string, error) {
+	if preferred != "" {
+		if _, err := lookPath(preferred); err != nil {
+			return "", fmt.Errorf("%s not found in PATH", preferred)
+		}
+		return preferred, nil
+	}
+
+	for _, tool := range order {
+		if _, err := lookPath(tool); err == nil {
+			return tool, nil
+		}
+	}
+	return "", fmt.Errorf("no wallpaper tool found (install one of: %s)", strings.Join(order, ", "))
+}
+
+func toolCandidates(lookPath func(string) (string, error), order []string) []platform.ToolCandidate {
+	candidates := make([]platform.ToolCandidate, 0, len(order))
+	for _, tool := range order {
+		path, _ := lookPath(tool)
+		candidates = append(candidates, platform.ToolCandidate{Name: tool, Path: path})
+	}
+	return candidates
+}
@@ -0,0 +1,206 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+var wlrootsTools = []string{backendSwww, backendSwaybg}
+
+type WlrootsWallpaperService struct {
+	tool       string
+	transition platform.TransitionSettings
+	recordPath string
+
+	run      CommandRunner
+	lookPath func(string) (string, error)
+	spawn    func(name string, args ...string) (int, error)
+	kill     func(pid int) error
+	running  func(pid int, name string) bool
+	sleep    func(time.Duration)
+}
+
+func NewWlrootsWallpaperService(tool string, transition platform.TransitionSettings, run CommandRunner) *WlrootsWallpaperService {
+	return &WlrootsWallpaperService{
+		tool:       tool,
+		transition: transition,
+		recordPath: defaultRecordPath(),
+		run:        run,
+		lookPath:   exec.LookPath,
+		spawn:      spawnDetached,
+		kill:       killProcess,
+		running:    processRunning,
+		sleep:      time.Sleep,
+	}
+}
+
+func (s *WlrootsWallpaperService) Set(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	tool, err := s.resolveTool()
+	if err != nil {
+		return err
+	}
+
+	previous, _ := loadRecord(s.recordPath)
+	record := wallpaperRecord{Tool: tool, Path: absPath}
+
+	switch tool {
+	case backendSwww:
+		err = s.setSwww(absPath)
+	case backendSwaybg:
+		record.PID, err = s.spawn("swaybg", swaybgArgs(record)...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	s.stopPrevious(previous, record)
+
+	return saveRecord(s.recordPath, record)
+}
+
+func (s *WlrootsWallpaperService) Get() (string, error) {
+	record, err := loadRecord(s.recordPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	return record.current(), nil
+}
+
+func (s *WlrootsWallpaperService) Displays() ([]platform.Display, error) {
+	return waylandDisplays(s.run)
+}
+
+func (s *WlrootsWallpaperService) SetDisplay(display, path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	tool, err := s.resolveTool()
+	if err != nil {
+		return err
+	}
+
+	previous, _ := loadRecord(s.recordPath)
+	record := wallpaperRecord{Tool: tool}
+	if previous.Tool == tool {
+		record.Path = previous.Path
+		for name, p := range previous.Displays {
+			record.setDisplay(name, p)
+		}
+	}
+	record.setDisplay(display, absPath)
+
+	switch tool {
+	case backendSwww:
+		err = s.setSwww(absPath, "--outputs", display)
+	case backendSwaybg:
+		record.PID, err = s.spawn("swaybg", swaybgArgs(record)...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	s.stopPrevious(previous, record)
+
+	return saveRecord(s.recordPath, record)
+}
+
+func (s *WlrootsWallpaperService) GetDisplay(display string) (string, error) {
+	record, err := loadRecord(s.recordPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	return record.pathFor(display), nil
+}
+
+func (s *WlrootsWallpaperService) Tool() string {
+	tool, _ := s.resolveTool()
+	return tool
+}
+
+func (s *WlrootsWallpaperService) Candidates() []platform.ToolCandidate {
+	return toolCandidates(s.lookPath, wlrootsTools)
+}
+
+func (s *WlrootsWallpaperService) resolveTool() (string, error) {
+	return findTool(s.lookPath, s.tool, wlrootsTools)
+}
+
+func (s *WlrootsWallpaperService) stopPrevious(previous, record wallpaperRecord) {
+	if previous.Tool == backendSwaybg && previous.PID > 0 && previous.PID != record.PID && s.running(previous.PID, "swaybg") {
+		_ = s.kill(previous.PID)
+	}
+}
+
+func (s *WlrootsWallpaperService) setSwww(path string, extra ...string) error {
+	if _, err := s.run("swww", "query"); err != nil {
+		if _, err := s.spawn("swww-daemon"); err != nil {
+			return fmt.Errorf("failed to start swww-daemon: %w", err)
+		}
+		s.waitSwwwDaemon()
+	}
+
+	args := append([]string{"img", path}, extra...)
+	args = append(args, s.transitionArgs()...)
+	_, err := s.run("swww", args...)
+	return err
+}
+
+func (s *WlrootsWallpaperService) waitSwwwDaemon() {
+	for i := 0; i < 20; i++ {
+		s.sleep(100 * time.Millisecond)
+		if _, err := s.run("swww", "query"); err == nil {
+			return
+		}
+	}
+}
+
+func (s *WlrootsWallpaperService) transitionArgs() []string {
+	var args []string
+	if s.transition.Type != "" {
+		args = append(args, "--transition-type", s.transition.Type)
+	}
+	if s.transition.Duration > 0 {
+		args = append(args, "--transition-duration", strconv.FormatFloat(s.transition.Duration.Seconds(), 'f', -1, 64))
+	}
+	if s.transition.FPS > 0 {
+		args = append(args, "--transition-fps", strconv.Itoa(s.transition.FPS))
+	}
+	return args
+}
+
+func swaybgArgs(record wallpaperRecord) []string {
+	if len(record.Displays) == 0 {
+		return []string{"-i", record.Path, "-m", "fill"}
+	}
+
+	var args []string
+	if record.Path != "" {
+		args = append(args, "-o", "*", "-i", record.Path, "-m", "fill")
+	}
+
+	names := make([]string, 0, len(record.Displays))
+	for name := range record.Displays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		args = append(args, "-o", name, "-i", record.Displays[name], "-m", "fill")
+	}
+	return args
+}
@@ -0,0 +1,159 @@
+//go:build linux
+
+package linux
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProcesses struct {
+	nextPID int
+	spawned [][]string
+	killed  []int
+	alive   map[int]bool
+}
+
+func (f *fakeProcesses) spawn(name string, args ...string) (int, error) {
+	f.nextPID++
+	f.spawned = append(f.spawned, append([]string{name}, args...))
+	f.alive[f.nextPID] = true
+	return f.nextPID, nil
+}
+
+func (f *fakeProcesses) kill(pid int) error {
+	f.killed = append(f.killed, pid)
+	delete(f.alive, pid)
+	return nil
+}
+
+func (f *fakeProcesses) running(pid int, name string) bool {
+	return f.alive[pid]
+}
+
+func newTestWlroots(t *testing.T, tool string, available ...string) (*WlrootsWallpaperService, *fakeRunner, *fakeProcesses) {
+	runner := newFakeRunner()
+	procs := &fakeProcesses{nextPID: 100, alive: make(map[int]bool)}
+
+	svc := NewWlrootsWallpaperService(tool, platform.TransitionSettings{}, runner.run)
+	svc.recordPath = filepath.Join(t.TempDir(), "wallpaper.json")
+	svc.spawn = procs.spawn
+	svc.kill = procs.kill
+	svc.running = procs.running
+	svc.sleep = func(time.Duration) {}
+	svc.lookPath = func(name string) (string, error) {
+		for _, a := range available {
+			if a == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+
+	return svc, runner, procs
+}
+
+func TestWlrootsWallpaperService_Swaybg(t *testing.T) {
+	svc, _, procs := newTestWlroots(t, "", "swaybg")
+
+	require.NoError(t, svc.Set("/tmp/one.jpg"))
+	require.NoError(t, svc.Set("/tmp/two.jpg"))
+
+	assert.Equal(t, [][]string{
+		{"swaybg", "-i", "/tmp/one.jpg", "-m", "fill"},
+		{"swaybg", "-i", "/tmp/two.jpg", "-m", "fill"},
+	}, procs.spawned)
+	assert.Equal(t, []int{101}, procs.killed)
+
+	path, err := svc.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/two.jpg", path)
+}
+
+func TestWlrootsWallpaperService_SwwwWithTransition(t *testing.T) {
+	svc, runner, procs := newTestWlroots(t, "", "swww", "swaybg")
+	svc.transition = platform.TransitionSettings{Type: "grow", Duration: 1500 * time.Millisecond, FPS: 60}
+
+	require.NoError(t, svc.Set("/tmp/one.jpg"))
+
+	assert.Empty(t, procs.spawned)
+	assert.Equal(t, []string{"swww", "img", "/tmp/one.jpg", "--transition-type", "grow", "--transition-duration", "1.5", "--transition-fps", "60"}, runner.calls[len(runner.calls)-1])
+
+	path, err := svc.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/one.jpg", path)
+}
+
+func TestWlrootsWallpaperService_SwwwStartsDaemon(t *testing.T) {
+	svc, runner, procs := newTestWlroots(t, "swww", "swww")
+	runner.errs["swww query"] = errors.New("daemon not running")
+
+	require.NoError(t, svc.Set("/tmp/one.jpg"))
+
+	assert.Equal(t, [][]string{{"swww-daemon"}}, procs.spawned)
+	assert.Equal(t, []string{"swww", "img", "/tmp/one.jpg"}, runner.calls[len(runner.calls)-1])
+}
+
+func TestWlrootsWallpaperService_SwitchFromSwaybgToSwww(t *testing.T) {
+	svc, _, procs := newTestWlroots(t, "swaybg", "swww", "swaybg")
+	require.NoError(t, svc.Set("/tmp/one.jpg"))
+
+	svc.tool = "swww"
+	require.NoError(t, svc.Set("/tmp/two.jpg"))
+
+	assert.Equal(t, []int{101}, procs.killed)
+}
+
+func TestWlrootsWallpaperService_NoTool(t *testing.T) {
+	svc, _, _ := newTestWlroots(t, "")
+
+	err := svc.Set("/tmp/one.jpg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "install one of: swww, swaybg")
+
+	path, err := svc.Get()
+	require.NoError(t, err)
+	assert.Empty(t, path)
+}
+
+func TestWlrootsWallpaperService_SwaybgPerDisplay(t *testing.T) {
+	svc, _, procs := newTestWlroots(t, "swaybg", "swaybg")
+
+	require.NoError(t, svc.Set("/tmp/all.jpg"))
+	require.NoError(t, svc.SetDisplay("HDMI-A-1", "/tmp/b.jpg"))
+	require.NoError(t, svc.SetDisplay("DP-1", "/tmp/a.jpg"))
+
+	assert.Equal(t, []string{
+		"swaybg",
+		"-o", "*", "-i", "/tmp/all.jpg", "-m", "fill",
+		"-o", "DP-1", "-i", "/tmp/a.jpg", "-m", "fill",
+		"-o", "HDMI-A-1", "-i", "/tmp/b.jpg", "-m", "fill",
+	}, procs.spawned[len(procs.spawned)-1])
+	assert.Equal(t, []int{101, 102}, procs.killed)
+
+	path, err := svc.GetDisplay("DP-1")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/a.jpg", path)
+
+	path, err = svc.GetDisplay("eDP-1")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/all.jpg", path)
+}
+
+func TestWlrootsWallpaperService_SwwwPerDisplay(t *testing.T) {
+	svc, runner, _ := newTestWlroots(t, "swww", "swww")
+
+	require.NoError(t, svc.SetDisplay("DP-1", "/tmp/a.jpg"))
+
+	assert.Equal(t, []string{"swww", "img", "/tmp/a.jpg", "--outputs", "DP-1"}, runner.calls[len(runner.calls)-1])
+
+	path, err := svc.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/a.jpg", path)
+}
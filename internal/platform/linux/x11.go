@@ -0,0 +1,216 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+const (
+	scaleFill   = "fill"
+	scaleFit    = "fit"
+	scaleCenter = "center"
+	scaleTile   = "tile"
+)
+
+var x11Tools = []string{backendFeh, backendXwallpaper, backendNitrogen, backendHsetroot}
+
+var x11ScaleFlags = map[string]map[string]string{
+	backendFeh: {
+		scaleFill:   "--bg-fill",
+		scaleFit:    "--bg-max",
+		scaleCenter: "--bg-center",
+		scaleTile:   "--bg-tile",
+	},
+	backendXwallpaper: {
+		scaleFill:   "--zoom",
+		scaleFit:    "--maximize",
+		scaleCenter: "--center",
+		scaleTile:   "--tile",
+	},
+	backendNitrogen: {
+		scaleFill:   "--set-zoom-fill",
+		scaleFit:    "--set-zoom",
+		scaleCenter: "--set-centered",
+		scaleTile:   "--set-tiled",
+	},
+	backendHsetroot: {
+		scaleFill:   "-fill",
+		scaleFit:    "-full",
+		scaleCenter: "-center",
+		scaleTile:   "-tile",
+	},
+}
+
+type X11WallpaperService struct {
+	tool       string
+	mode       string
+	recordPath string
+
+	run      CommandRunner
+	lookPath func(string) (string, error)
+}
+
+func NewX11WallpaperService(tool, mode string, run CommandRunner) *X11WallpaperService {
+	if mode == "" {
+		mode = scaleFill
+	}
+	return &X11WallpaperService{
+		tool:       tool,
+		mode:       mode,
+		recordPath: defaultRecordPath(),
+		run:        run,
+		lookPath:   exec.LookPath,
+	}
+}
+
+func (s *X11WallpaperService) Set(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	tool, err := findTool(s.lookPath, s.tool, x11Tools)
+	if err != nil {
+		return err
+	}
+
+	args, err := x11Command(tool, s.mode, absPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.run(tool, args...); err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	return saveRecord(s.recordPath, wallpaperRecord{Tool: tool, Path: absPath})
+}
+
+func (s *X11WallpaperService) Get() (string, error) {
+	record, err := loadRecord(s.recordPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	return record.current(), nil
+}
+
+func (s *X11WallpaperService) Displays() ([]platform.Display, error) {
+	return x11Displays(s.run)
+}
+
+func (s *X11WallpaperService) SetDisplay(display, path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	tool, err := findTool(s.lookPath, s.tool, x11Tools)
+	if err != nil {
+		return err
+	}
+
+	displays, err := x11Displays(s.run)
+	if err != nil {
+		return err
+	}
+	index := displayIndex(displays, display)
+	if index == -1 {
+		return fmt.Errorf("unknown display: %s", display)
+	}
+
+	record, _ := loadRecord(s.recordPath)
+	record.Tool = tool
+	record.setDisplay(display, absPath)
+
+	args, err := x11DisplayCommand(tool, s.mode, displays, index, record)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.run(tool, args...); err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	return saveRecord(s.recordPath, record)
+}
+
+func (s *X11WallpaperService) GetDisplay(display string) (string, error) {
+	record, err := loadRecord(s.recordPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallpaper: %w", err)
+	}
+	return record.pathFor(display), nil
+}
+
+func (s *X11WallpaperService) Tool() string {
+	tool, _ := findTool(s.lookPath, s.tool, x11Tools)
+	return tool
+}
+
+func (s *X11WallpaperService) Candidates() []platform.ToolCandidate {
+	return toolCandidates(s.lookPath, x11Tools)
+}
+
+func x11Command(tool, mode, path string) ([]string, error) {
+	flag, err := x11ScaleFlag(tool, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if tool == backendNitrogen {
+		return []string{flag, "--save", path}, nil
+	}
+	return []string{flag, path}, nil
+}
+
+func x11DisplayCommand(tool, mode string, displays []platform.Display, index int, record wallpaperRecord) ([]string, error) {
+	flag, err := x11ScaleFlag(tool, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	target := record.pathFor(displays[index].Name)
+
+	switch tool {
+	case backendFeh:
+		args := []string{flag}
+		for _, d := range displays {
+			path := record.pathFor(d.Name)
+			if path == "" {
+				path = target
+			}
+			args = append(args, path)
+		}
+		return args, nil
+	case backendXwallpaper:
+		var args []string
+		for _, d := range displays {
+			if path := record.pathFor(d.Name); path != "" {
+				args = append(args, "--output", d.Name, flag, path)
+			}
+		}
+		return args, nil
+	case backendNitrogen:
+		return []string{"--head=" + strconv.Itoa(index), flag, "--save", target}, nil
+	default:
+		return nil, fmt.Errorf("%s does not support per-display wallpapers", tool)
+	}
+}
+
+func x11ScaleFlag(tool, mode string) (string, error) {
+	flags, ok := x11ScaleFlags[tool]
+	if !ok {
+		return "", fmt.Errorf("unsupported X11 wallpaper tool: %s", tool)
+	}
+	flag, ok := flags[mode]
+	if !ok {
+		return "", fmt.Errorf("unsupported scaling mode: %s", mode)
+	}
+	return flag, nil
+}
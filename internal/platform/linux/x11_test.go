@@ -0,0 +1,168 @@
+//go:build linux
+
+package linux
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestX11(t *testing.T, tool, mode string, available ...string) (*X11WallpaperService, *fakeRunner) {
+	runner := newFakeRunner()
+	svc := NewX11WallpaperService(tool, mode, runner.run)
+	svc.recordPath = filepath.Join(t.TempDir(), "wallpaper.json")
+	svc.lookPath = func(name string) (string, error) {
+		for _, a := range available {
+			if a == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	return svc, runner
+}
+
+func TestX11WallpaperService_DetectionOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []string
+		expected  string
+	}{
+		{"feh preferred", []string{"hsetroot", "xwallpaper", "feh"}, "feh"},
+		{"xwallpaper before nitrogen", []string{"nitrogen", "xwallpaper"}, "xwallpaper"},
+		{"nitrogen before hsetroot", []string{"hsetroot", "nitrogen"}, "nitrogen"},
+		{"hsetroot last", []string{"hsetroot"}, "hsetroot"},
+		{"nothing", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestX11(t, "", "", tt.available...)
+			assert.Equal(t, tt.expected, svc.Tool())
+		})
+	}
+}
+
+func TestX11WallpaperService_Set(t *testing.T) {
+	tests := []struct {
+		tool     string
+		mode     string
+		expected []string
+	}{
+		{"feh", "", []string{"feh", "--bg-fill", "/tmp/a.jpg"}},
+		{"feh", "tile", []string{"feh", "--bg-tile", "/tmp/a.jpg"}},
+		{"xwallpaper", "fit", []string{"xwallpaper", "--maximize", "/tmp/a.jpg"}},
+		{"nitrogen", "center", []string{"nitrogen", "--set-centered", "--save", "/tmp/a.jpg"}},
+		{"hsetroot", "fill", []string{"hsetroot", "-fill", "/tmp/a.jpg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool+"_"+tt.mode, func(t *testing.T) {
+			svc, runner := newTestX11(t, tt.tool, tt.mode, tt.tool)
+
+			require.NoError(t, svc.Set("/tmp/a.jpg"))
+			assert.Equal(t, [][]string{tt.expected}, runner.calls)
+
+			path, err := svc.Get()
+			require.NoError(t, err)
+			assert.Equal(t, "/tmp/a.jpg", path)
+		})
+	}
+}
+
+func TestX11WallpaperService_Errors(t *testing.T) {
+	svc, _ := newTestX11(t, "feh", "fill")
+	err := svc.Set("/tmp/a.jpg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "feh not found")
+
+	svc, _ = newTestX11(t, "", "stretch", "feh")
+	err = svc.Set("/tmp/a.jpg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported scaling mode")
+}
+
+func TestX11WallpaperService_Candidates(t *testing.T) {
+	svc, _ := newTestX11(t, "", "", "nitrogen")
+
+	candidates := svc.Candidates()
+	require.Len(t, candidates, 4)
+	assert.Equal(t, "feh", candidates[0].Name)
+	assert.False(t, candidates[0].Available())
+	assert.Equal(t, "nitrogen", candidates[2].Name)
+	assert.Equal(t, "/usr/bin/nitrogen", candidates[2].Path)
+}
+
+func TestX11WallpaperService_SetDisplay(t *testing.T) {
+	tests := []struct {
+		tool     string
+		expected [][]string
+	}{
+		{"feh", [][]string{
+			{"feh", "--bg-fill", "/tmp/a.jpg", "/tmp/a.jpg"},
+			{"feh", "--bg-fill", "/tmp/a.jpg", "/tmp/b.jpg"},
+		}},
+		{"xwallpaper", [][]string{
+			{"xwallpaper", "--output", "DP-1", "--zoom", "/tmp/a.jpg"},
+			{"xwallpaper", "--output", "DP-1", "--zoom", "/tmp/a.jpg", "--output", "HDMI-1", "--zoom", "/tmp/b.jpg"},
+		}},
+		{"nitrogen", [][]string{
+			{"nitrogen", "--head=0", "--set-zoom-fill", "--save", "/tmp/a.jpg"},
+			{"nitrogen", "--head=1", "--set-zoom-fill", "--save", "/tmp/b.jpg"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			svc, runner := newTestX11(t, tt.tool, "", tt.tool)
+			runner.outputs["xrandr --listmonitors"] = xrandrTwoMonitors
+
+			require.NoError(t, svc.SetDisplay("DP-1", "/tmp/a.jpg"))
+			require.NoError(t, svc.SetDisplay("HDMI-1", "/tmp/b.jpg"))
+
+			var calls [][]string
+			for _, call := range runner.calls {
+				if call[0] == tt.tool {
+					calls = append(calls, call)
+				}
+			}
+			assert.Equal(t, tt.expected, calls)
+
+			path, err := svc.GetDisplay("HDMI-1")
+			require.NoError(t, err)
+			assert.Equal(t, "/tmp/b.jpg", path)
+		})
+	}
+}
+
+func TestX11WallpaperService_SetDisplayErrors(t *testing.T) {
+	svc, runner := newTestX11(t, "feh", "", "feh")
+	runner.outputs["xrandr --listmonitors"] = xrandrTwoMonitors
+
+	err := svc.SetDisplay("VGA-1", "/tmp/a.jpg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown display")
+
+	svc, runner = newTestX11(t, "hsetroot", "", "hsetroot")
+	runner.outputs["xrandr --listmonitors"] = xrandrTwoMonitors
+
+	err = svc.SetDisplay("DP-1", "/tmp/a.jpg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support per-display")
+}
+
+func TestX11WallpaperService_SetClearsDisplays(t *testing.T) {
+	svc, runner := newTestX11(t, "xwallpaper", "", "xwallpaper")
+	runner.outputs["xrandr --listmonitors"] = xrandrTwoMonitors
+
+	require.NoError(t, svc.SetDisplay("HDMI-1", "/tmp/b.jpg"))
+	require.NoError(t, svc.Set("/tmp/c.jpg"))
+
+	path, err := svc.GetDisplay("HDMI-1")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/c.jpg", path)
+}
@@ -23,6 +23,22 @@ type WallpaperService interface {
 	Get() (string, error)
 }
 
+type DisplayWallpaperService interface {
+	WallpaperService
+	Displays() ([]Display, error)
+	SetDisplay(display, path string) error
+	GetDisplay(display string) (string, error)
+}
+
+type Display struct {
+	Name    string
+	Primary bool
+	X       int
+	Y       int
+	Width   int
+	Height  int
+}
+
 type ThemeService interface {
 	Detect() Theme
 }
@@ -40,16 +56,53 @@ type SchedulerConfig struct {
 	Args      []string
 	Interval  time.Duration
 	RunAtLoad bool
+	KeepAlive bool
 	LogPath   string
 }
 
 type SchedulerStatus struct {
 	Installed bool
 	Running   bool
+	KeepAlive bool
 	Interval  time.Duration
 	LogPath   string
 }
 
+type Configurable interface {
+	Configure(settings Settings) error
+}
+
+type Settings struct {
+	Backend    string
+	Mode       string
+	Transition TransitionSettings
+}
+
+type TransitionSettings struct {
+	Type     string
+	Duration time.Duration
+	FPS      int
+}
+
+type BackendReporter interface {
+	BackendInfo() BackendInfo
+}
+
+type BackendInfo struct {
+	Backend    string
+	Tool       string
+	Candidates []ToolCandidate
+}
+
+type ToolCandidate struct {
+	Name string
+	Path string
+}
+
+func (c ToolCandidate) Available() bool {
+	return c.Path != ""
+}
+
 type FileManagerService interface {
 	Reveal(path string) error
 	Open(path string) error
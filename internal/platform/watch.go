@@ -0,0 +1,53 @@
+package platform
+
+import (
+	"context"
+	"time"
+)
+
+const DefaultThemePollInterval = 5 * time.Second
+
+type ThemeWatcher interface {
+	Watch(ctx context.Context) <-chan Theme
+}
+
+func WatchTheme(ctx context.Context, svc ThemeService, interval time.Duration) <-chan Theme {
+	if watcher, ok := svc.(ThemeWatcher); ok {
+		return watcher.Watch(ctx)
+	}
+	return PollTheme(ctx, svc, interval)
+}
+
+func PollTheme(ctx context.Context, svc ThemeService, interval time.Duration) <-chan Theme {
+	last := svc.Detect()
+	ch := make(chan Theme)
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			theme := svc.Detect()
+			if theme == last {
+				continue
+			}
+			last = theme
+
+			select {
+			case ch <- theme:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,64 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sequenceTheme struct {
+	mu     sync.Mutex
+	themes []Theme
+}
+
+func (s *sequenceTheme) Detect() Theme {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	theme := s.themes[0]
+	if len(s.themes) > 1 {
+		s.themes = s.themes[1:]
+	}
+	return theme
+}
+
+type watchingTheme struct {
+	sequenceTheme
+	ch chan Theme
+}
+
+func (w *watchingTheme) Watch(ctx context.Context) <-chan Theme { return w.ch }
+
+func TestPollTheme(t *testing.T) {
+	svc := &sequenceTheme{themes: []Theme{ThemeLight, ThemeLight, ThemeDark, ThemeDark, ThemeLight}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := PollTheme(ctx, svc, time.Millisecond)
+
+	var got []Theme
+	for len(got) < 2 {
+		select {
+		case theme := <-ch:
+			got = append(got, theme)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for theme change")
+		}
+	}
+	assert.Equal(t, []Theme{ThemeDark, ThemeLight}, got)
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchTheme_PrefersWatcher(t *testing.T) {
+	svc := &watchingTheme{ch: make(chan Theme, 1)}
+	svc.ch <- ThemeDark
+
+	ch := WatchTheme(context.Background(), svc, time.Hour)
+	require.Equal(t, ThemeDark, <-ch)
+}
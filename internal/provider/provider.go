@@ -76,6 +76,8 @@ func (p *BaseProvider) downloadFile(ctx context.Context, url, dest string) (stri
 	return dest, nil
 }
 
+type Factory func(name, auth string) Provider
+
 func NewProvider(providerType string, auth string, urls []string) Provider {
 	switch providerType {
 	case "unsplash":
@@ -0,0 +1,209 @@
+package span
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/platform"
+	_ "golang.org/x/image/webp"
+)
+
+const DefaultTolerance = 0.15
+
+type Layout struct {
+	X        int
+	Y        int
+	Width    int
+	Height   int
+	Displays []platform.Display
+}
+
+type Crop struct {
+	Display string
+	Path    string
+}
+
+func NewLayout(displays []platform.Display) (*Layout, error) {
+	if len(displays) == 0 {
+		return nil, fmt.Errorf("no displays found")
+	}
+
+	arranged := make([]platform.Display, len(displays))
+	copy(arranged, displays)
+
+	for _, d := range arranged {
+		if d.Width <= 0 || d.Height <= 0 {
+			return nil, fmt.Errorf("unknown resolution for display %s", d.Name)
+		}
+	}
+
+	if len(arranged) > 1 && samePosition(arranged) {
+		x := 0
+		for i := range arranged {
+			arranged[i].X, arranged[i].Y = x, 0
+			x += arranged[i].Width
+		}
+	}
+
+	minX, minY := arranged[0].X, arranged[0].Y
+	maxX, maxY := arranged[0].X+arranged[0].Width, arranged[0].Y+arranged[0].Height
+	for _, d := range arranged[1:] {
+		minX = min(minX, d.X)
+		minY = min(minY, d.Y)
+		maxX = max(maxX, d.X+d.Width)
+		maxY = max(maxY, d.Y+d.Height)
+	}
+
+	return &Layout{
+		X:        minX,
+		Y:        minY,
+		Width:    maxX - minX,
+		Height:   maxY - minY,
+		Displays: arranged,
+	}, nil
+}
+
+func (l *Layout) AspectRatio() float64 {
+	return float64(l.Width) / float64(l.Height)
+}
+
+func (l *Layout) Matches(width, height int, tolerance float64) bool {
+	if width <= 0 || height <= 0 {
+		return false
+	}
+	ratio := float64(width) / float64(height)
+	return math.Abs(ratio-l.AspectRatio())/l.AspectRatio() <= tolerance
+}
+
+func (l *Layout) MatchesFile(path string, tolerance float64) bool {
+	width, height, err := ImageSize(path)
+	if err != nil {
+		return false
+	}
+	return l.Matches(width, height, tolerance)
+}
+
+func (l *Layout) Slice(srcPath, outDir string) ([]Crop, error) {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create span directory: %w", err)
+	}
+	if old, err := filepath.Glob(filepath.Join(outDir, "*.jpg")); err == nil {
+		for _, path := range old {
+			os.Remove(path)
+		}
+	}
+
+	stamp := time.Now().UnixNano()
+	crops := make([]Crop, 0, len(l.Displays))
+
+	for _, d := range l.Displays {
+		rect := l.cropRect(img.Bounds(), d)
+		path := filepath.Join(outDir, fmt.Sprintf("%d-%s.jpg", stamp, safeName(d.Name)))
+
+		if err := writeJPEG(path, subImage(img, rect)); err != nil {
+			return nil, fmt.Errorf("failed to write crop for %s: %w", d.Name, err)
+		}
+		crops = append(crops, Crop{Display: d.Name, Path: path})
+	}
+
+	return crops, nil
+}
+
+func (l *Layout) cropRect(bounds image.Rectangle, d platform.Display) image.Rectangle {
+	imgW, imgH := float64(bounds.Dx()), float64(bounds.Dy())
+	scale := math.Max(float64(l.Width)/imgW, float64(l.Height)/imgH)
+
+	offsetX := (imgW*scale - float64(l.Width)) / 2
+	offsetY := (imgH*scale - float64(l.Height)) / 2
+
+	x0 := (float64(d.X-l.X) + offsetX) / scale
+	y0 := (float64(d.Y-l.Y) + offsetY) / scale
+	x1 := x0 + float64(d.Width)/scale
+	y1 := y0 + float64(d.Height)/scale
+
+	rect := image.Rect(
+		bounds.Min.X+int(math.Round(x0)),
+		bounds.Min.Y+int(math.Round(y0)),
+		bounds.Min.X+int(math.Round(x1)),
+		bounds.Min.Y+int(math.Round(y1)),
+	)
+	return rect.Intersect(bounds)
+}
+
+func ImageSize(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+func subImage(img image.Image, rect image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
+
+func writeJPEG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 95}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func samePosition(displays []platform.Display) bool {
+	for _, d := range displays[1:] {
+		if d.X != displays[0].X || d.Y != displays[0].Y {
+			return false
+		}
+	}
+	return true
+}
+
+func safeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,141 @@
+package span
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Artawower/wallboy/internal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSplitPNG(t *testing.T, width, height int) string {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "wide.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+	return path
+}
+
+func TestNewLayout(t *testing.T) {
+	t.Run("bounding box", func(t *testing.T) {
+		layout, err := NewLayout([]platform.Display{
+			{Name: "DP-1", X: 0, Y: 0, Width: 2560, Height: 1440},
+			{Name: "HDMI-1", X: 2560, Y: 180, Width: 1920, Height: 1080},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, layout.X)
+		assert.Equal(t, 4480, layout.Width)
+		assert.Equal(t, 1440, layout.Height)
+	})
+
+	t.Run("unknown positions are laid out left to right", func(t *testing.T) {
+		layout, err := NewLayout([]platform.Display{
+			{Name: "A", Width: 1920, Height: 1080},
+			{Name: "B", Width: 1920, Height: 1080},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3840, layout.Width)
+		assert.Equal(t, 1920, layout.Displays[1].X)
+		assert.InDelta(t, 32.0/9.0, layout.AspectRatio(), 0.001)
+	})
+
+	t.Run("missing resolution", func(t *testing.T) {
+		_, err := NewLayout([]platform.Display{{Name: "A"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown resolution for display A")
+	})
+
+	t.Run("no displays", func(t *testing.T) {
+		_, err := NewLayout(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestLayout_Matches(t *testing.T) {
+	layout, err := NewLayout([]platform.Display{
+		{Name: "A", Width: 1920, Height: 1080},
+		{Name: "B", X: 1920, Width: 1920, Height: 1080},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, layout.Matches(5120, 1440, DefaultTolerance))
+	assert.True(t, layout.Matches(3840, 1080, DefaultTolerance))
+	assert.False(t, layout.Matches(1920, 1080, DefaultTolerance))
+	assert.False(t, layout.Matches(0, 0, DefaultTolerance))
+}
+
+func TestLayout_Slice(t *testing.T) {
+	src := writeSplitPNG(t, 400, 100)
+	outDir := filepath.Join(t.TempDir(), "span")
+
+	layout, err := NewLayout([]platform.Display{
+		{Name: "DP-1", X: 0, Y: 0, Width: 1920, Height: 1080},
+		{Name: "HDMI-A/1", X: 1920, Y: 0, Width: 1920, Height: 1080},
+	})
+	require.NoError(t, err)
+	assert.True(t, layout.MatchesFile(src, DefaultTolerance))
+
+	crops, err := layout.Slice(src, outDir)
+	require.NoError(t, err)
+	require.Len(t, crops, 2)
+
+	assert.Equal(t, "DP-1", crops[0].Display)
+	assert.Equal(t, "HDMI-A/1", crops[1].Display)
+	assert.Contains(t, filepath.Base(crops[1].Path), "HDMI-A_1")
+
+	for i, crop := range crops {
+		width, height, err := ImageSize(crop.Path)
+		require.NoError(t, err)
+		assert.InDelta(t, 16.0/9.0, float64(width)/float64(height), 0.05)
+
+		f, err := os.Open(crop.Path)
+		require.NoError(t, err)
+		img, _, err := image.Decode(f)
+		f.Close()
+		require.NoError(t, err)
+
+		r, _, b, _ := img.At(width/2, height/2).RGBA()
+		if i == 0 {
+			assert.Greater(t, r, b)
+		} else {
+			assert.Greater(t, b, r)
+		}
+	}
+
+	again, err := layout.Slice(src, outDir)
+	require.NoError(t, err)
+	files, err := filepath.Glob(filepath.Join(outDir, "*.jpg"))
+	require.NoError(t, err)
+	assert.Len(t, files, len(again))
+}
+
+func TestLayout_cropRect(t *testing.T) {
+	layout, err := NewLayout([]platform.Display{
+		{Name: "A", Width: 1000, Height: 500},
+		{Name: "B", X: 1000, Width: 1000, Height: 500},
+	})
+	require.NoError(t, err)
+
+	bounds := image.Rect(0, 0, 4000, 1500)
+	assert.Equal(t, image.Rect(0, 250, 2000, 1250), layout.cropRect(bounds, layout.Displays[0]))
+	assert.Equal(t, image.Rect(2000, 250, 4000, 1250), layout.cropRect(bounds, layout.Displays[1]))
+}
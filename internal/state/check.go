@@ -0,0 +1,140 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+type Issue struct {
+	Field string `json:"field"`
+	Key   string `json:"key,omitempty"`
+	Path  string `json:"path"`
+}
+
+func (s *State) Check() []Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dangling()
+}
+
+func (s *State) Repair() []Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issues := s.dangling()
+	if len(issues) > 0 {
+		s.update(func(s *State) {
+			s.dropDangling()
+		})
+	}
+	return issues
+}
+
+func (s *State) dangling() []Issue {
+	var issues []Issue
+	add := func(field, key, path string) {
+		if path != "" && missing(path) {
+			issues = append(issues, Issue{Field: field, Key: key, Path: path})
+		}
+	}
+
+	add("current", "", s.Current.Path)
+	for _, name := range sortedKeys(s.Displays) {
+		add("displays", name, s.Displays[name].Path)
+	}
+	for _, theme := range sortedKeys(s.Themes) {
+		add("themes", theme, s.Themes[theme].Path)
+	}
+	for i, current := range s.Stack {
+		add("stack", strconv.Itoa(i), current.Path)
+	}
+	for _, path := range s.History {
+		add("history", "", path)
+	}
+	for _, id := range sortedKeys(s.Prefetched) {
+		if entry := s.Prefetched[id]; entry != nil {
+			add("prefetched", id, entry.Path)
+		}
+	}
+	for _, key := range sortedKeys(s.Ratings) {
+		if filepath.IsAbs(key) {
+			add("ratings", "", key)
+		}
+	}
+	for _, key := range sortedKeys(s.Bans) {
+		if filepath.IsAbs(key) {
+			add("bans", "", key)
+		}
+	}
+	return issues
+}
+
+func (s *State) dropDangling() {
+	if s.Current.Path != "" && missing(s.Current.Path) {
+		s.Current = CurrentWallpaper{}
+	}
+	for name, current := range s.Displays {
+		if missing(current.Path) {
+			delete(s.Displays, name)
+		}
+	}
+	for theme, current := range s.Themes {
+		if missing(current.Path) {
+			delete(s.Themes, theme)
+		}
+	}
+
+	stack := s.Stack[:0]
+	cursor := 0
+	for i, current := range s.Stack {
+		if missing(current.Path) {
+			continue
+		}
+		if i <= s.Cursor {
+			cursor = len(stack)
+		}
+		stack = append(stack, current)
+	}
+	s.Stack = stack
+	s.Cursor = cursor
+
+	history := []string{}
+	for _, path := range s.History {
+		if !missing(path) {
+			history = append(history, path)
+		}
+	}
+	s.History = history
+
+	for id, entry := range s.Prefetched {
+		if entry == nil || missing(entry.Path) {
+			delete(s.Prefetched, id)
+		}
+	}
+	for key := range s.Ratings {
+		if filepath.IsAbs(key) && missing(key) {
+			delete(s.Ratings, key)
+		}
+	}
+	for key := range s.Bans {
+		if filepath.IsAbs(key) && missing(key) {
+			delete(s.Bans, key)
+		}
+	}
+}
+
+func missing(path string) bool {
+	_, err := os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAndRepair(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.jpg")
+	gone := filepath.Join(dir, "gone.jpg")
+	lost := filepath.Join(dir, "lost.jpg")
+	require.NoError(t, os.WriteFile(kept, []byte("image"), 0644))
+
+	path := filepath.Join(dir, "state.json")
+	s, err := Load(path)
+	require.NoError(t, err)
+	s.SetCurrent(gone, "dark-local", "dark", "", "", false)
+	s.SetCurrent(kept, "dark-local", "light", "", "", false)
+	s.SetCurrent(lost, "dark-local", "dark", "", "", false)
+	s.SetPrefetch("dark-bing", gone, "", "")
+	s.SetRating(gone, 5)
+	s.SetRating(kept, 4)
+	s.SetRating("wallhaven:abc", 2)
+	s.Ban(lost)
+	require.NoError(t, s.Save())
+
+	issues := s.Check()
+	assert.Equal(t, []Issue{
+		{Field: "current", Path: lost},
+		{Field: "themes", Key: "dark", Path: lost},
+		{Field: "stack", Key: "0", Path: gone},
+		{Field: "stack", Key: "2", Path: lost},
+		{Field: "history", Path: gone},
+		{Field: "prefetched", Key: "dark-bing", Path: gone},
+		{Field: "ratings", Path: gone},
+		{Field: "bans", Path: lost},
+	}, issues)
+
+	assert.Equal(t, issues, s.Repair())
+	require.NoError(t, s.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Check())
+	assert.False(t, loaded.HasCurrent())
+	require.Len(t, loaded.Stack, 1)
+	assert.Equal(t, kept, loaded.Stack[0].Path)
+	assert.Equal(t, 0, loaded.Cursor)
+	assert.Equal(t, []string{kept}, loaded.History)
+	assert.Equal(t, map[string]int{kept: 4, "wallhaven:abc": 2}, loaded.Ratings)
+	assert.Empty(t, loaded.Bans)
+	assert.Empty(t, loaded.Prefetched)
+	assert.Contains(t, loaded.Themes, "light")
+	assert.NotContains(t, loaded.Themes, "dark")
+}
+
+func TestRepair_Clean(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "state.json"))
+	assert.Empty(t, s.Check())
+	assert.Empty(t, s.Repair())
+}
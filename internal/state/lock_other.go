@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package state
+
+import "os"
+
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
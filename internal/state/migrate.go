@@ -0,0 +1,72 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const CurrentVersion = 2
+
+type document map[string]json.RawMessage
+
+var migrations = []func(document) error{
+	migrateV1,
+}
+
+type legacyPrefetchEntry struct {
+	Path      string    `json:"path"`
+	SourceID  string    `json:"source_id"`
+	CacheKey  string    `json:"cache_key"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+func migrate(doc document) (int, error) {
+	version := 1
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return 0, fmt.Errorf("failed to parse state version: %w", err)
+		}
+	}
+	if version < 1 {
+		return 0, fmt.Errorf("invalid state version: %d", version)
+	}
+	if version > CurrentVersion {
+		return 0, fmt.Errorf("state version %d is newer than supported version %d", version, CurrentVersion)
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v-1](doc); err != nil {
+			return 0, fmt.Errorf("failed to migrate state from version %d: %w", v, err)
+		}
+	}
+	doc["version"] = json.RawMessage(fmt.Sprint(CurrentVersion))
+	return version, nil
+}
+
+func migrateV1(doc document) error {
+	raw, ok := doc["prefetched"]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+
+	var entries map[string]*PrefetchEntry
+	if err := json.Unmarshal(raw, &entries); err == nil {
+		return nil
+	}
+
+	delete(doc, "prefetched")
+	var old legacyPrefetchEntry
+	if err := json.Unmarshal(raw, &old); err != nil || old.Path == "" {
+		return nil
+	}
+
+	converted, err := json.Marshal(map[string]PrefetchEntry{
+		old.SourceID: {Path: old.Path, FetchedAt: old.FetchedAt},
+	})
+	if err != nil {
+		return err
+	}
+	doc["prefetched"] = converted
+	return nil
+}
@@ -0,0 +1,138 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDocument(t *testing.T, data string) document {
+	t.Helper()
+	var doc document
+	require.NoError(t, json.Unmarshal([]byte(data), &doc))
+	return doc
+}
+
+func TestMigrations_CoverEveryVersion(t *testing.T) {
+	assert.Len(t, migrations, CurrentVersion-1)
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantVersion int
+		errContains string
+	}{
+		{name: "missing version is v1", data: `{"theme":"dark"}`, wantVersion: 1},
+		{name: "current version", data: `{"version":2,"theme":"dark"}`, wantVersion: 2},
+		{name: "newer version", data: `{"version":99}`, errContains: "newer than supported"},
+		{name: "invalid version", data: `{"version":0}`, errContains: "invalid state version"},
+		{name: "malformed version", data: `{"version":"two"}`, errContains: "failed to parse state version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, tt.data)
+			version, err := migrate(doc)
+			if tt.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, version)
+			assert.JSONEq(t, "2", string(doc["version"]))
+		})
+	}
+}
+
+func TestMigrateV1(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "single entry becomes per-source map",
+			data: `{"prefetched":{"path":"/tmp/a.jpg","source_id":"dark-bing","cache_key":"dark:bing:","fetched_at":"2024-01-01T00:00:00Z"}}`,
+			want: `{"dark-bing":{"path":"/tmp/a.jpg","fetched_at":"2024-01-01T00:00:00Z"}}`,
+		},
+		{
+			name: "per-source map is kept",
+			data: `{"prefetched":{"dark-bing":{"path":"/tmp/a.jpg","fetched_at":"2024-01-01T00:00:00Z"}}}`,
+			want: `{"dark-bing":{"path":"/tmp/a.jpg","fetched_at":"2024-01-01T00:00:00Z"}}`,
+		},
+		{
+			name: "null is kept",
+			data: `{"prefetched":null}`,
+			want: `null`,
+		},
+		{
+			name: "unreadable entry is dropped",
+			data: `{"prefetched":{"path":""}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, tt.data)
+			require.NoError(t, migrateV1(doc))
+			if tt.want == "" {
+				assert.NotContains(t, doc, "prefetched")
+				return
+			}
+			assert.JSONEq(t, tt.want, string(doc["prefetched"]))
+		})
+	}
+}
+
+func TestLoad_MigratesAndBacksUp(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	original, err := os.ReadFile("testdata/v1.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, original, 0644))
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentVersion, s.Version)
+	assert.Equal(t, "/tmp/wallpaper.jpg", s.Current.Path)
+	assert.Equal(t, []string{"/home/user/Pictures/old1.jpg"}, s.History)
+	require.Contains(t, s.Prefetched, "dark-bing")
+	assert.Equal(t, "/tmp/prefetch.jpg", s.Prefetched["dark-bing"].Path)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), s.Prefetched["dark-bing"].FetchedAt)
+
+	backup := NewFileStore(path).BackupPath(1)
+	assert.NoFileExists(t, backup)
+
+	require.NoError(t, s.Save())
+	saved, err := os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, original, saved)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc document
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.JSONEq(t, "2", string(doc["version"]))
+
+	require.NoError(t, os.Remove(backup))
+	s.SetRating("a", 4)
+	require.NoError(t, s.Save())
+	assert.NoFileExists(t, backup)
+}
+
+func TestLoad_RejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":99}`), 0644))
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than supported")
+}
@@ -1,10 +1,14 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -15,139 +19,493 @@ type CurrentWallpaper struct {
 	SetAt    time.Time `json:"set_at"`
 	IsTemp   bool      `json:"is_temp,omitempty"`
 	Query    string    `json:"query,omitempty"`
+	URL      string    `json:"url,omitempty"`
 }
 
 type PrefetchEntry struct {
 	Path      string    `json:"path"`
 	FetchedAt time.Time `json:"fetched_at"`
 	Query     string    `json:"query,omitempty"`
+	URL       string    `json:"url,omitempty"`
 }
 
 type State struct {
-	Theme      string                    `json:"theme"`
-	Current    CurrentWallpaper          `json:"current"`
-	History    []string                  `json:"history"`
-	Prefetched map[string]*PrefetchEntry `json:"prefetched,omitempty"`
-
-	path string
+	Version    int                         `json:"version"`
+	Theme      string                      `json:"theme"`
+	Current    CurrentWallpaper            `json:"current"`
+	Displays   map[string]CurrentWallpaper `json:"displays,omitempty"`
+	Themes     map[string]CurrentWallpaper `json:"themes,omitempty"`
+	History    []string                    `json:"history"`
+	Stack      []CurrentWallpaper          `json:"stack,omitempty"`
+	Cursor     int                         `json:"cursor,omitempty"`
+	Prefetched map[string]*PrefetchEntry   `json:"prefetched,omitempty"`
+	Ratings    map[string]int              `json:"ratings,omitempty"`
+	Bans       map[string]time.Time        `json:"bans,omitempty"`
+
+	mu          sync.Mutex
+	store       Store
+	now         func() time.Time
+	base        []byte
+	baseVersion int
+	synced      bool
+	pending     []func(*State)
 }
 
 func New(path string) *State {
 	return &State{
-		path:    path,
+		Version: CurrentVersion,
+		store:   NewFileStore(path),
 		History: []string{},
 	}
 }
 
-type legacyState struct {
-	Theme      string           `json:"theme"`
-	Current    CurrentWallpaper `json:"current"`
-	History    []string         `json:"history"`
-	Prefetched json.RawMessage  `json:"prefetched,omitempty"`
+func Load(path string) (*State, error) {
+	return LoadFrom(NewFileStore(expandPath(path)))
 }
 
-type legacyPrefetchEntry struct {
-	Path      string    `json:"path"`
-	SourceID  string    `json:"source_id"`
-	CacheKey  string    `json:"cache_key"`
-	FetchedAt time.Time `json:"fetched_at"`
-}
+func LoadFrom(store Store) (*State, error) {
+	data, err := store.Read()
+	if err != nil {
+		return nil, err
+	}
 
-func Load(path string) (*State, error) {
-	path = expandPath(path)
+	s, version, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.store = store
+	s.base = data
+	s.baseVersion = version
+	s.synced = true
+	return s, nil
+}
 
+func decode(data []byte) (*State, int, error) {
 	s := &State{
-		path:    path,
+		Version: CurrentVersion,
 		History: []string{},
 	}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return s, nil
+	if len(data) == 0 {
+		return s, CurrentVersion, nil
 	}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read state file: %w", err)
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse state file: %w", err)
 	}
 
-	if len(data) == 0 {
-		return s, nil
+	version, err := migrate(doc)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	var legacy legacyState
-	if err := json.Unmarshal(data, &legacy); err != nil {
-		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode migrated state: %w", err)
+	}
+	if err := json.Unmarshal(migrated, s); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse state file: %w", err)
 	}
 
-	s.Theme = legacy.Theme
-	s.Current = legacy.Current
-	s.History = legacy.History
-
-	if len(legacy.Prefetched) > 0 && string(legacy.Prefetched) != "null" {
-		var newFormat map[string]*PrefetchEntry
-		if err := json.Unmarshal(legacy.Prefetched, &newFormat); err == nil {
-			s.Prefetched = newFormat
-		} else {
-			var oldFormat legacyPrefetchEntry
-			if err := json.Unmarshal(legacy.Prefetched, &oldFormat); err == nil && oldFormat.Path != "" {
-				s.Prefetched = map[string]*PrefetchEntry{
-					oldFormat.SourceID: {
-						Path:      oldFormat.Path,
-						FetchedAt: oldFormat.FetchedAt,
-					},
-				}
-			}
+	if s.Cursor < 0 || s.Cursor >= len(s.Stack) {
+		s.Cursor = len(s.Stack) - 1
+		if s.Cursor < 0 {
+			s.Cursor = 0
 		}
 	}
 
-	s.path = path
-	return s, nil
+	return s, version, nil
+}
+
+func (s *State) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.store.Read()
+	if err != nil {
+		return err
+	}
+	loaded, version, err := decode(data)
+	if err != nil {
+		return err
+	}
+	s.assign(loaded)
+	s.base = data
+	s.baseVersion = version
+	s.synced = true
+	s.pending = nil
+	return nil
 }
 
 func (s *State) Save() error {
-	if s.path == "" {
+	if s.store == nil {
 		return fmt.Errorf("state path not set")
 	}
 
-	dir := filepath.Dir(s.path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create state directory: %w", err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if locker, ok := s.store.(Locker); ok {
+		unlock, err := locker.Lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	if err := s.merge(); err != nil {
+		return err
 	}
+	if err := s.backup(); err != nil {
+		return err
+	}
+	s.Version = CurrentVersion
 
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	if err := os.WriteFile(s.path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write state file: %w", err)
+	if err := s.store.Write(data); err != nil {
+		return err
+	}
+	s.base = data
+	s.baseVersion = CurrentVersion
+	s.synced = true
+	s.pending = nil
+	return nil
+}
+
+func (s *State) backup() error {
+	if len(s.base) == 0 || s.baseVersion >= CurrentVersion {
+		return nil
+	}
+	if backup, ok := s.store.(Backuper); ok {
+		return backup.Backup(s.base, s.baseVersion)
+	}
+	return nil
+}
+
+func (s *State) merge() error {
+	if !s.synced {
+		return nil
+	}
+
+	data, err := s.store.Read()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, s.base) {
+		return nil
 	}
 
+	fresh, version, err := decode(data)
+	if err != nil {
+		return err
+	}
+	s.base = data
+	s.baseVersion = version
+	fresh.now = s.now
+	for _, op := range s.pending {
+		op(fresh)
+	}
+	s.assign(fresh)
 	return nil
 }
 
-func (s *State) SetCurrent(path, sourceID, theme, query string, isTemp bool) {
+func (s *State) assign(from *State) {
+	s.Version = from.Version
+	s.Theme = from.Theme
+	s.Current = from.Current
+	s.Displays = from.Displays
+	s.Themes = from.Themes
+	s.History = from.History
+	s.Stack = from.Stack
+	s.Cursor = from.Cursor
+	s.Prefetched = from.Prefetched
+	s.Ratings = from.Ratings
+	s.Bans = from.Bans
+}
+
+func (s *State) update(op func(*State)) {
+	op(s)
+	s.pending = append(s.pending, op)
+}
+
+func (s *State) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *State) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *State) SetCurrent(path, sourceID, theme, query, url string, isTemp bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := CurrentWallpaper{
+		Path:     path,
+		SourceID: sourceID,
+		Theme:    theme,
+		SetAt:    s.clock(),
+		IsTemp:   isTemp,
+		Query:    query,
+		URL:      url,
+	}
+	s.update(func(s *State) {
+		s.setCurrent(current)
+		s.pushStack(current)
+	})
+}
+
+func (s *State) setCurrent(current CurrentWallpaper) {
 	if s.Current.Path != "" && !s.Current.IsTemp {
 		s.addToHistory(s.Current.Path)
 	}
 
-	s.Current = CurrentWallpaper{
+	s.Current = current
+	s.Displays = nil
+	s.Theme = current.Theme
+
+	if current.Theme != "" {
+		if s.Themes == nil {
+			s.Themes = make(map[string]CurrentWallpaper)
+		}
+		s.Themes[current.Theme] = current
+	}
+}
+
+func (s *State) pushStack(current CurrentWallpaper) {
+	const maxStack = 50
+
+	if len(s.Stack) > 0 {
+		s.Stack = s.Stack[:s.Cursor+1]
+	}
+	s.Stack = append(s.Stack, current)
+
+	if len(s.Stack) > maxStack {
+		s.Stack = s.Stack[len(s.Stack)-maxStack:]
+	}
+	s.Cursor = len(s.Stack) - 1
+}
+
+func (s *State) Peek(delta int, usable func(CurrentWallpaper) bool) (CurrentWallpaper, bool) {
+	current, _, ok := s.findStep(delta, usable)
+	return current, ok
+}
+
+func (s *State) Step(delta int, usable func(CurrentWallpaper) bool) (CurrentWallpaper, bool) {
+	current, i, ok := s.findStep(delta, usable)
+	if !ok {
+		return CurrentWallpaper{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current.SetAt = s.clock()
+	s.update(func(s *State) {
+		s.moveCursor(i, current.Path)
+		s.setCurrent(current)
+	})
+	return current, true
+}
+
+func (s *State) findStep(delta int, usable func(CurrentWallpaper) bool) (CurrentWallpaper, int, bool) {
+	s.mu.Lock()
+	stack := append([]CurrentWallpaper(nil), s.Stack...)
+	cursor := s.Cursor
+	s.mu.Unlock()
+
+	if delta == 0 || len(stack) == 0 {
+		return CurrentWallpaper{}, 0, false
+	}
+	for i := cursor + delta; i >= 0 && i < len(stack); i += delta {
+		if usable == nil || usable(stack[i]) {
+			return stack[i], i, true
+		}
+	}
+	return CurrentWallpaper{}, 0, false
+}
+
+func (s *State) moveCursor(i int, path string) {
+	if i >= 0 && i < len(s.Stack) && s.Stack[i].Path == path {
+		s.Cursor = i
+		return
+	}
+	for j := len(s.Stack) - 1; j >= 0; j-- {
+		if s.Stack[j].Path == path {
+			s.Cursor = j
+			return
+		}
+	}
+}
+
+func (s *State) ThemeCurrent(theme string) (CurrentWallpaper, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.Themes[theme]
+	if !ok || current.Path == "" {
+		return CurrentWallpaper{}, false
+	}
+	return current, true
+}
+
+func (s *State) SetDisplayCurrent(display, path, sourceID, theme, query, url string, isTemp bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := CurrentWallpaper{
 		Path:     path,
 		SourceID: sourceID,
 		Theme:    theme,
-		SetAt:    time.Now(),
+		SetAt:    s.clock(),
 		IsTemp:   isTemp,
 		Query:    query,
+		URL:      url,
 	}
-	s.Theme = theme
+	s.update(func(s *State) {
+		s.setDisplayCurrent(display, current)
+	})
+}
+
+func (s *State) setDisplayCurrent(display string, current CurrentWallpaper) {
+	if previous, ok := s.Displays[display]; ok && !previous.IsTemp {
+		s.addToHistory(previous.Path)
+	} else if !ok && s.Current.Path != "" && !s.Current.IsTemp {
+		s.addToHistory(s.Current.Path)
+	}
+
+	if s.Displays == nil {
+		s.Displays = make(map[string]CurrentWallpaper)
+	}
+	s.Displays[display] = current
+	s.Current = current
+	s.Theme = current.Theme
+}
+
+func (s *State) DisplayCurrent(display string) (CurrentWallpaper, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.Displays[display]
+	if !ok || current.Path == "" {
+		return CurrentWallpaper{}, false
+	}
+	return current, true
 }
 
 func (s *State) MarkSaved(newPath string) {
-	s.Current.Path = newPath
-	s.Current.IsTemp = false
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldPath := s.Current.Path
+	s.update(func(s *State) {
+		s.replacePath(oldPath, newPath)
+	})
+}
+
+func (s *State) MarkDisplaySaved(display, newPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.Displays[display]; ok {
+		s.update(func(s *State) {
+			s.replacePath(current.Path, newPath)
+		})
+	}
+}
+
+func (s *State) replacePath(oldPath, newPath string) {
+	for name, current := range s.Displays {
+		if current.Path == oldPath {
+			current.Path = newPath
+			current.IsTemp = false
+			s.Displays[name] = current
+		}
+	}
+	for theme, current := range s.Themes {
+		if current.Path == oldPath {
+			current.Path = newPath
+			current.IsTemp = false
+			s.Themes[theme] = current
+		}
+	}
+	for i := range s.Stack {
+		if s.Stack[i].Path == oldPath {
+			s.Stack[i].Path = newPath
+			s.Stack[i].IsTemp = false
+		}
+	}
+	if s.Current.Path == oldPath {
+		s.Current.Path = newPath
+		s.Current.IsTemp = false
+	}
+}
+
+func (s *State) IsReferenced(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Current.Path == path {
+		return true
+	}
+	for _, current := range s.Displays {
+		if current.Path == path {
+			return true
+		}
+	}
+	for _, current := range s.Themes {
+		if current.Path == path {
+			return true
+		}
+	}
+	for _, current := range s.Stack {
+		if current.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) TempPaths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(current CurrentWallpaper) {
+		if current.IsTemp && !seen[current.Path] {
+			seen[current.Path] = true
+			paths = append(paths, current.Path)
+		}
+	}
+
+	add(s.Current)
+	for _, current := range s.Displays {
+		add(current)
+	}
+	for _, current := range s.Themes {
+		add(current)
+	}
+	for _, current := range s.Stack {
+		add(current)
+	}
+	return paths
 }
 
 func (s *State) IsTempWallpaper() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.Current.IsTemp
 }
 
@@ -168,6 +526,9 @@ func (s *State) addToHistory(path string) {
 }
 
 func (s *State) IsInHistory(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for _, h := range s.History {
 		if h == path {
 			return true
@@ -177,18 +538,121 @@ func (s *State) IsInHistory(path string) bool {
 }
 
 func (s *State) Clear() {
-	s.Current = CurrentWallpaper{}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update(func(s *State) {
+		s.Current = CurrentWallpaper{}
+		s.Displays = nil
+	})
 }
 
 func (s *State) HasCurrent() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.Current.Path != ""
 }
 
+func (s *State) CurrentEntry() CurrentWallpaper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Current
+}
+
+func (s *State) HistoryCopy() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.History)
+}
+
+func (s *State) BansCopy() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.Bans)
+}
+
+func (s *State) DisplaysCopy() map[string]CurrentWallpaper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.Displays)
+}
+
+func (s *State) StackCopy() []CurrentWallpaper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.Stack)
+}
+
 func (s *State) Path() string {
-	return s.path
+	if fs, ok := s.store.(*FileStore); ok {
+		return fs.Path()
+	}
+	return ""
+}
+
+func (s *State) Rating(key string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	score, ok := s.Ratings[key]
+	return score, ok
+}
+
+func (s *State) SetRating(key string, score int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update(func(s *State) {
+		if s.Ratings == nil {
+			s.Ratings = make(map[string]int)
+		}
+		s.Ratings[key] = score
+	})
+}
+
+func (s *State) Ban(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at := s.clock()
+	s.update(func(s *State) {
+		if s.Bans == nil {
+			s.Bans = make(map[string]time.Time)
+		}
+		if _, ok := s.Bans[key]; !ok {
+			s.Bans[key] = at
+		}
+	})
+}
+
+func (s *State) Unban(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Bans[key]; !ok {
+		return false
+	}
+	s.update(func(s *State) {
+		delete(s.Bans, key)
+	})
+	return true
+}
+
+func (s *State) IsBanned(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.Bans[key]
+	return ok
 }
 
 func (s *State) GetPrefetchedForSource(sourceID string) *PrefetchEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prefetched(sourceID)
+}
+
+func (s *State) prefetched(sourceID string) *PrefetchEntry {
 	if s.Prefetched == nil {
 		return nil
 	}
@@ -197,43 +661,59 @@ func (s *State) GetPrefetchedForSource(sourceID string) *PrefetchEntry {
 		return nil
 	}
 	if _, err := os.Stat(entry.Path); os.IsNotExist(err) {
-		delete(s.Prefetched, sourceID)
+		s.update(func(s *State) {
+			delete(s.Prefetched, sourceID)
+		})
 		return nil
 	}
-	return entry
+	copied := *entry
+	return &copied
 }
 
-func (s *State) SetPrefetchedForSource(sourceID, path, query string) {
-	if s.Prefetched == nil {
-		s.Prefetched = make(map[string]*PrefetchEntry)
-	}
-	s.Prefetched[sourceID] = &PrefetchEntry{
+func (s *State) SetPrefetchedForSource(sourceID, path, query, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := PrefetchEntry{
 		Path:      path,
-		FetchedAt: time.Now(),
+		FetchedAt: s.clock(),
 		Query:     query,
+		URL:       url,
 	}
+	s.update(func(s *State) {
+		if s.Prefetched == nil {
+			s.Prefetched = make(map[string]*PrefetchEntry)
+		}
+		copied := entry
+		s.Prefetched[sourceID] = &copied
+	})
 }
 
 func (s *State) ClearPrefetchedForSource(sourceID string) {
-	if s.Prefetched != nil {
-		delete(s.Prefetched, sourceID)
-	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update(func(s *State) {
+		if s.Prefetched != nil {
+			delete(s.Prefetched, sourceID)
+		}
+	})
 }
 
 func (s *State) HasPrefetchedForSource(sourceID string) bool {
 	return s.GetPrefetchedForSource(sourceID) != nil
 }
 
-func (s *State) GetPrefetch(sourceID string) (string, string, bool) {
+func (s *State) GetPrefetch(sourceID string) (string, string, string, bool) {
 	entry := s.GetPrefetchedForSource(sourceID)
 	if entry == nil {
-		return "", "", false
+		return "", "", "", false
 	}
-	return entry.Path, entry.Query, true
+	return entry.Path, entry.Query, entry.URL, true
 }
 
-func (s *State) SetPrefetch(sourceID, path, query string) {
-	s.SetPrefetchedForSource(sourceID, path, query)
+func (s *State) SetPrefetch(sourceID, path, query, url string) {
+	s.SetPrefetchedForSource(sourceID, path, query, url)
 }
 
 func (s *State) ClearPrefetch(sourceID string) {
@@ -103,7 +103,7 @@ func TestLoad_WithTildeExpansion(t *testing.T) {
 	// Create a valid state file
 	state := &State{
 		Theme: "dark",
-		path:  stateFile,
+		store: NewFileStore(stateFile),
 	}
 	err := state.Save()
 	require.NoError(t, err)
@@ -120,7 +120,7 @@ func TestState_Save(t *testing.T) {
 
 	s := New(statePath)
 	s.Theme = "light"
-	s.SetCurrent("/tmp/wall.jpg", "source-1", "light", "", false)
+	s.SetCurrent("/tmp/wall.jpg", "source-1", "light", "", "", false)
 
 	// Save should create directories
 	err := s.Save()
@@ -137,6 +137,68 @@ func TestState_Save(t *testing.T) {
 	assert.Equal(t, "/tmp/wall.jpg", loaded.Current.Path)
 }
 
+func TestState_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s := New(path)
+	s.SetCurrent("/tmp/a.jpg", "local", "light", "", "", false)
+	require.NoError(t, s.Save())
+
+	other, err := Load(path)
+	require.NoError(t, err)
+	other.SetCurrent("/tmp/b.jpg", "local", "dark", "", "", false)
+	require.NoError(t, other.Save())
+
+	require.NoError(t, s.Reload())
+	assert.Equal(t, "/tmp/b.jpg", s.Current.Path)
+	assert.Equal(t, []string{"/tmp/a.jpg"}, s.History)
+	assert.Equal(t, path, s.Path())
+}
+
+func TestState_Ratings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s := New(path)
+	_, ok := s.Rating("/tmp/a.jpg")
+	assert.False(t, ok)
+
+	s.SetRating("/tmp/a.jpg", 5)
+	s.SetRating("wallhaven:abc", 1)
+	require.NoError(t, s.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	score, ok := loaded.Rating("/tmp/a.jpg")
+	assert.True(t, ok)
+	assert.Equal(t, 5, score)
+	score, ok = loaded.Rating("wallhaven:abc")
+	assert.True(t, ok)
+	assert.Equal(t, 1, score)
+}
+
+func TestState_Bans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s := New(path)
+	assert.False(t, s.IsBanned("/tmp/a.jpg"))
+
+	s.Ban("/tmp/a.jpg")
+	s.Ban("wallhaven:abc")
+	bannedAt := s.Bans["/tmp/a.jpg"]
+	s.Ban("/tmp/a.jpg")
+	assert.Equal(t, bannedAt, s.Bans["/tmp/a.jpg"])
+	require.NoError(t, s.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.IsBanned("/tmp/a.jpg"))
+	assert.True(t, loaded.IsBanned("wallhaven:abc"))
+
+	assert.True(t, loaded.Unban("/tmp/a.jpg"))
+	assert.False(t, loaded.Unban("/tmp/a.jpg"))
+	assert.False(t, loaded.IsBanned("/tmp/a.jpg"))
+}
+
 func TestState_Save_NoPath(t *testing.T) {
 	s := &State{}
 	err := s.Save()
@@ -148,7 +210,7 @@ func TestState_SetCurrent(t *testing.T) {
 	s := New("/tmp/state.json")
 
 	// Set first wallpaper (temp)
-	s.SetCurrent("/tmp/first.jpg", "source-1", "light", "", true)
+	s.SetCurrent("/tmp/first.jpg", "source-1", "light", "", "", true)
 
 	assert.Equal(t, "/tmp/first.jpg", s.Current.Path)
 	assert.Equal(t, "source-1", s.Current.SourceID)
@@ -158,13 +220,13 @@ func TestState_SetCurrent(t *testing.T) {
 	assert.Empty(t, s.History) // Temp wallpapers not added to history
 
 	// Set second wallpaper (not temp) - previous was temp, so not added to history
-	s.SetCurrent("/tmp/second.jpg", "source-2", "light", "", false)
+	s.SetCurrent("/tmp/second.jpg", "source-2", "light", "", "", false)
 	assert.Equal(t, "/tmp/second.jpg", s.Current.Path)
 	assert.False(t, s.Current.IsTemp)
 	assert.Empty(t, s.History) // Previous was temp
 
 	// Set third wallpaper - previous was not temp, so added to history
-	s.SetCurrent("/tmp/third.jpg", "source-3", "dark", "", false)
+	s.SetCurrent("/tmp/third.jpg", "source-3", "dark", "", "", false)
 	assert.Equal(t, "/tmp/third.jpg", s.Current.Path)
 	assert.Equal(t, "dark", s.Theme)
 	require.Len(t, s.History, 1)
@@ -173,7 +235,7 @@ func TestState_SetCurrent(t *testing.T) {
 
 func TestState_MarkSaved(t *testing.T) {
 	s := New("/tmp/state.json")
-	s.SetCurrent("/tmp/temp.jpg", "source-1", "light", "", true)
+	s.SetCurrent("/tmp/temp.jpg", "source-1", "light", "", "", true)
 
 	assert.True(t, s.IsTempWallpaper())
 
@@ -183,6 +245,158 @@ func TestState_MarkSaved(t *testing.T) {
 	assert.Equal(t, "/home/user/saved.jpg", s.Current.Path)
 }
 
+func TestState_SetDisplayCurrent(t *testing.T) {
+	s := New("/tmp/state.json")
+	s.SetCurrent("/path/all.jpg", "s1", "light", "", "", false)
+
+	s.SetDisplayCurrent("DP-1", "/tmp/a.jpg", "light-bing", "light", "mountains", "", true)
+	s.SetDisplayCurrent("HDMI-1", "/path/b.jpg", "light-local-1", "light", "", "", false)
+
+	a, ok := s.DisplayCurrent("DP-1")
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/a.jpg", a.Path)
+	assert.True(t, a.IsTemp)
+	assert.Equal(t, "mountains", a.Query)
+
+	_, ok = s.DisplayCurrent("eDP-1")
+	assert.False(t, ok)
+
+	assert.Equal(t, "/path/b.jpg", s.Current.Path)
+	assert.Equal(t, []string{"/path/all.jpg"}, s.History)
+
+	s.SetDisplayCurrent("HDMI-1", "/path/c.jpg", "light-local-1", "light", "", "", false)
+	assert.Equal(t, []string{"/path/all.jpg", "/path/b.jpg"}, s.History)
+
+	s.SetCurrent("/path/d.jpg", "s1", "light", "", "", false)
+	assert.Empty(t, s.Displays)
+}
+
+func TestState_MarkDisplaySaved(t *testing.T) {
+	s := New("/tmp/state.json")
+	s.SetDisplayCurrent("DP-1", "/tmp/a.jpg", "light-bing", "light", "", "", true)
+	s.SetDisplayCurrent("HDMI-1", "/tmp/b.jpg", "light-bing", "light", "", "", true)
+
+	s.MarkDisplaySaved("DP-1", "/home/user/saved.jpg")
+
+	a, _ := s.DisplayCurrent("DP-1")
+	assert.Equal(t, "/home/user/saved.jpg", a.Path)
+	assert.False(t, a.IsTemp)
+
+	b, _ := s.DisplayCurrent("HDMI-1")
+	assert.True(t, b.IsTemp)
+	assert.True(t, s.IsTempWallpaper())
+
+	s.MarkSaved("/home/user/saved-b.jpg")
+	b, _ = s.DisplayCurrent("HDMI-1")
+	assert.Equal(t, "/home/user/saved-b.jpg", b.Path)
+	assert.False(t, b.IsTemp)
+}
+
+func TestState_TempPaths(t *testing.T) {
+	s := New("/tmp/state.json")
+	s.SetDisplayCurrent("DP-1", "/tmp/a.jpg", "light-bing", "light", "", "", true)
+	s.SetDisplayCurrent("HDMI-1", "/path/b.jpg", "light-local-1", "light", "", "", false)
+	s.SetDisplayCurrent("eDP-1", "/tmp/c.jpg", "light-bing", "light", "", "", true)
+
+	assert.ElementsMatch(t, []string{"/tmp/a.jpg", "/tmp/c.jpg"}, s.TempPaths())
+	assert.True(t, s.IsReferenced("/path/b.jpg"))
+	assert.False(t, s.IsReferenced("/tmp/other.jpg"))
+}
+
+func TestState_ThemeCurrent(t *testing.T) {
+	s := New("/tmp/state.json")
+	s.SetCurrent("/tmp/light.jpg", "light-bing", "light", "", "", true)
+	s.SetCurrent("/path/dark.jpg", "dark-local-1", "dark", "", "", false)
+
+	light, ok := s.ThemeCurrent("light")
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/light.jpg", light.Path)
+	assert.True(t, light.IsTemp)
+
+	dark, ok := s.ThemeCurrent("dark")
+	require.True(t, ok)
+	assert.Equal(t, "/path/dark.jpg", dark.Path)
+
+	_, ok = s.ThemeCurrent("sepia")
+	assert.False(t, ok)
+
+	assert.True(t, s.IsReferenced("/tmp/light.jpg"))
+	assert.Equal(t, []string{"/tmp/light.jpg"}, s.TempPaths())
+
+	s.SetCurrent("/tmp/light.jpg", "light-bing", "light", "", "", true)
+	s.MarkSaved("/saved/light.jpg")
+	light, _ = s.ThemeCurrent("light")
+	assert.Equal(t, "/saved/light.jpg", light.Path)
+	assert.False(t, light.IsTemp)
+}
+
+func TestState_Stack(t *testing.T) {
+	s := New("/tmp/state.json")
+	s.SetCurrent("/tmp/a.jpg", "light-local-1", "light", "", "", false)
+	s.SetCurrent("/tmp/b.jpg", "light-bing", "light", "", "", true)
+	s.SetCurrent("/tmp/c.jpg", "light-local-1", "light", "", "", false)
+
+	require.Len(t, s.Stack, 3)
+	assert.Equal(t, 2, s.Cursor)
+	_, ok := s.Peek(1, nil)
+	assert.False(t, ok)
+	peeked, ok := s.Peek(-1, nil)
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/b.jpg", peeked.Path)
+	assert.Equal(t, 2, s.Cursor)
+
+	back, ok := s.Step(-1, nil)
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/b.jpg", back.Path)
+	assert.True(t, back.IsTemp)
+	assert.Equal(t, "/tmp/b.jpg", s.Current.Path)
+	assert.Equal(t, 1, s.Cursor)
+	assert.Len(t, s.Stack, 3)
+
+	skipTemp := func(c CurrentWallpaper) bool { return !c.IsTemp }
+	back, ok = s.Step(-1, skipTemp)
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/a.jpg", back.Path)
+	assert.Equal(t, 0, s.Cursor)
+
+	_, ok = s.Step(-1, nil)
+	assert.False(t, ok)
+	assert.Equal(t, 0, s.Cursor)
+
+	forward, ok := s.Step(1, skipTemp)
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/c.jpg", forward.Path)
+
+	s.Step(-1, nil)
+	s.SetCurrent("/tmp/d.jpg", "light-local-1", "light", "", "", false)
+	assert.Equal(t, []string{"/tmp/a.jpg", "/tmp/b.jpg", "/tmp/d.jpg"}, stackPaths(s))
+	assert.Equal(t, 2, s.Cursor)
+	assert.False(t, s.IsReferenced("/tmp/c.jpg"))
+	assert.True(t, s.IsReferenced("/tmp/b.jpg"))
+}
+
+func TestState_Stack_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := New(path)
+	s.SetCurrent("/tmp/a.jpg", "light-local-1", "light", "", "", false)
+	s.SetCurrent("/tmp/b.jpg", "light-local-1", "light", "", "", false)
+	s.Step(-1, nil)
+	require.NoError(t, s.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/tmp/a.jpg", "/tmp/b.jpg"}, stackPaths(loaded))
+	assert.Equal(t, 0, loaded.Cursor)
+}
+
+func stackPaths(s *State) []string {
+	paths := make([]string, len(s.Stack))
+	for i, c := range s.Stack {
+		paths[i] = c.Path
+	}
+	return paths
+}
+
 func TestState_IsTempWallpaper(t *testing.T) {
 	s := New("/tmp/state.json")
 
@@ -190,11 +404,11 @@ func TestState_IsTempWallpaper(t *testing.T) {
 	assert.False(t, s.IsTempWallpaper())
 
 	// Set temp wallpaper
-	s.SetCurrent("/tmp/temp.jpg", "source-1", "light", "", true)
+	s.SetCurrent("/tmp/temp.jpg", "source-1", "light", "", "", true)
 	assert.True(t, s.IsTempWallpaper())
 
 	// Set non-temp wallpaper
-	s.SetCurrent("/home/user/perm.jpg", "source-2", "light", "", false)
+	s.SetCurrent("/home/user/perm.jpg", "source-2", "light", "", "", false)
 	assert.False(t, s.IsTempWallpaper())
 }
 
@@ -202,9 +416,9 @@ func TestState_IsInHistory(t *testing.T) {
 	s := New("/tmp/state.json")
 
 	// Add some history
-	s.SetCurrent("/path/1.jpg", "s1", "light", "", false)
-	s.SetCurrent("/path/2.jpg", "s2", "light", "", false)
-	s.SetCurrent("/path/3.jpg", "s3", "light", "", false)
+	s.SetCurrent("/path/1.jpg", "s1", "light", "", "", false)
+	s.SetCurrent("/path/2.jpg", "s2", "light", "", "", false)
+	s.SetCurrent("/path/3.jpg", "s3", "light", "", "", false)
 
 	// /path/1.jpg and /path/2.jpg should be in history
 	assert.True(t, s.IsInHistory("/path/1.jpg"))
@@ -219,10 +433,10 @@ func TestState_History_NoDuplicates(t *testing.T) {
 	s := New("/tmp/state.json")
 
 	// Add same path multiple times via SetCurrent cycle
-	s.SetCurrent("/path/1.jpg", "s1", "light", "", false)
-	s.SetCurrent("/path/2.jpg", "s2", "light", "", false)
-	s.SetCurrent("/path/1.jpg", "s1", "light", "", false) // Back to 1
-	s.SetCurrent("/path/3.jpg", "s3", "light", "", false)
+	s.SetCurrent("/path/1.jpg", "s1", "light", "", "", false)
+	s.SetCurrent("/path/2.jpg", "s2", "light", "", "", false)
+	s.SetCurrent("/path/1.jpg", "s1", "light", "", "", false) // Back to 1
+	s.SetCurrent("/path/3.jpg", "s3", "light", "", "", false)
 
 	// Count occurrences of /path/1.jpg
 	count := 0
@@ -239,7 +453,7 @@ func TestState_History_MaxSize(t *testing.T) {
 
 	// Add more than 100 items
 	for i := 0; i < 110; i++ {
-		s.SetCurrent("/path/current.jpg", "s", "light", "", false)
+		s.SetCurrent("/path/current.jpg", "s", "light", "", "", false)
 		// Simulate adding to history by modifying directly for test
 		s.Current.Path = "" // Clear so next SetCurrent sees no previous
 	}
@@ -248,7 +462,7 @@ func TestState_History_MaxSize(t *testing.T) {
 	s2 := New("/tmp/state.json")
 	for i := 0; i < 110; i++ {
 		path := filepath.Join("/path", string(rune('A'+i%26)), "wall.jpg")
-		s2.SetCurrent(path, "s", "light", "", false)
+		s2.SetCurrent(path, "s", "light", "", "", false)
 	}
 
 	assert.LessOrEqual(t, len(s2.History), 100)
@@ -256,7 +470,7 @@ func TestState_History_MaxSize(t *testing.T) {
 
 func TestState_Clear(t *testing.T) {
 	s := New("/tmp/state.json")
-	s.SetCurrent("/tmp/wall.jpg", "source-1", "light", "", false)
+	s.SetCurrent("/tmp/wall.jpg", "source-1", "light", "", "", false)
 
 	require.True(t, s.HasCurrent())
 
@@ -271,13 +485,42 @@ func TestState_HasCurrent(t *testing.T) {
 
 	assert.False(t, s.HasCurrent())
 
-	s.SetCurrent("/tmp/wall.jpg", "source-1", "light", "", false)
+	s.SetCurrent("/tmp/wall.jpg", "source-1", "light", "", "", false)
 	assert.True(t, s.HasCurrent())
 
 	s.Clear()
 	assert.False(t, s.HasCurrent())
 }
 
+func TestState_Copies(t *testing.T) {
+	s := New("/tmp/state.json")
+	s.SetCurrent("/tmp/a.jpg", "source-1", "light", "", "", false)
+	s.SetCurrent("/tmp/b.jpg", "source-1", "light", "", "", false)
+	s.SetDisplayCurrent("DP-1", "/tmp/b.jpg", "source-1", "light", "", "", false)
+	s.Ban("/tmp/c.jpg")
+
+	assert.Equal(t, "/tmp/b.jpg", s.CurrentEntry().Path)
+
+	history := s.HistoryCopy()
+	require.Contains(t, history, "/tmp/a.jpg")
+	history[0] = "/tmp/changed.jpg"
+	assert.False(t, s.IsInHistory("/tmp/changed.jpg"))
+
+	bans := s.BansCopy()
+	delete(bans, "/tmp/c.jpg")
+	assert.True(t, s.IsBanned("/tmp/c.jpg"))
+
+	displays := s.DisplaysCopy()
+	delete(displays, "DP-1")
+	_, ok := s.DisplayCurrent("DP-1")
+	assert.True(t, ok)
+
+	stack := s.StackCopy()
+	require.Len(t, stack, 2)
+	stack[0].Path = "/tmp/changed.jpg"
+	assert.Equal(t, "/tmp/a.jpg", s.StackCopy()[0].Path)
+}
+
 func TestState_Path(t *testing.T) {
 	s := New("/custom/path/state.json")
 	assert.Equal(t, "/custom/path/state.json", s.Path())
@@ -286,7 +529,7 @@ func TestState_Path(t *testing.T) {
 func TestState_JSON_Serialization(t *testing.T) {
 	s := New("/tmp/state.json")
 	s.Theme = "dark"
-	s.SetCurrent("/tmp/wall.jpg", "source-1", "dark", "", true)
+	s.SetCurrent("/tmp/wall.jpg", "source-1", "dark", "", "", true)
 
 	// Marshal
 	data, err := json.Marshal(s)
@@ -302,6 +545,22 @@ func TestState_JSON_Serialization(t *testing.T) {
 	assert.True(t, loaded.Current.IsTemp)
 }
 
+func TestState_Save_Displays(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	s := New(statePath)
+	s.SetDisplayCurrent("DP-1", "/tmp/a.jpg", "dark-bing", "dark", "", "", true)
+	require.NoError(t, s.Save())
+
+	loaded, err := Load(statePath)
+	require.NoError(t, err)
+
+	current, ok := loaded.DisplayCurrent("DP-1")
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/a.jpg", current.Path)
+	assert.Equal(t, "dark-bing", current.SourceID)
+}
+
 func TestExpandPath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -343,7 +602,7 @@ func TestState_Prefetch(t *testing.T) {
 	t.Run("GetPrefetch returns empty when no prefetch", func(t *testing.T) {
 		s := New(filepath.Join(tmpDir, "state.json"))
 
-		path, query, ok := s.GetPrefetch("dark-bing")
+		path, query, _, ok := s.GetPrefetch("dark-bing")
 		assert.False(t, ok)
 		assert.Empty(t, path)
 		assert.Empty(t, query)
@@ -356,9 +615,9 @@ func TestState_Prefetch(t *testing.T) {
 		prefetchPath := filepath.Join(tmpDir, "prefetched.jpg")
 		require.NoError(t, os.WriteFile(prefetchPath, []byte("image"), 0644))
 
-		s.SetPrefetch("dark-bing", prefetchPath, "nature")
+		s.SetPrefetch("dark-bing", prefetchPath, "nature", "")
 
-		path, query, ok := s.GetPrefetch("dark-bing")
+		path, query, _, ok := s.GetPrefetch("dark-bing")
 		assert.True(t, ok)
 		assert.Equal(t, prefetchPath, path)
 		assert.Equal(t, "nature", query)
@@ -370,14 +629,14 @@ func TestState_Prefetch(t *testing.T) {
 		prefetchPath := filepath.Join(tmpDir, "prefetched2.jpg")
 		require.NoError(t, os.WriteFile(prefetchPath, []byte("image"), 0644))
 
-		s.SetPrefetch("dark-bing", prefetchPath, "landscape")
+		s.SetPrefetch("dark-bing", prefetchPath, "landscape", "")
 
 		// Different source should not match
-		path, _, ok := s.GetPrefetch("light-bing")
+		path, _, _, ok := s.GetPrefetch("light-bing")
 		assert.False(t, ok)
 		assert.Empty(t, path)
 
-		path, _, ok = s.GetPrefetch("dark-wallhaven")
+		path, _, _, ok = s.GetPrefetch("dark-wallhaven")
 		assert.False(t, ok)
 		assert.Empty(t, path)
 	})
@@ -386,9 +645,9 @@ func TestState_Prefetch(t *testing.T) {
 		s := New(filepath.Join(tmpDir, "state.json"))
 
 		// Set prefetch with non-existent file
-		s.SetPrefetch("dark-bing", "/nonexistent/path.jpg", "test")
+		s.SetPrefetch("dark-bing", "/nonexistent/path.jpg", "test", "")
 
-		path, _, ok := s.GetPrefetch("dark-bing")
+		path, _, _, ok := s.GetPrefetch("dark-bing")
 		assert.False(t, ok)
 		assert.Empty(t, path)
 		// Entry should be cleared from map
@@ -401,7 +660,7 @@ func TestState_Prefetch(t *testing.T) {
 		prefetchPath := filepath.Join(tmpDir, "prefetched3.jpg")
 		require.NoError(t, os.WriteFile(prefetchPath, []byte("image"), 0644))
 
-		s.SetPrefetch("dark-bing", prefetchPath, "mountains")
+		s.SetPrefetch("dark-bing", prefetchPath, "mountains", "")
 		require.Contains(t, s.Prefetched, "dark-bing")
 
 		s.ClearPrefetch("dark-bing")
@@ -416,11 +675,11 @@ func TestState_Prefetch(t *testing.T) {
 		require.NoError(t, os.WriteFile(path1, []byte("image1"), 0644))
 		require.NoError(t, os.WriteFile(path2, []byte("image2"), 0644))
 
-		s.SetPrefetch("dark-bing", path1, "query1")
-		s.SetPrefetch("dark-wallhaven", path2, "query2")
+		s.SetPrefetch("dark-bing", path1, "query1", "")
+		s.SetPrefetch("dark-wallhaven", path2, "query2", "")
 
-		p1, q1, ok1 := s.GetPrefetch("dark-bing")
-		p2, q2, ok2 := s.GetPrefetch("dark-wallhaven")
+		p1, q1, _, ok1 := s.GetPrefetch("dark-bing")
+		p2, q2, _, ok2 := s.GetPrefetch("dark-wallhaven")
 
 		assert.True(t, ok1)
 		assert.True(t, ok2)
@@ -438,7 +697,7 @@ func TestState_Prefetch(t *testing.T) {
 		prefetchPath := filepath.Join(tmpDir, "prefetched4.jpg")
 		require.NoError(t, os.WriteFile(prefetchPath, []byte("image"), 0644))
 
-		s.SetPrefetch("dark-bing", prefetchPath, "test")
+		s.SetPrefetch("dark-bing", prefetchPath, "test", "")
 
 		assert.True(t, s.HasPrefetchedForSource("dark-bing"))
 		assert.False(t, s.HasPrefetchedForSource("light-bing"))
@@ -450,14 +709,14 @@ func TestState_Prefetch(t *testing.T) {
 		require.NoError(t, os.WriteFile(prefetchPath, []byte("image"), 0644))
 
 		s := New(statePath)
-		s.SetPrefetch("dark-bing", prefetchPath, "persisted query")
+		s.SetPrefetch("dark-bing", prefetchPath, "persisted query", "")
 		require.NoError(t, s.Save())
 
 		// Load and verify
 		loaded, err := Load(statePath)
 		require.NoError(t, err)
 
-		path, query, ok := loaded.GetPrefetch("dark-bing")
+		path, query, _, ok := loaded.GetPrefetch("dark-bing")
 		assert.True(t, ok)
 		assert.Equal(t, prefetchPath, path)
 		assert.Equal(t, "persisted query", query)
@@ -491,7 +750,7 @@ func TestLoad_LegacyPrefetchMigration(t *testing.T) {
 		require.NoError(t, err)
 
 		// Should be able to get prefetch by source ID
-		path, _, ok := loaded.GetPrefetch("dark-bing")
+		path, _, _, ok := loaded.GetPrefetch("dark-bing")
 		assert.True(t, ok)
 		assert.Equal(t, prefetchPath, path)
 	})
@@ -518,7 +777,7 @@ func TestLoad_LegacyPrefetchMigration(t *testing.T) {
 		loaded, err := Load(statePath)
 		require.NoError(t, err)
 
-		path, _, ok := loaded.GetPrefetch("dark-bing")
+		path, _, _, ok := loaded.GetPrefetch("dark-bing")
 		assert.True(t, ok)
 		assert.Equal(t, prefetchPath, path)
 	})
@@ -537,7 +796,7 @@ func TestLoad_LegacyPrefetchMigration(t *testing.T) {
 		loaded, err := Load(statePath)
 		require.NoError(t, err)
 
-		_, _, ok := loaded.GetPrefetch("any")
+		_, _, _, ok := loaded.GetPrefetch("any")
 		assert.False(t, ok)
 	})
 
@@ -554,7 +813,7 @@ func TestLoad_LegacyPrefetchMigration(t *testing.T) {
 		loaded, err := Load(statePath)
 		require.NoError(t, err)
 
-		_, _, ok := loaded.GetPrefetch("any")
+		_, _, _, ok := loaded.GetPrefetch("any")
 		assert.False(t, ok)
 	})
 }
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type Store interface {
+	Read() ([]byte, error)
+	Write(data []byte) error
+}
+
+type Locker interface {
+	Lock() (func(), error)
+}
+
+type Backuper interface {
+	Backup(data []byte, version int) error
+}
+
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (f *FileStore) Path() string {
+	return f.path
+}
+
+func (f *FileStore) Read() ([]byte, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	return data, nil
+}
+
+func (f *FileStore) Write(data []byte) error {
+	if f.path == "" {
+		return fmt.Errorf("state path not set")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return writeAtomic(f.path, data)
+}
+
+func (f *FileStore) Backup(data []byte, version int) error {
+	if f.path == "" {
+		return fmt.Errorf("state path not set")
+	}
+	if err := writeAtomic(f.BackupPath(version), data); err != nil {
+		return fmt.Errorf("failed to back up state file: %w", err)
+	}
+	return nil
+}
+
+func (f *FileStore) BackupPath(version int) string {
+	return fmt.Sprintf("%s.v%d.bak", f.path, version)
+}
+
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to set state file permissions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+func (f *FileStore) Lock() (func(), error) {
+	if f.path == "" {
+		return nil, fmt.Errorf("state path not set")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state lock: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock state file: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,234 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	data []byte
+}
+
+func (m *memoryStore) Read() ([]byte, error) { return m.data, nil }
+
+func (m *memoryStore) Write(data []byte) error {
+	m.data = append([]byte(nil), data...)
+	return nil
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	store := NewFileStore(path)
+
+	data, err := store.Read()
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	require.NoError(t, store.Write([]byte(`{"theme":"dark"}`)))
+	data, err = store.Read()
+	require.NoError(t, err)
+	assert.Equal(t, `{"theme":"dark"}`, string(data))
+	assert.Equal(t, path, store.Path())
+
+	assert.EqualError(t, NewFileStore("").Write(nil), "state path not set")
+}
+
+func TestLoadFrom_CustomStore(t *testing.T) {
+	store := &memoryStore{}
+	s, err := LoadFrom(store)
+	require.NoError(t, err)
+	assert.Empty(t, s.Path())
+
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+	s.SetCurrent("/tmp/a.jpg", "dark-local-1", "dark", "", "", false)
+	require.NoError(t, s.Save())
+
+	loaded, err := LoadFrom(store)
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/a.jpg", loaded.Current.Path)
+	assert.Equal(t, now, loaded.Current.SetAt)
+
+	require.NoError(t, s.Reload())
+	s.Ban("unsplash:abc")
+	assert.Equal(t, now, s.Bans["unsplash:abc"])
+}
+
+func TestFileStore_AtomicWrite(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStore(filepath.Join(dir, "state.json"))
+
+	require.NoError(t, store.Write([]byte(`{"theme":"dark"}`)))
+	require.NoError(t, store.Write([]byte(`{"theme":"light"}`)))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "state.json", entries[0].Name())
+
+	info, err := os.Stat(store.Path())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+func TestFileStore_Lock(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+
+	unlock, err := store.Lock()
+	require.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock, err := store.Lock()
+		if err == nil {
+			unlock()
+		}
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("lock not released")
+	}
+
+	_, err = NewFileStore("").Lock()
+	assert.EqualError(t, err, "state path not set")
+}
+
+func TestState_SaveMergesOtherWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	first, err := Load(path)
+	require.NoError(t, err)
+	second, err := Load(path)
+	require.NoError(t, err)
+
+	first.SetCurrent("/tmp/a.jpg", "dark-local", "dark", "", "", false)
+	first.SetRating("a", 5)
+	require.NoError(t, first.Save())
+
+	second.SetCurrent("/tmp/b.jpg", "dark-local", "dark", "", "", false)
+	second.Ban("c")
+	require.NoError(t, second.Save())
+
+	assert.Equal(t, "/tmp/b.jpg", second.Current.Path)
+	assert.Equal(t, []string{"/tmp/a.jpg"}, second.History)
+	assert.Equal(t, 5, second.Ratings["a"])
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/b.jpg", loaded.Current.Path)
+	assert.Equal(t, []string{"/tmp/a.jpg"}, loaded.History)
+	assert.Len(t, loaded.Stack, 2)
+	assert.Equal(t, 5, loaded.Ratings["a"])
+	assert.True(t, loaded.IsBanned("c"))
+}
+
+func TestState_ConcurrentGoroutines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	shared, err := Load(path)
+	require.NoError(t, err)
+
+	const workers, rounds = 8, 10
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				key := fmt.Sprintf("shared-%d-%d", w, i)
+				shared.SetRating(key, 4)
+				shared.SetPrefetch(key, "/tmp/"+key, "", "")
+				shared.IsBanned(key)
+				assert.NoError(t, shared.Save())
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			own, err := Load(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for i := 0; i < rounds; i++ {
+				own.SetRating(fmt.Sprintf("own-%d-%d", w, i), 2)
+				assert.NoError(t, own.Save())
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	for w := 0; w < workers; w++ {
+		for i := 0; i < rounds; i++ {
+			assert.Equal(t, 4, loaded.Ratings[fmt.Sprintf("shared-%d-%d", w, i)])
+			assert.Equal(t, 2, loaded.Ratings[fmt.Sprintf("own-%d-%d", w, i)])
+		}
+	}
+}
+
+func TestState_ConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	const procs, rounds = 4, 15
+	cmds := make([]*exec.Cmd, procs)
+	for p := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestState_HammerProcess$")
+		cmd.Env = append(os.Environ(),
+			"WALLBOY_STATE_HAMMER="+path,
+			"WALLBOY_STATE_HAMMER_ID="+strconv.Itoa(p),
+			"WALLBOY_STATE_HAMMER_ROUNDS="+strconv.Itoa(rounds),
+		)
+		require.NoError(t, cmd.Start())
+		cmds[p] = cmd
+	}
+	for _, cmd := range cmds {
+		require.NoError(t, cmd.Wait())
+	}
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Ratings, procs*rounds)
+	assert.Len(t, loaded.History, procs*rounds-1)
+	for p := 0; p < procs; p++ {
+		for i := 0; i < rounds; i++ {
+			assert.Equal(t, 3, loaded.Ratings[fmt.Sprintf("proc-%d-%d", p, i)])
+		}
+	}
+}
+
+func TestState_HammerProcess(t *testing.T) {
+	path := os.Getenv("WALLBOY_STATE_HAMMER")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	id := os.Getenv("WALLBOY_STATE_HAMMER_ID")
+	rounds, err := strconv.Atoi(os.Getenv("WALLBOY_STATE_HAMMER_ROUNDS"))
+	require.NoError(t, err)
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	for i := 0; i < rounds; i++ {
+		key := fmt.Sprintf("proc-%s-%d", id, i)
+		s.SetCurrent("/tmp/"+key+".jpg", "dark-local", "dark", "", "", false)
+		s.SetRating(key, 3)
+		require.NoError(t, s.Save())
+	}
+}
@@ -0,0 +1,72 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/Artawower/wallboy/internal/colors"
+)
+
+type Data struct {
+	colors.Palette
+	Wallpaper string
+	Theme     string
+}
+
+func Render(src, dest string, data Data) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(src)).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", src, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render template %s: %w", src, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+
+	return nil
+}
+
+func RenderAll(templates map[string]string, data Data) ([]string, error) {
+	sources := make([]string, 0, len(templates))
+	for src := range templates {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	var rendered []string
+	var errs []error
+	for _, src := range sources {
+		dest := templates[src]
+		if err := Render(src, dest, data); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rendered = append(rendered, dest)
+	}
+
+	return rendered, errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Artawower/wallboy/internal/colors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testData() Data {
+	return Data{
+		Palette:   colors.NewPalette([]colors.Color{{R: 10, G: 20, B: 30}, {R: 200, G: 100, B: 50}}, true),
+		Wallpaper: "/tmp/wall.jpg",
+		Theme:     "dark",
+	}
+}
+
+func TestRender(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "kitty", "colors.conf")
+	data := testData()
+
+	require.NoError(t, Render("testdata/colors.conf.tmpl", dest, data))
+
+	content, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 19)
+	assert.Equal(t, "# /tmp/wall.jpg (dark)", lines[0])
+	assert.Equal(t, "background "+data.Background.Hex(), lines[1])
+	assert.Equal(t, "foreground "+data.Foreground.Hex(), lines[2])
+	assert.Equal(t, "color0 "+data.ANSI[0].Hex(), lines[3])
+	assert.Equal(t, "color15 "+data.ANSI[15].Hex(), lines[18])
+	assert.NoFileExists(t, dest+".tmp")
+}
+
+func TestRender_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("missing template", func(t *testing.T) {
+		err := Render(filepath.Join(dir, "missing.tmpl"), filepath.Join(dir, "out"), testData())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read template")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		src := filepath.Join(dir, "bad.tmpl")
+		require.NoError(t, os.WriteFile(src, []byte("{{.Nope}}"), 0644))
+
+		err := Render(src, filepath.Join(dir, "out"), testData())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to render template")
+		assert.NoFileExists(t, filepath.Join(dir, "out"))
+	})
+}
+
+func TestRenderAll(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.conf")
+	broken := filepath.Join(dir, "broken.tmpl")
+	require.NoError(t, os.WriteFile(broken, []byte("{{"), 0644))
+
+	rendered, err := RenderAll(map[string]string{
+		"testdata/colors.conf.tmpl": good,
+		broken:                      filepath.Join(dir, "broken.conf"),
+	}, testData())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse template")
+	assert.Equal(t, []string{good}, rendered)
+	assert.FileExists(t, good)
+}
@@ -0,0 +1,81 @@
+package theme
+
+import (
+	"math"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/config"
+)
+
+const daylightElevation = -0.833
+
+func Solar(settings config.ThemeSettings, t time.Time) Theme {
+	if settings.HasSchedule() {
+		lightFrom, err := config.ParseTimeOfDay(settings.LightFrom)
+		if err != nil {
+			return Light
+		}
+		darkFrom, err := config.ParseTimeOfDay(settings.DarkFrom)
+		if err != nil {
+			return Light
+		}
+		if InWindow(t, lightFrom, darkFrom) {
+			return Light
+		}
+		return Dark
+	}
+
+	if settings.HasCoordinates() {
+		if IsDaylight(t, *settings.Latitude, *settings.Longitude) {
+			return Light
+		}
+		return Dark
+	}
+
+	return Light
+}
+
+func InWindow(t time.Time, from, to time.Duration) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	if from <= to {
+		return offset >= from && offset < to
+	}
+	return offset >= from || offset < to
+}
+
+func IsDaylight(t time.Time, latitude, longitude float64) bool {
+	return SolarElevation(t, latitude, longitude) > daylightElevation
+}
+
+func SolarElevation(t time.Time, latitude, longitude float64) float64 {
+	days := float64(t.UTC().UnixNano())/float64(24*time.Hour) + 2440587.5 - 2451545.0
+
+	meanLongitude := normalizeDegrees(280.460 + 0.9856474*days)
+	meanAnomaly := radians(normalizeDegrees(357.528 + 0.9856003*days))
+	eclipticLongitude := radians(meanLongitude + 1.915*math.Sin(meanAnomaly) + 0.020*math.Sin(2*meanAnomaly))
+	obliquity := radians(23.439 - 0.0000004*days)
+
+	rightAscension := math.Atan2(math.Cos(obliquity)*math.Sin(eclipticLongitude), math.Cos(eclipticLongitude))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(eclipticLongitude))
+
+	siderealTime := normalizeDegrees(280.46061837 + 360.98564736629*days + longitude)
+	hourAngle := radians(siderealTime) - rightAscension
+
+	lat := radians(latitude)
+	elevation := math.Asin(math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle))
+	return elevation * 180 / math.Pi
+}
+
+func normalizeDegrees(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
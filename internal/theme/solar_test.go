@@ -0,0 +1,73 @@
+package theme
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestSolarElevation(t *testing.T) {
+	noon := time.Date(2024, time.June, 21, 12, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 61.6, SolarElevation(noon, 51.48, 0), 1.0)
+
+	midnight := time.Date(2024, time.June, 21, 0, 0, 0, 0, time.UTC)
+	assert.InDelta(t, -15.0, SolarElevation(midnight, 51.48, 0), 1.0)
+}
+
+func TestIsDaylight(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		name     string
+		time     time.Time
+		lat, lon float64
+		expected bool
+	}{
+		{"berlin winter morning", time.Date(2024, time.January, 15, 9, 0, 0, 0, berlin), 52.52, 13.40, true},
+		{"berlin winter evening", time.Date(2024, time.January, 15, 17, 0, 0, 0, berlin), 52.52, 13.40, false},
+		{"berlin before sunrise", time.Date(2024, time.January, 15, 7, 30, 0, 0, berlin), 52.52, 13.40, false},
+		{"tromso polar night", time.Date(2024, time.December, 21, 12, 0, 0, 0, time.UTC), 69.65, 18.96, false},
+		{"tromso midnight sun", time.Date(2024, time.June, 21, 23, 0, 0, 0, time.UTC), 69.65, 18.96, true},
+		{"sydney summer noon", time.Date(2024, time.January, 15, 2, 0, 0, 0, time.UTC), -33.87, 151.21, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsDaylight(tt.time, tt.lat, tt.lon))
+		})
+	}
+}
+
+func TestInWindow(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, time.March, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	assert.True(t, InWindow(at(7, 0), 7*time.Hour, 19*time.Hour))
+	assert.True(t, InWindow(at(18, 59), 7*time.Hour, 19*time.Hour))
+	assert.False(t, InWindow(at(19, 0), 7*time.Hour, 19*time.Hour))
+	assert.False(t, InWindow(at(6, 59), 7*time.Hour, 19*time.Hour))
+
+	assert.True(t, InWindow(at(23, 0), 22*time.Hour, 6*time.Hour))
+	assert.True(t, InWindow(at(5, 0), 22*time.Hour, 6*time.Hour))
+	assert.False(t, InWindow(at(12, 0), 22*time.Hour, 6*time.Hour))
+}
+
+func TestSolar(t *testing.T) {
+	noon := time.Date(2024, time.June, 21, 12, 0, 0, 0, time.Local)
+	night := time.Date(2024, time.June, 21, 22, 0, 0, 0, time.Local)
+
+	schedule := config.ThemeSettings{Mode: config.ThemeModeSolar, LightFrom: "07:00", DarkFrom: "20:30"}
+	assert.Equal(t, Light, Solar(schedule, noon))
+	assert.Equal(t, Dark, Solar(schedule, night))
+
+	coords := config.ThemeSettings{Mode: config.ThemeModeSolar, Latitude: ptr(51.48), Longitude: ptr(0)}
+	assert.Equal(t, Light, Solar(coords, time.Date(2024, time.June, 21, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, Dark, Solar(coords, time.Date(2024, time.June, 21, 0, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, Light, Solar(config.ThemeSettings{Mode: config.ThemeModeSolar}, night))
+}
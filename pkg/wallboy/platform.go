@@ -0,0 +1,38 @@
+package wallboy
+
+import (
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+type Platform interface {
+	SetWallpaper(path string) error
+	Wallpaper() (string, error)
+	Theme() Theme
+}
+
+type platformAdapter struct {
+	platform.Platform
+	p Platform
+}
+
+func newPlatformAdapter(p Platform) *platformAdapter {
+	return &platformAdapter{Platform: platform.Unsupported("embedded"), p: p}
+}
+
+func (a *platformAdapter) IsSupported() bool                    { return true }
+func (a *platformAdapter) Wallpaper() platform.WallpaperService { return wallpaperAdapter{a.p} }
+func (a *platformAdapter) Theme() platform.ThemeService         { return themeAdapter{a.p} }
+
+type wallpaperAdapter struct{ p Platform }
+
+func (w wallpaperAdapter) Set(path string) error { return w.p.SetWallpaper(path) }
+func (w wallpaperAdapter) Get() (string, error)  { return w.p.Wallpaper() }
+
+type themeAdapter struct{ p Platform }
+
+func (t themeAdapter) Detect() platform.Theme {
+	if t.p.Theme() == ThemeDark {
+		return platform.ThemeDark
+	}
+	return platform.ThemeLight
+}
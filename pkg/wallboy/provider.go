@@ -0,0 +1,68 @@
+package wallboy
+
+import (
+	"context"
+
+	"github.com/Artawower/wallboy/internal/provider"
+)
+
+type Image struct {
+	ID          string
+	URL         string
+	DownloadURL string
+	Width       int
+	Height      int
+	Author      string
+}
+
+type Provider interface {
+	Name() string
+	Search(ctx context.Context, queries []string) ([]Image, error)
+	Download(ctx context.Context, image Image, dest string) (string, error)
+}
+
+type ProviderFactory func(name, auth string) Provider
+
+func adaptFactory(factory ProviderFactory) provider.Factory {
+	return func(name, auth string) provider.Provider {
+		if p := factory(name, auth); p != nil {
+			return providerAdapter{p}
+		}
+		return nil
+	}
+}
+
+type providerAdapter struct{ p Provider }
+
+func (a providerAdapter) Name() string { return a.p.Name() }
+
+func (a providerAdapter) Search(ctx context.Context, queries []string) ([]provider.ImageMeta, error) {
+	images, err := a.p.Search(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]provider.ImageMeta, len(images))
+	for i, img := range images {
+		metas[i] = provider.ImageMeta{
+			ID:          img.ID,
+			URL:         img.URL,
+			DownloadURL: img.DownloadURL,
+			Width:       img.Width,
+			Height:      img.Height,
+			Author:      img.Author,
+			Source:      a.p.Name(),
+		}
+	}
+	return metas, nil
+}
+
+func (a providerAdapter) Download(ctx context.Context, meta provider.ImageMeta, dest string) (string, error) {
+	return a.p.Download(ctx, Image{
+		ID:          meta.ID,
+		URL:         meta.URL,
+		DownloadURL: meta.DownloadURL,
+		Width:       meta.Width,
+		Height:      meta.Height,
+		Author:      meta.Author,
+	}, dest)
+}
@@ -0,0 +1,212 @@
+package wallboy
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Artawower/wallboy/internal/core"
+	"github.com/Artawower/wallboy/internal/datasource"
+	"github.com/Artawower/wallboy/internal/platform"
+)
+
+var (
+	ErrNoWallpaper = core.ErrNoWallpaper
+	ErrNoSources   = core.ErrNoSources
+	ErrNoImages    = datasource.ErrNoImages
+	ErrUnsupported = platform.ErrUnsupported
+)
+
+type Theme string
+
+const (
+	ThemeLight Theme = "light"
+	ThemeDark  Theme = "dark"
+)
+
+type Wallpaper struct {
+	Path      string
+	Theme     Theme
+	SourceID  string
+	Temporary bool
+	SetAt     time.Time
+	Query     string
+	Display   string
+}
+
+type Info struct {
+	Wallpaper
+	Exists bool
+	Rating int
+}
+
+type Source struct {
+	ID          string
+	Theme       Theme
+	Type        string
+	Description string
+}
+
+type Color struct {
+	R, G, B uint8
+	Share   float64
+}
+
+func (c Color) Hex() string {
+	return core.Color{R: c.R, G: c.G, B: c.B}.Hex()
+}
+
+type StateStore interface {
+	Read() ([]byte, error)
+	Write(data []byte) error
+}
+
+type HistoryStore interface {
+	Read() ([]byte, error)
+	Append(data []byte) error
+}
+
+type Option func(*options)
+
+type options struct {
+	configPath string
+	core       []core.Option
+}
+
+func WithConfigPath(path string) Option {
+	return func(o *options) { o.configPath = path }
+}
+
+func WithTheme(theme Theme) Option {
+	return func(o *options) { o.core = append(o.core, core.WithThemeOverride(string(theme))) }
+}
+
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) { o.core = append(o.core, core.WithLogger(logger)) }
+}
+
+func WithPlatform(p Platform) Option {
+	return func(o *options) { o.core = append(o.core, core.WithPlatform(newPlatformAdapter(p))) }
+}
+
+func WithProviderFactory(factory ProviderFactory) Option {
+	return func(o *options) { o.core = append(o.core, core.WithProviderFactory(adaptFactory(factory))) }
+}
+
+func WithStateStore(store StateStore) Option {
+	return func(o *options) { o.core = append(o.core, core.WithStateStore(store)) }
+}
+
+func WithHistoryStore(store HistoryStore) Option {
+	return func(o *options) { o.core = append(o.core, core.WithHistoryStore(store)) }
+}
+
+func WithClock(now func() time.Time) Option {
+	return func(o *options) { o.core = append(o.core, core.WithClock(now)) }
+}
+
+type Client struct {
+	engine *core.Engine
+}
+
+func New(opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	engine, err := core.New(o.configPath, o.core...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{engine: engine}, nil
+}
+
+func (c *Client) Next(ctx context.Context) (*Wallpaper, error) {
+	result, err := c.engine.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newWallpaper(result), nil
+}
+
+func (c *Client) Set(ctx context.Context, ref string) (*Wallpaper, error) {
+	result, err := c.engine.Set(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return newWallpaper(result), nil
+}
+
+func (c *Client) Save() (*Wallpaper, error) {
+	result, err := c.engine.Save()
+	if err != nil {
+		return nil, err
+	}
+	return newWallpaper(result), nil
+}
+
+func (c *Client) Delete(ctx context.Context) (*Wallpaper, error) {
+	result, err := c.engine.Delete(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newWallpaper(result), nil
+}
+
+func (c *Client) Info() (*Info, error) {
+	info, err := c.engine.Info()
+	if err != nil {
+		return nil, err
+	}
+	return &Info{
+		Wallpaper: Wallpaper{
+			Path:      info.Path,
+			Theme:     Theme(info.Theme),
+			SourceID:  info.SourceID,
+			Temporary: info.IsTemp,
+			SetAt:     info.SetAt,
+			Query:     info.Query,
+			Display:   info.Display,
+		},
+		Exists: info.Exists,
+		Rating: info.Rating,
+	}, nil
+}
+
+func (c *Client) Sources() []Source {
+	infos := c.engine.ListSources()
+	sources := make([]Source, len(infos))
+	for i, s := range infos {
+		sources[i] = Source{ID: s.ID, Theme: Theme(s.Theme), Type: s.Type, Description: s.Description}
+	}
+	return sources
+}
+
+func (c *Client) Palette(n int) ([]Color, error) {
+	analyzed, err := c.engine.AnalyzeColors(n)
+	if err != nil {
+		return nil, err
+	}
+	palette := make([]Color, len(analyzed))
+	for i, col := range analyzed {
+		palette[i] = Color{R: col.R, G: col.G, B: col.B, Share: col.Share}
+	}
+	return palette, nil
+}
+
+func (c *Client) Close() {
+	c.engine.WaitPrefetch()
+}
+
+func newWallpaper(r *core.WallpaperResult) *Wallpaper {
+	return &Wallpaper{
+		Path:      r.Path,
+		Theme:     Theme(r.Theme),
+		SourceID:  r.SourceID,
+		Temporary: r.IsTemp,
+		SetAt:     r.SetAt,
+		Query:     r.Query,
+		Display:   r.Display,
+	}
+}
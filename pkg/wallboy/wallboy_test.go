@@ -0,0 +1,213 @@
+package wallboy
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePlatform struct {
+	current string
+	theme   Theme
+}
+
+func (p *fakePlatform) SetWallpaper(path string) error {
+	p.current = path
+	return nil
+}
+
+func (p *fakePlatform) Wallpaper() (string, error) { return p.current, nil }
+func (p *fakePlatform) Theme() Theme               { return p.theme }
+
+type memoryStore struct {
+	data []byte
+}
+
+func (m *memoryStore) Read() ([]byte, error) { return m.data, nil }
+
+func (m *memoryStore) Write(data []byte) error {
+	m.data = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *memoryStore) Append(data []byte) error {
+	m.data = append(m.data, data...)
+	return nil
+}
+
+type fakeProvider struct {
+	searches int
+}
+
+func (p *fakeProvider) Name() string { return "wallhaven" }
+
+func (p *fakeProvider) Search(ctx context.Context, queries []string) ([]Image, error) {
+	p.searches++
+	return []Image{{ID: "abc123", Width: 8, Height: 8}}, nil
+}
+
+func (p *fakeProvider) Download(ctx context.Context, img Image, dest string) (string, error) {
+	if err := writePNG(dest, color.RGBA{B: 200, A: 255}); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func writePNG(path string, c color.Color) error {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0644))
+	return path
+}
+
+func TestClient_LocalSource(t *testing.T) {
+	dir := t.TempDir()
+	localDir := filepath.Join(dir, "dark")
+	require.NoError(t, os.MkdirAll(localDir, 0755))
+	require.NoError(t, writePNG(filepath.Join(localDir, "red.png"), color.RGBA{R: 200, A: 255}))
+
+	cfg := writeConfig(t, fmt.Sprintf(`
+[state]
+path = %q
+
+[theme]
+mode = "auto"
+
+[dark]
+dirs = [%q]
+upload-dir = %q
+`, filepath.Join(dir, "state.json"), localDir, filepath.Join(dir, "saved")))
+
+	p := &fakePlatform{theme: ThemeDark}
+	store := &memoryStore{}
+	historyStore := &memoryStore{}
+	now := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	client, err := New(WithConfigPath(cfg), WithPlatform(p), WithStateStore(store), WithHistoryStore(historyStore), WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Info()
+	assert.ErrorIs(t, err, ErrNoWallpaper)
+
+	wp, err := client.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(localDir, "red.png"), wp.Path)
+	assert.Equal(t, ThemeDark, wp.Theme)
+	assert.Equal(t, "dark-local-1", wp.SourceID)
+	assert.False(t, wp.Temporary)
+	assert.Equal(t, now, wp.SetAt)
+	assert.Equal(t, wp.Path, p.current)
+	assert.Contains(t, string(store.data), wp.Path)
+	assert.NoFileExists(t, filepath.Join(dir, "state.json"))
+	assert.Contains(t, string(historyStore.data), wp.Path)
+	assert.NoFileExists(t, filepath.Join(dir, "history.jsonl"))
+
+	info, err := client.Info()
+	require.NoError(t, err)
+	assert.Equal(t, wp.Path, info.Path)
+	assert.True(t, info.Exists)
+
+	palette, err := client.Palette(3)
+	require.NoError(t, err)
+	require.NotEmpty(t, palette)
+	assert.Equal(t, "#c80000", palette[0].Hex())
+	assert.InDelta(t, 1.0, palette[0].Share, 0.001)
+
+	sources := client.Sources()
+	require.Len(t, sources, 1)
+	assert.Equal(t, Source{ID: "dark-local-1", Theme: ThemeDark, Type: "local", Description: localDir}, sources[0])
+}
+
+func TestClient_ProviderFactory(t *testing.T) {
+	dir := t.TempDir()
+	cfg := writeConfig(t, fmt.Sprintf(`
+[state]
+path = %q
+
+[theme]
+mode = "light"
+
+[providers.wallhaven]
+auth = "key"
+
+[light]
+dirs = []
+upload-dir = %q
+queries = ["nature"]
+
+[dark]
+queries = ["space"]
+`, filepath.Join(dir, "state.json"), filepath.Join(dir, "saved")))
+
+	fake := &fakeProvider{}
+	var factoryCalls []string
+	client, err := New(
+		WithConfigPath(cfg),
+		WithPlatform(&fakePlatform{theme: ThemeLight}),
+		WithStateStore(&memoryStore{}),
+		WithProviderFactory(func(name, auth string) Provider {
+			factoryCalls = append(factoryCalls, name+":"+auth)
+			return fake
+		}),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.Equal(t, []string{"wallhaven:key"}, factoryCalls)
+
+	wp, err := client.Next(context.Background())
+	require.NoError(t, err)
+	assert.True(t, wp.Temporary)
+	assert.Equal(t, "light-wallhaven", wp.SourceID)
+	assert.GreaterOrEqual(t, fake.searches, 1)
+
+	saved, err := client.Save()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "saved"), filepath.Dir(saved.Path))
+	assert.FileExists(t, saved.Path)
+}
+
+func TestClient_NoImages(t *testing.T) {
+	dir := t.TempDir()
+	cfg := writeConfig(t, fmt.Sprintf(`
+[state]
+path = %q
+
+[theme]
+mode = "light"
+
+[light]
+dirs = [%q]
+`, filepath.Join(dir, "state.json"), dir))
+
+	client, err := New(WithConfigPath(cfg), WithPlatform(&fakePlatform{}), WithStateStore(&memoryStore{}))
+	require.NoError(t, err)
+
+	_, err = client.Next(context.Background())
+	assert.ErrorIs(t, err, ErrNoImages)
+}